@@ -3,15 +3,24 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/signal"
 	"runtime"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/mksmstpck/spy_cat_agency/internal/config"
 	"github.com/mksmstpck/spy_cat_agency/internal/db"
 	"github.com/mksmstpck/spy_cat_agency/internal/events"
 	"github.com/mksmstpck/spy_cat_agency/internal/handlers"
+	"github.com/mksmstpck/spy_cat_agency/internal/keyring"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
 	"github.com/mksmstpck/spy_cat_agency/internal/services"
+	"github.com/mksmstpck/spy_cat_agency/internal/storage"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
 	"github.com/sirupsen/logrus"
 )
 
@@ -34,23 +43,155 @@ func formatFilePath(path string) string {
 }
 
 func main() {
-	ctx := context.TODO()
+	os.Exit(run(os.Args[1:]))
+}
 
-	config := config.NewConfig()
+// run serves until SIGINT or SIGTERM, then drains requests, stops the
+// background workers and closes the pool, in that order. It returns the
+// exit code.
+func run(args []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	pgconn, err := pgxpool.New(ctx, config.PostgregUrl)
+	config, opts, err := config.Load(args)
 	if err != nil {
-		logrus.Error(err)
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		return 2
+	}
+
+	if opts.PrintConfig {
+		if err := config.Print(os.Stdout); err != nil {
+			logrus.Fatal(err)
+		}
+		return 0
+	}
+
+	level, _ := logrus.ParseLevel(config.LogLevel)
+	logrus.SetLevel(level)
+
+	shutdownTracing, err := tracing.Setup(ctx, config)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(config.PostgregUrl)
+	if err != nil {
+		logrus.Fatal(err)
 	}
+	poolConfig.MaxConns = config.DBMaxConns
+	poolConfig.MinConns = config.DBMinConns
+	poolConfig.ConnConfig.ConnectTimeout = config.DBConnectTimeout
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
 
-	db := db.NewDB(pgconn)
+	pgconn, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	notes, err := keyring.Parse(config.NotesKeys, config.NotesKeyID)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	index, err := keyring.NewIndex(config.NotesIndexKey)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	db := db.NewDB(pgconn, notes, index)
+
+	blobs, err := storage.New(config)
+	if err != nil {
+		logrus.Fatal(err)
+	}
 
-	services := services.NewServices(*db)
+	services := services.NewServices(*db, config, blobs)
 
-	if err := events.NewEvents(*services, config).LoadBreeds(ctx); err != nil {
+	if err := metrics.RegisterPool(pgconn); err != nil {
 		logrus.Error(err)
 	}
+	if err := metrics.RegisterBusiness(&services.Stats); err != nil {
+		logrus.Error(err)
+	}
+
+	// Fail readiness as soon as a signal arrives, before the HTTP server drains.
+	context.AfterFunc(ctx, services.Health.MarkStopping)
+
+	var workers sync.WaitGroup
+
+	events := events.NewEvents(*services, config)
+
+	// Serve probes while the breed import runs; /readyz stays red until it is done.
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		if config.BreedsLoadOnStart {
+			result, err := events.LoadBreeds(ctx)
+			if err != nil {
+				logrus.Error(err)
+			}
+			if result != nil {
+				logrus.Infof("Breeds loaded from %s: %d new, %d renamed, %d updated, %d removed, %d failed",
+					result.Source, result.Created, result.Renamed, result.Updated, result.Removed, result.Failed)
+			}
+		}
+		services.Health.MarkStarted()
+	}()
+
+	if config.BreedsSyncInterval > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			events.RunBreedSync(ctx, config.BreedsSyncInterval)
+		}()
+	}
+
+	if config.TrashPurgeInterval > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			events.RunTrashPurge(ctx, config.TrashPurgeInterval)
+		}()
+	}
 
 	handlers := handlers.NewHandlers(config, services)
-	handlers.HandleAll(ctx)
+	serveErr := handlers.HandleAll(ctx)
+	if serveErr != nil {
+		logrus.Error(serveErr)
+	}
+
+	// The server may have stopped on its own; make sure workers see cancellation too.
+	stop()
+	waitWorkers(&workers, config.WorkerShutdownTimeout)
+
+	pgconn.Close()
+	logrus.Info("Database pool closed")
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), config.WorkerShutdownTimeout)
+	if err := shutdownTracing(flushCtx); err != nil {
+		logrus.Errorf("Flushing spans: %s", err)
+	}
+	cancel()
+
+	logrus.Info("Shutdown complete")
+
+	if serveErr != nil {
+		return 1
+	}
+	return 0
+}
+
+func waitWorkers(workers *sync.WaitGroup, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logrus.Info("Background workers stopped")
+	case <-time.After(timeout):
+		logrus.Warnf("Background workers did not stop within %s", timeout)
+	}
 }
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+var agencyCommands = []command{
+	{name: "list", usage: "", run: agenciesList},
+	{name: "create", usage: "<slug> <name>", run: agenciesCreate},
+	{name: "settings", usage: "<slug> [-skill-check off|warn|block] [-require-evidence bool] [-attachment-max-bytes n] [-attachment-types a,b]", run: agenciesSettings},
+	{name: "breeds", usage: "<slug>", run: agenciesBreeds},
+	{name: "breed-set", usage: "<slug> <breed-id> [-name <name>] [-hidden]", run: agenciesBreedSet},
+	{name: "breed-reset", usage: "<slug> <breed-id>", run: agenciesBreedReset},
+}
+
+var agencyHeader = []string{"SLUG", "NAME", "SETTINGS"}
+
+func agencySettingsRow(s models.AgencySettings) string {
+	var parts []string
+	if s.SkillCheck != nil {
+		parts = append(parts, "skill_check="+*s.SkillCheck)
+	}
+	if s.RequireEvidence != nil {
+		parts = append(parts, "require_evidence="+strconv.FormatBool(*s.RequireEvidence))
+	}
+	if s.AttachmentMaxBytes != nil {
+		parts = append(parts, "attachment_max_bytes="+strconv.FormatInt(*s.AttachmentMaxBytes, 10))
+	}
+	if len(s.AttachmentTypes) > 0 {
+		parts = append(parts, "attachment_types="+strings.Join(s.AttachmentTypes, ","))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
+
+func agenciesList(ctx context.Context, a *app, args []string) error {
+	agencies, err := a.services.Agency.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(agencies))
+	for _, ag := range agencies {
+		rows = append(rows, []string{
+			ag.Slug,
+			ag.Name,
+			fmt.Sprint(ag.Cats),
+			fmt.Sprint(ag.ActiveMissions),
+			fmt.Sprint(ag.Missions),
+			fmt.Sprint(ag.Templates),
+			agencySettingsRow(ag.Settings),
+		})
+	}
+	return a.out.print(agencies, []string{"SLUG", "NAME", "CATS", "ACTIVE", "MISSIONS", "TEMPLATES", "SETTINGS"}, rows)
+}
+
+func agenciesCreate(ctx context.Context, a *app, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: agencies create <slug> <name>")
+	}
+
+	created, err := a.services.Agency.Create(ctx, models.Agency{Slug: args[0], Name: args[1]})
+	if err != nil {
+		return err
+	}
+	return a.out.print(created, agencyHeader, [][]string{{created.Slug, created.Name, agencySettingsRow(created.Settings)}})
+}
+
+// agenciesSettings changes only the settings given as flags; without flags
+// it shows the current ones. An empty value clears a setting.
+func agenciesSettings(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.New("slug argument is required")
+	}
+	slug := args[0]
+
+	fs := flag.NewFlagSet("agencies settings", flag.ContinueOnError)
+	skillCheck := fs.String("skill-check", "", "off, warn or block")
+	requireEvidence := fs.String("require-evidence", "", "true or false")
+	maxBytes := fs.String("attachment-max-bytes", "", "maximum attachment size in bytes")
+	types := fs.String("attachment-types", "", "comma-separated accepted content types")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	ag, err := a.services.Agency.GetBySlug(ctx, slug)
+	if err != nil {
+		return err
+	}
+
+	settings := ag.Settings
+	changed := false
+	var parseErr error
+	fs.Visit(func(f *flag.Flag) {
+		changed = true
+		switch f.Name {
+		case "skill-check":
+			settings.SkillCheck = nil
+			if *skillCheck != "" {
+				settings.SkillCheck = skillCheck
+			}
+		case "require-evidence":
+			settings.RequireEvidence = nil
+			if *requireEvidence != "" {
+				v, err := strconv.ParseBool(*requireEvidence)
+				if err != nil {
+					parseErr = fmt.Errorf("invalid -require-evidence %q", *requireEvidence)
+					return
+				}
+				settings.RequireEvidence = &v
+			}
+		case "attachment-max-bytes":
+			settings.AttachmentMaxBytes = nil
+			if *maxBytes != "" {
+				v, err := strconv.ParseInt(*maxBytes, 10, 64)
+				if err != nil {
+					parseErr = fmt.Errorf("invalid -attachment-max-bytes %q", *maxBytes)
+					return
+				}
+				settings.AttachmentMaxBytes = &v
+			}
+		case "attachment-types":
+			settings.AttachmentTypes = nil
+			for _, t := range strings.Split(*types, ",") {
+				if t = strings.TrimSpace(t); t != "" {
+					settings.AttachmentTypes = append(settings.AttachmentTypes, t)
+				}
+			}
+		}
+	})
+	if parseErr != nil {
+		return parseErr
+	}
+
+	if changed {
+		if ag, err = a.services.Agency.UpdateSettings(ctx, slug, settings); err != nil {
+			return err
+		}
+	}
+	return a.out.print(ag, agencyHeader, [][]string{{ag.Slug, ag.Name, agencySettingsRow(ag.Settings)}})
+}
+
+func agenciesBreeds(ctx context.Context, a *app, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: agencies breeds <slug>")
+	}
+
+	overrides, err := a.services.Agency.GetBreedOverrides(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(overrides))
+	for _, o := range overrides {
+		name := "-"
+		if o.Name != nil {
+			name = *o.Name
+		}
+		rows = append(rows, []string{o.BreedID.String(), name, strconv.FormatBool(o.Hidden)})
+	}
+	return a.out.print(overrides, []string{"BREED", "NAME", "HIDDEN"}, rows)
+}
+
+func agenciesBreedSet(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.New("slug argument is required")
+	}
+	slug := args[0]
+	breedID, rest, err := splitID(args[1:])
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("agencies breed-set", flag.ContinueOnError)
+	name := fs.String("name", "", "name the agency sees for the breed")
+	hidden := fs.Bool("hidden", false, "hide the breed from the agency")
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+
+	override := models.BreedOverride{BreedID: breedID, Hidden: *hidden}
+	if *name != "" {
+		override.Name = name
+	}
+	if _, err := a.services.Agency.SetBreedOverride(ctx, slug, override); err != nil {
+		return err
+	}
+	return a.out.done("breed override set")
+}
+
+func agenciesBreedReset(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.New("slug argument is required")
+	}
+	slug := args[0]
+	breedID, _, err := splitID(args[1:])
+	if err != nil {
+		return err
+	}
+
+	if err := a.services.Agency.DeleteBreedOverride(ctx, slug, breedID); err != nil {
+		return err
+	}
+	return a.out.done("breed override removed")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/principal"
+)
+
+// classifiedRead is a classified object scactl is about to print.
+type classifiedRead struct {
+	kind  string
+	id    uuid.UUID
+	level models.Classification
+}
+
+// reads lists the classified missions, targets and search hits in v, the
+// objects the API would audit in a response.
+func reads(v any) []classifiedRead {
+	var found []classifiedRead
+	add := func(kind string, id uuid.UUID, level models.Classification) {
+		if level != "" && level != models.Unclassified {
+			found = append(found, classifiedRead{kind, id, level})
+		}
+	}
+	addMission := func(m *models.Mission) {
+		add("mission", m.ID, m.Classification)
+		for _, t := range m.Targets {
+			add("target", t.ID, t.Classification)
+		}
+	}
+
+	switch v := v.(type) {
+	case *models.Mission:
+		addMission(v)
+	case []models.Mission:
+		for i := range v {
+			addMission(&v[i])
+		}
+	case *models.Target:
+		add("target", v.ID, v.Classification)
+	case []models.NearbyTarget:
+		for _, t := range v {
+			add("target", t.ID, t.Classification)
+		}
+	case []models.SearchHit:
+		for _, h := range v {
+			add(h.Kind, h.ID, h.Classification)
+		}
+	}
+	return found
+}
+
+// print audits the classified objects in v under the operator's name, then
+// prints v. Nothing is printed if the audit trail cannot be written.
+func (a *app) print(ctx context.Context, v any, header []string, rows [][]string) error {
+	if err := a.audit(ctx, reads(v)); err != nil {
+		return err
+	}
+	return a.out.print(v, header, rows)
+}
+
+func (a *app) audit(ctx context.Context, found []classifiedRead) error {
+	p := principal.From(ctx)
+	entries := make([]models.AuditEntry, 0, len(found))
+	for _, r := range found {
+		entries = append(entries, models.AuditEntry{
+			Principal:      p.Name,
+			Clearance:      p.Clearance,
+			Kind:           r.kind,
+			ObjectID:       r.id,
+			Classification: r.level,
+			Outcome:        models.AuditRead,
+			Path:           a.command,
+		})
+	}
+	if err := a.services.Audit.Record(ctx, entries); err != nil {
+		return fmt.Errorf("record classified access: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+func TestReadsListsClassifiedObjects(t *testing.T) {
+	missionID := uuid.MustParse("3c9d8f7e-52a4-4b1e-8f0c-6d2a1b9e4f37")
+	targetID := uuid.MustParse("8d2e4b6a-1c3f-4e5d-9a7b-0f1e2d3c4b5a")
+
+	tests := []struct {
+		name string
+		v    any
+		want []classifiedRead
+	}{
+		{"unclassified mission", &models.Mission{ID: missionID, Classification: models.Unclassified}, nil},
+		{"mission with a classified target", []models.Mission{{
+			ID:             missionID,
+			Classification: models.Unclassified,
+			Targets:        []models.Target{{ID: targetID, Classification: models.Secret}},
+		}}, []classifiedRead{{"target", targetID, models.Secret}}},
+		{"classified mission", &models.Mission{ID: missionID, Classification: models.TopSecret},
+			[]classifiedRead{{"mission", missionID, models.TopSecret}}},
+		{"nearby target", []models.NearbyTarget{{Target: models.Target{ID: targetID, Classification: models.Confidential}}},
+			[]classifiedRead{{"target", targetID, models.Confidential}}},
+		{"search hit", []models.SearchHit{{Kind: "mission", ID: missionID, Classification: models.Secret}},
+			[]classifiedRead{{"mission", missionID, models.Secret}}},
+		{"anything else", []models.SpyCat{{Clearance: models.TopSecret}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reads(tt.v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+var catCommands = []command{
+	{name: "list", usage: "[-trait <expr>]... [-status <status>|all]...", run: catsList},
+	{name: "get", usage: "<id>", run: catsGet},
+	{name: "create", usage: "-name <name> -breed <breed> [-exp <years>] [-salary <amount>]", run: catsCreate},
+	{name: "update", usage: "<id> [-salary <amount>] [-exp <years>]", run: catsUpdate},
+	{name: "missions", usage: "<id>", run: catsMissions},
+	{name: "status", usage: "<id> active|suspended", run: catsStatus},
+	{name: "retire", usage: "<id> -reason <text> [-replacement <cat-id>]", run: catsRetire},
+	{name: "clearance", usage: "<id> <level>", run: catsClearance},
+}
+
+func catRows(cats []models.SpyCat) [][]string {
+	rows := make([][]string, 0, len(cats))
+	for _, cat := range cats {
+		rows = append(rows, []string{
+			cat.ID.String(),
+			cat.Name,
+			cat.Breed.Name,
+			fmt.Sprint(cat.ExpYears),
+			fmt.Sprintf("%.2f", cat.Salary),
+			cat.Status,
+		})
+	}
+	return rows
+}
+
+var catHeader = []string{"ID", "NAME", "BREED", "EXPERIENCE", "SALARY", "STATUS"}
+
+func catsList(ctx context.Context, a *app, args []string) error {
+	var filter models.CatFilter
+
+	fs := flag.NewFlagSet("cats list", flag.ContinueOnError)
+	fs.Func("trait", `breed trait filter such as "origin=Egypt" or "intelligence>=4", repeatable`, func(expr string) error {
+		trait, err := models.ParseBreedTraitFilter(expr)
+		if err != nil {
+			return err
+		}
+		filter.Traits = append(filter.Traits, trait)
+		return nil
+	})
+	fs.Func("status", "cat status to list, repeatable, or all; retired cats are hidden by default", func(status string) error {
+		if status == "all" {
+			filter.Statuses = models.CatStatuses
+			return nil
+		}
+		filter.Statuses = append(filter.Statuses, status)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cats, err := a.services.SpyCat.GetAll(ctx, filter)
+	if err != nil {
+		return err
+	}
+	return a.out.print(cats, catHeader, catRows(cats))
+}
+
+func catsGet(ctx context.Context, a *app, args []string) error {
+	id, _, err := splitID(args)
+	if err != nil {
+		return err
+	}
+
+	cat, err := a.services.SpyCat.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.out.print(cat, catHeader, catRows([]models.SpyCat{*cat}))
+}
+
+func catsCreate(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("cats create", flag.ContinueOnError)
+	name := fs.String("name", "", "cat name")
+	breedName := fs.String("breed", "", "breed name")
+	exp := fs.Int("exp", 0, "years of experience")
+	salary := fs.Float64("salary", 0, "salary")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cat := models.SpyCat{
+		Name:     *name,
+		ExpYears: *exp,
+		Salary:   float32(*salary),
+	}
+	if name := strings.TrimSpace(*breedName); name != "" {
+		breed, err := a.services.Breed.GetByName(ctx, name)
+		if err != nil {
+			return fmt.Errorf("breed %q: %w", name, err)
+		}
+		if breed == nil {
+			return fmt.Errorf("unknown breed %q", name)
+		}
+		cat.Breed = *breed
+	}
+
+	created, err := a.services.SpyCat.Create(ctx, cat)
+	if err != nil {
+		return err
+	}
+	return a.out.print(created, catHeader, catRows([]models.SpyCat{*created}))
+}
+
+func catsUpdate(ctx context.Context, a *app, args []string) error {
+	id, rest, err := splitID(args)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("cats update", flag.ContinueOnError)
+	salary := fs.Float64("salary", -1, "new salary")
+	exp := fs.Int("exp", -1, "new years of experience")
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if len(set) == 0 {
+		return errors.New("nothing to update: pass -salary and/or -exp")
+	}
+
+	if set["salary"] {
+		if err := a.services.SpyCat.UpdateSalary(ctx, id, float32(*salary)); err != nil {
+			return err
+		}
+	}
+	if set["exp"] {
+		if err := a.services.SpyCat.UpdateExperience(ctx, id, *exp); err != nil {
+			return err
+		}
+	}
+
+	return catsGet(ctx, a, []string{id.String()})
+}
+
+func catsClearance(ctx context.Context, a *app, args []string) error {
+	id, rest, err := splitID(args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return errors.New("usage: cats clearance <id> <level>")
+	}
+
+	if err := a.services.SpyCat.UpdateClearance(ctx, id, models.Classification(rest[0])); err != nil {
+		return err
+	}
+	return a.out.done("clearance updated")
+}
+
+func catsMissions(ctx context.Context, a *app, args []string) error {
+	id, _, err := splitID(args)
+	if err != nil {
+		return err
+	}
+
+	career, err := a.services.Mission.GetCareer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(career))
+	for _, entry := range career {
+		unassigned := "-"
+		if entry.UnassignedAt != nil {
+			unassigned = entry.UnassignedAt.Format(time.DateOnly)
+		}
+		rows = append(rows, []string{
+			entry.MissionID.String(),
+			entry.MissionTitle,
+			entry.Role,
+			entry.AssignedAt.Format(time.DateOnly),
+			unassigned,
+			(time.Duration(entry.DurationSeconds) * time.Second).String(),
+			fmt.Sprint(entry.MissionCompleted),
+		})
+	}
+	return a.out.print(career, []string{"MISSION", "TITLE", "ROLE", "ASSIGNED", "UNASSIGNED", "DURATION", "COMPLETED"}, rows)
+}
+
+func catsStatus(ctx context.Context, a *app, args []string) error {
+	id, rest, err := splitID(args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return errors.New("usage: cats status <id> active|suspended")
+	}
+
+	if err := a.services.SpyCat.UpdateStatus(ctx, id, rest[0]); err != nil {
+		return err
+	}
+	return catsGet(ctx, a, []string{id.String()})
+}
+
+func catsRetire(ctx context.Context, a *app, args []string) error {
+	id, rest, err := splitID(args)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("cats retire", flag.ContinueOnError)
+	reason := fs.String("reason", "", "why the cat is retired")
+	replacement := fs.String("replacement", "", "cat taking over the retiring cat's active missions")
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+
+	var replacementID *uuid.UUID
+	if *replacement != "" {
+		parsed, err := uuid.Parse(*replacement)
+		if err != nil {
+			return fmt.Errorf("invalid replacement cat id: %w", err)
+		}
+		replacementID = &parsed
+	}
+
+	if err := a.services.SpyCat.Retire(ctx, id, *reason, a.actor, replacementID); err != nil {
+		return err
+	}
+	return catsGet(ctx, a, []string{id.String()})
+}
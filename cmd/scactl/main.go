@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/events"
+	"github.com/mksmstpck/spy_cat_agency/internal/keyring"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/principal"
+	"github.com/mksmstpck/spy_cat_agency/internal/services"
+	"github.com/mksmstpck/spy_cat_agency/internal/storage"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
+	"github.com/sirupsen/logrus"
+)
+
+type app struct {
+	services *services.Services
+	events   *events.Events
+	out      *printer
+	// actor is recorded as "by" in assignment history, and as the
+	// principal of the classified reads in the audit trail.
+	actor string
+	// command is the command being run, the path of its audit entries.
+	command string
+}
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, a *app, args []string) error
+}
+
+var resources = map[string][]command{
+	"cats":       catCommands,
+	"missions":   missionCommands,
+	"targets":    targetCommands,
+	"breeds":     breedCommands,
+	"report":     reportCommands,
+	"trash":      trashCommands,
+	"templates":  templateCommands,
+	"agencies":   agencyCommands,
+	"notes":      notesCommands,
+	"principals": principalCommands,
+	"audit":      auditCommands,
+}
+
+// crossAgency resources work on every agency at once and ignore -agency.
+var crossAgency = map[string]bool{
+	"agencies": true,
+	"notes":    true,
+}
+
+func main() {
+	logrus.SetLevel(logrus.WarnLevel)
+
+	fs := flag.NewFlagSet("scactl", flag.ExitOnError)
+	configFile := fs.String("config", "", "path to a YAML config file (default CONFIG_FILE)")
+	dbURL := fs.String("db", "", "postgres connection url (default POSTGRES_URL)")
+	output := fs.String("o", "table", "output format: table or json")
+	agencySlug := fs.String("agency", envOr("SCA_AGENCY", "default"), "slug of the agency to act for")
+	fs.Usage = usage
+	fs.Parse(os.Args[1:])
+
+	args := fs.Args()
+	if len(args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := findCommand(args[0], args[1])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", strings.Join(args[:2], " "))
+		usage()
+		os.Exit(2)
+	}
+
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "unknown output format %q\n", *output)
+		os.Exit(2)
+	}
+
+	// The configuration is read like the service's: file, then
+	// environment, then -config and -db, and validated as a whole.
+	var loadArgs []string
+	if *configFile != "" {
+		loadArgs = append(loadArgs, "-config", *configFile)
+	}
+	if *dbURL != "" {
+		loadArgs = append(loadArgs, "-postgres-url", *dbURL)
+	}
+	cfg, _, err := config.Load(loadArgs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+
+	pgconn, err := pgxpool.New(ctx, cfg.PostgregUrl)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer pgconn.Close()
+
+	if err := pgconn.Ping(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	blobs, err := storage.New(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	// Validate has checked the keys already.
+	notes, err := keyring.Parse(cfg.NotesKeys, cfg.NotesKeyID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	index, err := keyring.NewIndex(cfg.NotesIndexKey)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	services := services.NewServices(*db.NewDB(pgconn, notes, index), cfg, blobs)
+
+	a := &app{
+		services: services,
+		events:   events.NewEvents(*services, cfg),
+		out:      newPrinter(os.Stdout, *output),
+		actor:    "scactl:" + os.Getenv("USER"),
+		command:  "scactl " + args[0] + " " + args[1],
+	}
+
+	if !crossAgency[args[0]] {
+		agency, err := services.Agency.GetBySlug(ctx, *agencySlug)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "agency %q: %s\n", *agencySlug, err)
+			os.Exit(1)
+		}
+		ctx = tenant.WithAgency(ctx, *agency)
+	}
+	// scactl already holds the database credentials, so it reads and
+	// writes at every classification. Classified reads are audited.
+	ctx = principal.With(ctx, models.Principal{Name: a.actor, Clearance: models.TopSecret})
+
+	if err := cmd.run(ctx, a, args[2:]); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+}
+
+// printError writes err to stderr, one line per invalid field.
+func printError(err error) {
+	invalid, ok := validate.As(err)
+	if !ok {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, strings.TrimSuffix(err.Error(), invalid.Error())+"invalid input:")
+	for _, v := range invalid {
+		fmt.Fprintln(os.Stderr, "  "+v.String())
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func findCommand(resource, name string) (command, bool) {
+	for _, cmd := range resources[resource] {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: scactl [-config file] [-db url] [-o table|json] [-agency slug] <resource> <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, cmd := range resources[name] {
+			fmt.Fprintf(os.Stderr, "  %s %s %s\n", name, cmd.name, cmd.usage)
+		}
+	}
+}
@@ -0,0 +1,548 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+var missionCommands = []command{
+	{name: "list", usage: "", run: missionsList},
+	{name: "get", usage: "<id>", run: missionsGet},
+	{name: "create", usage: "-f <file.yaml>", run: missionsCreate},
+	{name: "assign", usage: "<mission-id> <cat-id|none>", run: missionsAssign},
+	{name: "restore", usage: "<id>", run: missionsRestore},
+	{name: "search", usage: "<text> [-kind mission|target] [-status active|completed] [-cat id] [-limit n]", run: missionsSearch},
+	{name: "route", usage: "<id> [-from lat,lon]", run: missionsRoute},
+	{name: "classify", usage: "<id> <level>", run: missionsClassify},
+}
+
+var targetCommands = []command{
+	{name: "complete", usage: "<id>", run: targetsComplete},
+	{name: "restore", usage: "<id>", run: targetsRestore},
+	{name: "country", usage: "<id> <country>", run: targetsCountry},
+	{name: "nearby", usage: "-lat <deg> -lon <deg> -radius <km> [-limit n]", run: targetsNearby},
+	{name: "attach", usage: "<id> -f <file>", run: targetsAttach},
+	{name: "attachments", usage: "<id>", run: targetsAttachments},
+	{name: "download", usage: "<id> <attachment-id> -o <file|->", run: targetsDownload},
+	{name: "classify", usage: "<id> <level>", run: targetsClassify},
+}
+
+var missionHeader = []string{"ID", "TITLE", "ASSIGNED CAT", "TARGETS", "COMPLETED"}
+
+func missionRows(missions []models.Mission) [][]string {
+	rows := make([][]string, 0, len(missions))
+	for _, mission := range missions {
+		cat := "-"
+		if mission.AssignedCatID != nil {
+			cat = mission.AssignedCatID.String()
+		}
+
+		done := 0
+		for _, target := range mission.Targets {
+			if target.Completed {
+				done++
+			}
+		}
+
+		rows = append(rows, []string{
+			mission.ID.String(),
+			mission.Title,
+			cat,
+			fmt.Sprintf("%d/%d", done, len(mission.Targets)),
+			fmt.Sprint(mission.Completed),
+		})
+	}
+	return rows
+}
+
+func missionsList(ctx context.Context, a *app, args []string) error {
+	missions, err := a.services.Mission.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(ctx, missions, missionHeader, missionRows(missions))
+}
+
+func missionsGet(ctx context.Context, a *app, args []string) error {
+	id, _, err := splitID(args)
+	if err != nil {
+		return err
+	}
+
+	mission, err := a.services.Mission.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if mission == nil {
+		return fmt.Errorf("mission %s not found", id)
+	}
+
+	rows := make([][]string, 0, len(mission.Targets))
+	for _, target := range mission.Targets {
+		rows = append(rows, []string{
+			target.ID.String(),
+			target.Name,
+			target.Country,
+			fmt.Sprint(target.Completed),
+		})
+	}
+	return a.print(ctx, mission, []string{"TARGET ID", "NAME", "COUNTRY", "COMPLETED"}, rows)
+}
+
+// missionFile is the YAML layout accepted by "missions create". A file may hold
+// several missions as separate YAML documents.
+type missionFile struct {
+	Title         string     `yaml:"title"`
+	Description   *string    `yaml:"description"`
+	AssignedCatID *uuid.UUID `yaml:"assigned_cat_id"`
+	// ScheduledStart and ScheduledEnd are YYYY-MM-DD days.
+	ScheduledStart *time.Time `yaml:"scheduled_start"`
+	ScheduledEnd   *time.Time `yaml:"scheduled_end"`
+	Targets        []struct {
+		Name      string   `yaml:"name"`
+		Country   string   `yaml:"country"`
+		Latitude  *float64 `yaml:"latitude"`
+		Longitude *float64 `yaml:"longitude"`
+		City      string   `yaml:"city"`
+		Address   string   `yaml:"address"`
+		Notes     string   `yaml:"notes"`
+		// RequiredSkills maps skill codes to the minimum proficiency.
+		RequiredSkills map[string]int `yaml:"required_skills"`
+	} `yaml:"targets"`
+}
+
+func missionsCreate(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("missions create", flag.ContinueOnError)
+	file := fs.String("f", "", "path to a mission YAML file, - for stdin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-f is required")
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var created []models.Mission
+	dec := yaml.NewDecoder(r)
+	for n := 1; ; n++ {
+		var in missionFile
+		if err := dec.Decode(&in); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+
+		mission := models.Mission{
+			Title:          in.Title,
+			Description:    in.Description,
+			AssignedCatID:  in.AssignedCatID,
+			ScheduledStart: in.ScheduledStart,
+			ScheduledEnd:   in.ScheduledEnd,
+		}
+
+		targets := make([]models.Target, len(in.Targets))
+		for i, target := range in.Targets {
+			targets[i] = models.Target{
+				Name:      target.Name,
+				Country:   target.Country,
+				Latitude:  target.Latitude,
+				Longitude: target.Longitude,
+				City:      target.City,
+				Address:   target.Address,
+				Notes:     target.Notes,
+			}
+			for code, level := range target.RequiredSkills {
+				targets[i].RequiredSkills = append(targets[i].RequiredSkills, models.SkillRequirement{
+					Skill:          models.Skill{Code: code},
+					MinProficiency: level,
+				})
+			}
+		}
+
+		m, err := a.services.Mission.Create(ctx, mission, targets, a.actor)
+		if err != nil {
+			return fmt.Errorf("mission %d: %w", n, err)
+		}
+		created = append(created, *m)
+	}
+
+	return a.print(ctx, created, missionHeader, missionRows(created))
+}
+
+func missionsAssign(ctx context.Context, a *app, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: missions assign <mission-id> <cat-id|none>")
+	}
+
+	missionID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid mission id: %w", err)
+	}
+
+	var catID *uuid.UUID
+	if args[1] != "none" {
+		id, err := uuid.Parse(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid cat id: %w", err)
+		}
+		catID = &id
+	}
+
+	coverage, err := a.services.Mission.UpdateAssignedCat(ctx, missionID, catID, a.actor, "")
+	if err != nil {
+		return err
+	}
+	if coverage != nil && !coverage.Complete {
+		fmt.Fprintf(os.Stderr, "warning: cat covers %d of %d required skills\n", coverage.Covered, coverage.Total)
+	}
+	return a.out.done("assigned")
+}
+
+func targetsComplete(ctx context.Context, a *app, args []string) error {
+	id, _, err := splitID(args)
+	if err != nil {
+		return err
+	}
+
+	if err := a.services.Target.UpdateCompleted(ctx, id, true); err != nil {
+		return err
+	}
+	return a.out.done("completed")
+}
+
+func missionsClassify(ctx context.Context, a *app, args []string) error {
+	id, rest, err := splitID(args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return errors.New("usage: missions classify <id> <level>")
+	}
+
+	if err := a.services.Mission.UpdateClassification(ctx, id, models.Classification(rest[0])); err != nil {
+		return err
+	}
+	return a.out.done("classified")
+}
+
+func targetsClassify(ctx context.Context, a *app, args []string) error {
+	id, rest, err := splitID(args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return errors.New("usage: targets classify <id> <level>")
+	}
+
+	if err := a.services.Target.UpdateClassification(ctx, id, models.Classification(rest[0])); err != nil {
+		return err
+	}
+	return a.out.done("classified")
+}
+
+func missionsRestore(ctx context.Context, a *app, args []string) error {
+	id, _, err := splitID(args)
+	if err != nil {
+		return err
+	}
+
+	mission, err := a.services.Mission.Restore(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.print(ctx, mission, missionHeader, missionRows([]models.Mission{*mission}))
+}
+
+func missionsSearch(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.New("search text is required")
+	}
+	query := models.SearchQuery{Text: args[0]}
+
+	fs := flag.NewFlagSet("missions search", flag.ContinueOnError)
+	fs.StringVar(&query.Kind, "kind", "", "only mission or target hits")
+	status := fs.String("status", "", "only active or completed items")
+	cat := fs.String("cat", "", "only missions the cat is on, and their targets")
+	fs.IntVar(&query.Limit, "limit", 0, "maximum number of hits")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch *status {
+	case "":
+	case "active", "completed":
+		completed := *status == "completed"
+		query.Completed = &completed
+	default:
+		return fmt.Errorf("invalid status %q: must be active or completed", *status)
+	}
+	if *cat != "" {
+		catID, err := uuid.Parse(*cat)
+		if err != nil {
+			return fmt.Errorf("invalid cat id: %w", err)
+		}
+		query.CatID = &catID
+	}
+
+	hits, err := a.services.Search.Search(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(hits))
+	for _, hit := range hits {
+		rows = append(rows, []string{
+			hit.Kind,
+			hit.ID.String(),
+			hit.Title,
+			fmt.Sprintf("%.3f", hit.Rank),
+			hit.Snippet,
+		})
+	}
+	return a.print(ctx, hits, []string{"KIND", "ID", "TITLE", "RANK", "SNIPPET"}, rows)
+}
+
+func targetsRestore(ctx context.Context, a *app, args []string) error {
+	id, _, err := splitID(args)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.services.Target.Restore(ctx, id); err != nil {
+		return err
+	}
+	return a.out.done("restored")
+}
+
+func targetsCountry(ctx context.Context, a *app, args []string) error {
+	id, rest, err := splitID(args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return errors.New("usage: targets country <id> <country>")
+	}
+
+	if err := a.services.Target.UpdateCountry(ctx, id, rest[0]); err != nil {
+		return err
+	}
+	return a.out.done("updated")
+}
+
+func missionsRoute(ctx context.Context, a *app, args []string) error {
+	id, rest, err := splitID(args)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("missions route", flag.ContinueOnError)
+	from := fs.String("from", "", "start point as lat,lon")
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+
+	var fromLat, fromLon *float64
+	if *from != "" {
+		lat, lon, ok := strings.Cut(*from, ",")
+		la, errLat := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+		lo, errLon := strconv.ParseFloat(strings.TrimSpace(lon), 64)
+		if !ok || errLat != nil || errLon != nil {
+			return fmt.Errorf("invalid -from %q: want lat,lon", *from)
+		}
+		fromLat, fromLon = &la, &lo
+	}
+
+	route, err := a.services.Mission.Route(ctx, id, fromLat, fromLon)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(route.Stops)+1)
+	for i, stop := range route.Stops {
+		rows = append(rows, []string{
+			fmt.Sprint(i + 1),
+			stop.TargetID.String(),
+			stop.Name,
+			fmt.Sprintf("%.4f,%.4f", stop.Latitude, stop.Longitude),
+			fmt.Sprintf("%.1f", stop.LegKm),
+		})
+	}
+	rows = append(rows, []string{"", "", "total", "", fmt.Sprintf("%.1f", route.TotalKm)})
+	if len(route.Unlocated) > 0 {
+		fmt.Fprintf(os.Stderr, "%d target(s) have no location and are not on the route\n", len(route.Unlocated))
+	}
+	return a.out.print(route, []string{"#", "TARGET", "NAME", "LOCATION", "LEG KM"}, rows)
+}
+
+func targetsNearby(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("targets nearby", flag.ContinueOnError)
+	lat := fs.Float64("lat", 0, "latitude of the point")
+	lon := fs.Float64("lon", 0, "longitude of the point")
+	radius := fs.Float64("radius", 0, "search radius in km")
+	limit := fs.Int("limit", 0, "maximum number of targets")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	targets, err := a.services.Target.Nearby(ctx, *lat, *lon, *radius, *limit)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(targets))
+	for _, target := range targets {
+		rows = append(rows, []string{
+			target.ID.String(),
+			target.MissionID.String(),
+			target.Name,
+			target.City,
+			target.Country,
+			fmt.Sprintf("%.1f", target.DistanceKm),
+		})
+	}
+	return a.print(ctx, targets, []string{"ID", "MISSION", "NAME", "CITY", "COUNTRY", "KM"}, rows)
+}
+
+func targetsAttach(ctx context.Context, a *app, args []string) error {
+	id, rest, err := splitID(args)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("targets attach", flag.ContinueOnError)
+	file := fs.String("f", "", "path to the file to attach")
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-f is required")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	attachment, err := a.services.Attachment.Create(ctx, id, filepath.Base(*file), f, a.actor)
+	if err != nil {
+		return err
+	}
+	return a.out.print(attachment, attachmentHeader, attachmentRows([]models.Attachment{*attachment}))
+}
+
+var attachmentHeader = []string{"ID", "FILENAME", "TYPE", "BYTES", "SHA256", "BY"}
+
+func attachmentRows(attachments []models.Attachment) [][]string {
+	rows := make([][]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		rows = append(rows, []string{
+			attachment.ID.String(),
+			attachment.Filename,
+			attachment.ContentType,
+			fmt.Sprint(attachment.SizeBytes),
+			attachment.SHA256,
+			attachment.UploadedBy,
+		})
+	}
+	return rows
+}
+
+func targetsAttachments(ctx context.Context, a *app, args []string) error {
+	id, _, err := splitID(args)
+	if err != nil {
+		return err
+	}
+
+	target, err := a.services.Target.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return fmt.Errorf("target %s not found", id)
+	}
+	attachments, err := a.services.Attachment.GetByTarget(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Attachments are classified with their target, as a whole.
+	if err := a.audit(ctx, reads(target)); err != nil {
+		return err
+	}
+	return a.out.print(attachments, attachmentHeader, attachmentRows(attachments))
+}
+
+func targetsDownload(ctx context.Context, a *app, args []string) error {
+	targetID, rest, err := splitID(args)
+	if err != nil {
+		return err
+	}
+	id, rest, err := splitID(rest)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("targets download", flag.ContinueOnError)
+	out := fs.String("o", "", "file to write, - for stdout; defaults to the attachment's name")
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+
+	attachment, content, err := a.services.Attachment.Open(ctx, targetID, id)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		name := *out
+		if name == "" {
+			name = attachment.Filename
+		}
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	_, err = io.Copy(w, content)
+	return err
+}
+
+// splitID takes a leading UUID argument off args.
+func splitID(args []string) (uuid.UUID, []string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return uuid.Nil, nil, errors.New("id argument is required")
+	}
+
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("invalid id %q: %w", args[0], err)
+	}
+	return id, args[1:], nil
+}
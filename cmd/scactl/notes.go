@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+)
+
+var notesCommands = []command{
+	{name: "keys", usage: "", run: notesKeys},
+	{name: "rotate", usage: "[-batch 500]", run: notesRotate},
+	{name: "reindex", usage: "[-batch 500]", run: notesReindex},
+}
+
+func notesKeys(ctx context.Context, a *app, args []string) error {
+	usage, err := a.services.Target.NotesKeyUsage(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(usage))
+	for _, u := range usage {
+		key := u.KeyID
+		if key == "" {
+			key = "(plain text)"
+		}
+		rows = append(rows, []string{key, fmt.Sprint(u.Targets), fmt.Sprint(u.Templates)})
+	}
+	return a.out.print(usage, []string{"KEY", "TARGETS", "TEMPLATES"}, rows)
+}
+
+// notesRotate moves every note, template placeholders included, to
+// NOTES_KEY_ID, encrypting any still in plain text. Keys that "notes keys" no longer lists can then be removed.
+func notesRotate(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("notes rotate", flag.ContinueOnError)
+	batch := fs.Int("batch", 500, "notes re-encrypted per transaction")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	n, err := a.services.Target.RotateNotes(ctx, *batch)
+	if err != nil {
+		return fmt.Errorf("re-encrypted the notes of %d targets and templates before failing: %w", n, err)
+	}
+	return a.out.done(fmt.Sprintf("re-encrypted the notes of %d targets and templates", n))
+}
+
+// notesReindex rebuilds the blind index search finds notes by, after an
+// upgrade or a new NOTES_INDEX_KEY.
+func notesReindex(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("notes reindex", flag.ContinueOnError)
+	batch := fs.Int("batch", 500, "notes reindexed per transaction")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	n, err := a.services.Target.ReindexNotes(ctx, *batch)
+	if err != nil {
+		return fmt.Errorf("reindexed %d notes before failing: %w", n, err)
+	}
+	return a.out.done(fmt.Sprintf("reindexed %d notes", n))
+}
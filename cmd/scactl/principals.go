@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+var principalCommands = []command{
+	{name: "list", usage: "", run: principalsList},
+	{name: "set", usage: "<name> <clearance>", run: principalsSet},
+	{name: "delete", usage: "<name>", run: principalsDelete},
+}
+
+var auditCommands = []command{
+	{name: "list", usage: "[-limit n]", run: auditList},
+}
+
+func principalsList(ctx context.Context, a *app, args []string) error {
+	principals, err := a.services.Principal.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(principals))
+	for _, p := range principals {
+		rows = append(rows, []string{p.Name, string(p.Clearance), p.UpdatedAt.Format("2006-01-02 15:04")})
+	}
+	return a.out.print(principals, []string{"NAME", "CLEARANCE", "UPDATED"}, rows)
+}
+
+func principalsSet(ctx context.Context, a *app, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: principals set <name> <clearance>")
+	}
+
+	if _, err := a.services.Principal.Set(ctx, args[0], models.Classification(args[1])); err != nil {
+		return err
+	}
+	return a.out.done("clearance set")
+}
+
+func principalsDelete(ctx context.Context, a *app, args []string) error {
+	if len(args) != 1 || strings.HasPrefix(args[0], "-") {
+		return errors.New("usage: principals delete <name>")
+	}
+
+	if err := a.services.Principal.Delete(ctx, args[0]); err != nil {
+		return err
+	}
+	return a.out.done("principal removed")
+}
+
+func auditList(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("audit list", flag.ContinueOnError)
+	limit := fs.Int("limit", 0, "maximum entries, newest first (default 100)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	entries, err := a.services.Audit.GetAll(ctx, *limit)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(entries))
+	for _, e := range entries {
+		rows = append(rows, []string{
+			e.At.Format("2006-01-02 15:04:05"),
+			e.Principal,
+			string(e.Clearance),
+			e.Kind,
+			e.ObjectID.String(),
+			string(e.Classification),
+			e.Outcome,
+			e.Path,
+		})
+	}
+	return a.out.print(entries, []string{"AT", "PRINCIPAL", "CLEARANCE", "KIND", "OBJECT", "CLASSIFICATION", "OUTCOME", "PATH"}, rows)
+}
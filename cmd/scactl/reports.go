@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+var breedCommands = []command{
+	{name: "list", usage: "", run: breedsList},
+	{name: "sync", usage: "", run: breedsSync},
+}
+
+var reportCommands = []command{
+	{name: "missions", usage: "", run: reportMissions},
+	{name: "cats", usage: "", run: reportCats},
+	{name: "countries", usage: "", run: reportCountries},
+}
+
+func breedsList(ctx context.Context, a *app, args []string) error {
+	breeds, err := a.services.Breed.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(breeds))
+	for _, breed := range breeds {
+		removed := "-"
+		if breed.RemovedAt != nil {
+			removed = breed.RemovedAt.Format("2006-01-02")
+		}
+		rows = append(rows, []string{breed.ID.String(), breed.ApiID, breed.Name, removed})
+	}
+	return a.out.print(breeds, []string{"ID", "API ID", "NAME", "REMOVED"}, rows)
+}
+
+func breedsSync(ctx context.Context, a *app, args []string) error {
+	result, err := a.events.LoadBreeds(ctx)
+	if result != nil {
+		if printErr := a.out.print(result, []string{"SOURCE", "CREATED", "RENAMED", "UPDATED", "RESTORED", "REMOVED", "UNCHANGED", "FAILED"}, [][]string{{
+			result.Source,
+			fmt.Sprint(result.Created),
+			fmt.Sprint(result.Renamed),
+			fmt.Sprint(result.Updated),
+			fmt.Sprint(result.Restored),
+			fmt.Sprint(result.Removed),
+			fmt.Sprint(result.Unchanged),
+			fmt.Sprint(result.Failed),
+		}}); printErr != nil {
+			return printErr
+		}
+	}
+	return err
+}
+
+type missionReport struct {
+	Total            int `json:"total"`
+	Active           int `json:"active"`
+	Completed        int `json:"completed"`
+	Unassigned       int `json:"unassigned_active"`
+	TargetsTotal     int `json:"targets_total"`
+	TargetsCompleted int `json:"targets_completed"`
+}
+
+func reportMissions(ctx context.Context, a *app, args []string) error {
+	missions, err := a.services.Mission.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	var r missionReport
+	for _, mission := range missions {
+		r.Total++
+		if mission.Completed {
+			r.Completed++
+		} else {
+			r.Active++
+			if mission.AssignedCatID == nil {
+				r.Unassigned++
+			}
+		}
+		for _, target := range mission.Targets {
+			r.TargetsTotal++
+			if target.Completed {
+				r.TargetsCompleted++
+			}
+		}
+	}
+
+	return a.out.print(r, []string{"METRIC", "VALUE"}, [][]string{
+		{"missions total", fmt.Sprint(r.Total)},
+		{"missions active", fmt.Sprint(r.Active)},
+		{"missions completed", fmt.Sprint(r.Completed)},
+		{"active missions without cat", fmt.Sprint(r.Unassigned)},
+		{"targets total", fmt.Sprint(r.TargetsTotal)},
+		{"targets completed", fmt.Sprint(r.TargetsCompleted)},
+	})
+}
+
+type catReport struct {
+	Total       int     `json:"total"`
+	OnMission   int     `json:"on_mission"`
+	Idle        int     `json:"idle"`
+	TotalSalary float64 `json:"total_salary"`
+}
+
+func reportCats(ctx context.Context, a *app, args []string) error {
+	cats, err := a.services.SpyCat.GetAll(ctx, models.CatFilter{})
+	if err != nil {
+		return err
+	}
+
+	missions, err := a.services.Mission.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	busy := make(map[uuid.UUID]bool)
+	for _, mission := range missions {
+		if mission.Completed {
+			continue
+		}
+		for _, assignment := range mission.Assignments {
+			if assignment.CatID != nil {
+				busy[*assignment.CatID] = true
+			}
+		}
+	}
+
+	var r catReport
+	for _, cat := range cats {
+		r.Total++
+		r.TotalSalary += float64(cat.Salary)
+		if busy[cat.ID] {
+			r.OnMission++
+		} else {
+			r.Idle++
+		}
+	}
+
+	return a.out.print(r, []string{"METRIC", "VALUE"}, [][]string{
+		{"cats total", fmt.Sprint(r.Total)},
+		{"cats on mission", fmt.Sprint(r.OnMission)},
+		{"cats idle", fmt.Sprint(r.Idle)},
+		{"total salary", fmt.Sprintf("%.2f", r.TotalSalary)},
+	})
+}
+
+// reportCountries lists targets whose country could not be normalised;
+// fix them with "targets country".
+func reportCountries(ctx context.Context, a *app, args []string) error {
+	unresolved, err := a.services.Country.GetUnresolved(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(unresolved))
+	for _, u := range unresolved {
+		rows = append(rows, []string{u.TargetID.String(), u.MissionID.String(), u.Name, u.Country})
+	}
+	return a.out.print(unresolved, []string{"TARGET", "MISSION", "NAME", "COUNTRY"}, rows)
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+var templateCommands = []command{
+	{name: "list", usage: "", run: templatesList},
+	{name: "create", usage: "-f <file.yaml>", run: templatesCreate},
+	{name: "delete", usage: "<id>", run: templatesDelete},
+	{name: "use", usage: "<id> [-f <overrides.yaml>]", run: templatesUse},
+}
+
+var templateHeader = []string{"ID", "NAME", "TITLE", "TARGETS"}
+
+func templateRows(templates []models.MissionTemplate) [][]string {
+	rows := make([][]string, 0, len(templates))
+	for _, t := range templates {
+		rows = append(rows, []string{
+			t.ID.String(),
+			t.Name,
+			t.Title,
+			fmt.Sprint(len(t.Targets)),
+		})
+	}
+	return rows
+}
+
+func templatesList(ctx context.Context, a *app, args []string) error {
+	templates, err := a.services.Template.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	return a.out.print(templates, templateHeader, templateRows(templates))
+}
+
+// templateFile is the YAML layout accepted by "templates create". A file may
+// hold several templates as separate YAML documents.
+type templateFile struct {
+	Name        string  `yaml:"name"`
+	Title       string  `yaml:"title"`
+	Description *string `yaml:"description"`
+	Targets     []struct {
+		Name    string `yaml:"name"`
+		Country string `yaml:"country"`
+		Notes   string `yaml:"notes"`
+		// SuggestedSkills maps skill codes to the minimum proficiency.
+		SuggestedSkills map[string]int `yaml:"suggested_skills"`
+	} `yaml:"targets"`
+}
+
+// openInput opens the file named by -f, - meaning stdin.
+func openInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
+
+func templatesCreate(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("templates create", flag.ContinueOnError)
+	file := fs.String("f", "", "path to a template YAML file, - for stdin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-f is required")
+	}
+
+	r, err := openInput(*file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var created []models.MissionTemplate
+	dec := yaml.NewDecoder(r)
+	for {
+		var in templateFile
+		if err := dec.Decode(&in); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+
+		t := models.MissionTemplate{
+			Name:        in.Name,
+			Title:       in.Title,
+			Description: in.Description,
+			Targets:     make([]models.TemplateTarget, len(in.Targets)),
+		}
+		for i, target := range in.Targets {
+			t.Targets[i] = models.TemplateTarget{
+				Name:    target.Name,
+				Country: target.Country,
+				Notes:   target.Notes,
+			}
+			codes := make([]string, 0, len(target.SuggestedSkills))
+			for code := range target.SuggestedSkills {
+				codes = append(codes, code)
+			}
+			slices.Sort(codes)
+			for _, code := range codes {
+				t.Targets[i].SuggestedSkills = append(t.Targets[i].SuggestedSkills, models.SuggestedSkill{
+					Skill:          code,
+					MinProficiency: target.SuggestedSkills[code],
+				})
+			}
+		}
+
+		saved, err := a.services.Template.Create(ctx, t, a.actor)
+		if err != nil {
+			return fmt.Errorf("template %q: %w", t.Name, err)
+		}
+		created = append(created, *saved)
+	}
+
+	return a.out.print(created, templateHeader, templateRows(created))
+}
+
+func templatesDelete(ctx context.Context, a *app, args []string) error {
+	id, _, err := splitID(args)
+	if err != nil {
+		return err
+	}
+
+	if err := a.services.Template.Delete(ctx, id); err != nil {
+		return err
+	}
+	return a.out.done("deleted")
+}
+
+// overridesFile is the YAML layout of "templates use -f". Every key is
+// optional; targets[i] applies to the template's i-th placeholder.
+type overridesFile struct {
+	Title         *string    `yaml:"title"`
+	Description   *string    `yaml:"description"`
+	AssignedCatID *uuid.UUID `yaml:"assigned_cat_id"`
+	// ScheduledStart and ScheduledEnd are YYYY-MM-DD days.
+	ScheduledStart *time.Time `yaml:"scheduled_start"`
+	ScheduledEnd   *time.Time `yaml:"scheduled_end"`
+	Targets        []struct {
+		Name      *string  `yaml:"name"`
+		Country   *string  `yaml:"country"`
+		Latitude  *float64 `yaml:"latitude"`
+		Longitude *float64 `yaml:"longitude"`
+		City      *string  `yaml:"city"`
+		Address   *string  `yaml:"address"`
+		Notes     *string  `yaml:"notes"`
+		// RequiredSkills replaces the suggested skills when present.
+		RequiredSkills map[string]int `yaml:"required_skills"`
+		Omit           bool           `yaml:"omit"`
+	} `yaml:"targets"`
+}
+
+func templatesUse(ctx context.Context, a *app, args []string) error {
+	id, rest, err := splitID(args)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("templates use", flag.ContinueOnError)
+	file := fs.String("f", "", "path to an overrides YAML file, - for stdin")
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+
+	var in overridesFile
+	if *file != "" {
+		r, err := openInput(*file)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		if err := yaml.NewDecoder(r).Decode(&in); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+	}
+
+	overrides := models.MissionOverrides{
+		Title:          in.Title,
+		Description:    in.Description,
+		AssignedCatID:  in.AssignedCatID,
+		ScheduledStart: in.ScheduledStart,
+		ScheduledEnd:   in.ScheduledEnd,
+		Targets:        make([]models.TargetOverride, len(in.Targets)),
+	}
+	for i, target := range in.Targets {
+		overrides.Targets[i] = models.TargetOverride{
+			Name:      target.Name,
+			Country:   target.Country,
+			Latitude:  target.Latitude,
+			Longitude: target.Longitude,
+			City:      target.City,
+			Address:   target.Address,
+			Notes:     target.Notes,
+			Omit:      target.Omit,
+		}
+		if target.RequiredSkills != nil {
+			overrides.Targets[i].RequiredSkills = []models.SkillRequirement{}
+			for code, level := range target.RequiredSkills {
+				overrides.Targets[i].RequiredSkills = append(overrides.Targets[i].RequiredSkills, models.SkillRequirement{
+					Skill:          models.Skill{Code: code},
+					MinProficiency: level,
+				})
+			}
+		}
+	}
+
+	mission, err := a.services.Mission.CreateFromTemplate(ctx, id, overrides, a.actor)
+	if err != nil {
+		return err
+	}
+	return a.print(ctx, mission, missionHeader, missionRows([]models.Mission{*mission}))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+var trashCommands = []command{
+	{name: "list", usage: "", run: trashList},
+	{name: "purge", usage: "", run: trashPurge},
+}
+
+func trashList(ctx context.Context, a *app, args []string) error {
+	items, err := a.services.Trash.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		mission := "-"
+		if item.MissionID != nil {
+			mission = item.MissionID.String()
+		}
+		rows = append(rows, []string{
+			item.Kind,
+			item.ID.String(),
+			item.Name,
+			mission,
+			item.DeletedAt.Format("2006-01-02 15:04"),
+		})
+	}
+	return a.out.print(items, []string{"KIND", "ID", "NAME", "MISSION", "DELETED"}, rows)
+}
+
+func trashPurge(ctx context.Context, a *app, args []string) error {
+	result, err := a.services.Trash.Purge(ctx)
+	if err != nil {
+		return err
+	}
+	return a.out.print(result, []string{"MISSIONS", "TARGETS"}, [][]string{{
+		fmt.Sprint(result.Missions),
+		fmt.Sprint(result.Targets),
+	}})
+}
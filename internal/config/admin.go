@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// minAdminToken is the shortest operator or gateway token accepted.
+const minAdminToken = 16
+
+// ParseAdminTokens reads "name:token" entries into tokens by operator name.
+func ParseAdminTokens(entries []string) (map[string]string, error) {
+	return parseTokens(entries, "name", "operator")
+}
+
+// ParseGatewayTokens reads "agency:token" entries into tokens by agency
+// slug.
+func ParseGatewayTokens(entries []string) (map[string]string, error) {
+	return parseTokens(entries, "agency", "agency")
+}
+
+func parseTokens(entries []string, field, holder string) (map[string]string, error) {
+	tokens := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		name, token, ok := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("entry %q is not %s:token", maskSecret(entry), field)
+		}
+		if _, dup := tokens[name]; dup {
+			return nil, fmt.Errorf("%s %q is given twice", holder, name)
+		}
+		if len(token) < minAdminToken {
+			return nil, fmt.Errorf("token of %q must be at least %d characters", name, minAdminToken)
+		}
+		tokens[name] = token
+	}
+	return tokens, nil
+}
+
+// Operators returns the operator tokens by name. The config is validated on
+// load, so entries that do not parse are already reported.
+func (c Config) Operators() map[string]string {
+	tokens, _ := ParseAdminTokens(c.AdminTokens)
+	return tokens
+}
+
+// Gateways returns the gateway tokens by agency slug.
+func (c Config) Gateways() map[string]string {
+	tokens, _ := ParseGatewayTokens(c.GatewayTokens)
+	return tokens
+}
@@ -1,26 +1,229 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"os"
-	"strconv"
+	"time"
 
-	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	PostgregUrl  string
-	TheCatApiUrl string
-	Port         int
+	PostgregUrl  string `yaml:"postgres_url"`
+	TheCatApiUrl string `yaml:"the_cat_api_url"`
+	Port         int    `yaml:"port"`
+	LogLevel     string `yaml:"log_level"`
+
+	DBMaxConns       int32         `yaml:"db_max_conns"`
+	DBMinConns       int32         `yaml:"db_min_conns"`
+	DBConnectTimeout time.Duration `yaml:"db_connect_timeout"`
+
+	HTTPReadTimeout  time.Duration `yaml:"http_read_timeout"`
+	HTTPWriteTimeout time.Duration `yaml:"http_write_timeout"`
+	HTTPIdleTimeout  time.Duration `yaml:"http_idle_timeout"`
+	CORSOrigins      []string      `yaml:"cors_origins"`
+
+	// ShutdownTimeout bounds how long in-flight HTTP requests may drain.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// WorkerShutdownTimeout bounds how long background workers may take to stop.
+	WorkerShutdownTimeout time.Duration `yaml:"worker_shutdown_timeout"`
+
+	// BreedsLoadOnStart imports the TheCatAPI catalogue when the server boots.
+	BreedsLoadOnStart bool `yaml:"breeds_load_on_start"`
+	// BreedsSyncInterval re-syncs breeds periodically; zero disables it.
+	BreedsSyncInterval     time.Duration `yaml:"breeds_sync_interval"`
+	BreedsHTTPTimeout      time.Duration `yaml:"breeds_http_timeout"`
+	BreedsRetries          int           `yaml:"breeds_retries"`
+	BreedsRetryBackoff     time.Duration `yaml:"breeds_retry_backoff"`
+	BreedsSnapshotFallback bool          `yaml:"breeds_snapshot_fallback"`
+
+	// SkillCheck decides what happens when an assigned cat lacks the skills
+	// its mission's targets require: off, warn or block.
+	SkillCheck string `yaml:"skill_check"`
+
+	// TrashRetention is how long deleted missions and targets stay
+	// restorable before the purge job removes them for good.
+	TrashRetention time.Duration `yaml:"trash_retention"`
+	// TrashPurgeInterval runs the purge job periodically; zero disables it.
+	TrashPurgeInterval time.Duration `yaml:"trash_purge_interval"`
+
+	// StorageBackend picks where attachment files live; only "local" exists.
+	StorageBackend string `yaml:"storage_backend"`
+	// StorageDir is the root directory of the local storage backend.
+	StorageDir string `yaml:"storage_dir"`
+	// AttachmentMaxBytes caps the size of one uploaded file.
+	AttachmentMaxBytes int64 `yaml:"attachment_max_bytes"`
+	// AttachmentTypes lists the sniffed content types accepted for upload;
+	// "image/*" accepts every image type.
+	AttachmentTypes []string `yaml:"attachment_types"`
+	// RequireEvidence refuses to complete a target without an attachment.
+	RequireEvidence bool `yaml:"require_evidence"`
+
+	// DefaultAgency is the slug of the agency an operator acts for when
+	// the request carries no X-Agency header; empty makes the header
+	// mandatory.
+	DefaultAgency string `yaml:"default_agency"`
+
+	// NotesKeys are the key-encryption keys of target notes as
+	// "id:base64-key" entries. Old keys stay listed until
+	// "scactl notes rotate" has moved every note to NotesKeyID.
+	NotesKeys []string `yaml:"notes_keys"`
+	// NotesKeyID names the key new notes are encrypted with.
+	NotesKeyID string `yaml:"notes_key_id"`
+	// NotesIndexKey is the base64 key of the blind index that makes notes
+	// searchable. It is not rotated with NotesKeys; changing it needs
+	// "scactl notes reindex".
+	NotesIndexKey string `yaml:"notes_index_key"`
+
+	// AdminTokens are the bearer tokens of operators as "name:token"
+	// entries. /admin answers only requests carrying one; empty closes it.
+	AdminTokens []string `yaml:"admin_tokens"`
+	// GatewayTokens are the bearer tokens of the gateways in front of the
+	// API as "agency:token" entries. A request with one acts for that
+	// agency; empty closes everything but /admin.
+	GatewayTokens []string `yaml:"gateway_tokens"`
+
+	// TracingExporter sends OpenTelemetry spans to an OTLP/HTTP collector
+	// ("otlp"), to stdout or to TracingFile ("file"); "none" disables tracing.
+	TracingExporter string `yaml:"tracing_exporter"`
+	// TracingEndpoint is the OTLP/HTTP collector URL. Empty falls back to
+	// OTEL_EXPORTER_OTLP_ENDPOINT, then to http://localhost:4318.
+	TracingEndpoint string `yaml:"tracing_endpoint"`
+	// TracingFile is where the file exporter appends spans as JSON.
+	TracingFile string `yaml:"tracing_file"`
+	// TracingSampleRatio is the share of new traces recorded, from 0 to 1.
+	// Requests arriving with a sampled trace context are always recorded.
+	TracingSampleRatio float64 `yaml:"tracing_sample_ratio"`
+}
+
+// Default holds the values used when neither the config file, the
+// environment nor a flag sets an option.
+func Default() Config {
+	return Config{
+		TheCatApiUrl:           "https://api.thecatapi.com/v1/breeds",
+		Port:                   1323,
+		LogLevel:               "info",
+		DBMaxConns:             10,
+		DBMinConns:             0,
+		DBConnectTimeout:       5 * time.Second,
+		HTTPReadTimeout:        15 * time.Second,
+		HTTPWriteTimeout:       30 * time.Second,
+		HTTPIdleTimeout:        60 * time.Second,
+		CORSOrigins:            []string{"*", "https://accounts.google.com"},
+		ShutdownTimeout:        15 * time.Second,
+		WorkerShutdownTimeout:  10 * time.Second,
+		BreedsLoadOnStart:      true,
+		BreedsSyncInterval:     24 * time.Hour,
+		BreedsHTTPTimeout:      10 * time.Second,
+		BreedsRetries:          3,
+		BreedsRetryBackoff:     time.Second,
+		BreedsSnapshotFallback: true,
+		SkillCheck:             "warn",
+		TrashRetention:         30 * 24 * time.Hour,
+		TrashPurgeInterval:     time.Hour,
+		StorageBackend:         "local",
+		StorageDir:             "data/attachments",
+		AttachmentMaxBytes:     20 << 20,
+		AttachmentTypes:        []string{"image/*", "application/pdf", "text/plain", "application/zip"},
+		RequireEvidence:        false,
+		DefaultAgency:          "default",
+		TracingExporter:        "none",
+		TracingSampleRatio:     1,
+	}
 }
 
-func NewConfig() Config {
-	port, err := strconv.Atoi(os.Getenv("PORT"))
+// Options are the command-line switches that are not configuration values.
+type Options struct {
+	PrintConfig bool
+}
+
+// Load builds the effective configuration from, in increasing priority,
+// defaults, the YAML file given by -config or CONFIG_FILE, environment
+// variables and command-line flags, and validates the result.
+func Load(args []string) (Config, Options, error) {
+	cfg := Default()
+	var opts Options
+
+	fs := flag.NewFlagSet("sca", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "print the effective config with secrets masked and exit")
+
+	flagValues := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flagValues[s.key] = fs.String(s.flagName(), "", fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, opts, err
+	}
+
+	if *configFile != "" {
+		if err := cfg.readFile(*configFile); err != nil {
+			return cfg, opts, err
+		}
+	}
+
+	var errs []error
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok {
+			if err := s.set(&cfg, value); err != nil {
+				errs = append(errs, fmt.Errorf("env %s: %w", s.env, err))
+			}
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flagName() == f.Name {
+				if err := s.set(&cfg, *flagValues[s.key]); err != nil {
+					errs = append(errs, fmt.Errorf("flag -%s: %w", f.Name, err))
+				}
+			}
+		}
+	})
+
+	if len(errs) > 0 {
+		return cfg, opts, errors.Join(errs...)
+	}
+
+	return cfg, opts, cfg.Validate()
+}
+
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		logrus.Error(err)
+		return fmt.Errorf("config file: %w", err)
 	}
-	return Config{
-		PostgregUrl:  os.Getenv("POSTGRES_URL"),
-		TheCatApiUrl: os.Getenv("THE_CAT_API_URL"),
-		Port:         port,
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Print writes the configuration as YAML with secrets masked.
+func (c Config) Print(w io.Writer) error {
+	masked := c
+	masked.CORSOrigins = append([]string(nil), c.CORSOrigins...)
+	masked.AttachmentTypes = append([]string(nil), c.AttachmentTypes...)
+	for _, s := range settings {
+		if s.secret {
+			if err := s.set(&masked, maskSecret(s.get(&c))); err != nil {
+				return err
+			}
+		}
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(masked); err != nil {
+		return err
 	}
+	return enc.Close()
 }
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testNotesKeys = "test:OpILpyRQhXwVnQXx/hf3S3LspYzzZDvnaPMujshesNQ="
+	testIndexKey  = "wxRysNCcRuz0VRh734lFEHNjdNbtI9JrkTUrL4yGf5U="
+)
+
+// cleanEnv unsets every setting's environment variable for the test, then
+// sets the required ones and env on top.
+func cleanEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for _, name := range append([]string{"CONFIG_FILE"}, envNames()...) {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	required := map[string]string{
+		"POSTGRES_URL":    "postgres://sca:hunter2@db:5432/sca",
+		"NOTES_KEYS":      testNotesKeys,
+		"NOTES_KEY_ID":    "test",
+		"NOTES_INDEX_KEY": testIndexKey,
+	}
+	for name, value := range required {
+		t.Setenv(name, value)
+	}
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+}
+
+func envNames() []string {
+	names := make([]string, len(settings))
+	for i, s := range settings {
+		names[i] = s.env
+	}
+	return names
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sca.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLayers(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		env      map[string]string
+		args     []string
+		port     int
+		logLevel string
+		origins  []string
+	}{
+		{
+			name: "defaults",
+			port: 1323, logLevel: "info", origins: []string{"*", "https://accounts.google.com"},
+		},
+		{
+			name: "file over defaults",
+			yaml: "port: 8080\ncors_origins: [https://sca.example]\n",
+			port: 8080, logLevel: "info", origins: []string{"https://sca.example"},
+		},
+		{
+			name: "env over file",
+			yaml: "port: 8080\nlog_level: warn\n",
+			env:  map[string]string{"PORT": "9090", "CORS_ORIGINS": " https://a.example , ,https://b.example"},
+			port: 9090, logLevel: "warn", origins: []string{"https://a.example", "https://b.example"},
+		},
+		{
+			name: "flags over env",
+			yaml: "port: 8080\n",
+			env:  map[string]string{"PORT": "9090", "LOG_LEVEL": "debug"},
+			args: []string{"-port", "7070"},
+			port: 7070, logLevel: "debug", origins: []string{"*", "https://accounts.google.com"},
+		},
+		{
+			name: "file of comments",
+			yaml: "# nothing set here\n",
+			args: []string{"-log-level=error"},
+			port: 1323, logLevel: "error", origins: []string{"*", "https://accounts.google.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleanEnv(t, tt.env)
+			args := tt.args
+			if tt.yaml != "" {
+				args = append([]string{"-config", writeFile(t, tt.yaml)}, args...)
+			}
+
+			cfg, _, err := Load(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Port != tt.port || cfg.LogLevel != tt.logLevel || !reflect.DeepEqual(cfg.CORSOrigins, tt.origins) {
+				t.Errorf("got port %d, log level %q and origins %q, want %d, %q and %q",
+					cfg.Port, cfg.LogLevel, cfg.CORSOrigins, tt.port, tt.logLevel, tt.origins)
+			}
+		})
+	}
+}
+
+func TestLoadFileFromEnv(t *testing.T) {
+	cleanEnv(t, nil)
+	t.Setenv("CONFIG_FILE", writeFile(t, "port: 8181\n"))
+
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 8181 {
+		t.Errorf("got port %d, want the one from CONFIG_FILE", cfg.Port)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		env  map[string]string
+		args []string
+		want []string
+	}{
+		{
+			name: "unknown file key",
+			yaml: "prot: 8080\n",
+			want: []string{"field prot not found"},
+		},
+		{
+			name: "every bad value",
+			env:  map[string]string{"PORT": "eighty", "BREEDS_LOAD_ON_START": "maybe"},
+			args: []string{"-db-connect-timeout", "5"},
+			want: []string{
+				`env PORT: "eighty" is not an integer`,
+				`env BREEDS_LOAD_ON_START: "maybe" is not a boolean`,
+				`flag -db-connect-timeout: "5" is not a duration like 5s or 1m`,
+			},
+		},
+		{
+			name: "invalid result",
+			env:  map[string]string{"PORT": "0", "SKILL_CHECK": "strict", "POSTGRES_URL": "mysql://db/sca"},
+			want: []string{
+				"port: must be between 1 and 65535, got 0",
+				`skill_check: must be off, warn or block, got "strict"`,
+				"postgres_url: url scheme must be one of [postgres postgresql]",
+			},
+		},
+		{
+			name: "missing secrets",
+			env:  map[string]string{"NOTES_KEYS": "", "NOTES_INDEX_KEY": ""},
+			want: []string{"notes_keys: is required", "notes_index_key: is required"},
+		},
+		{
+			name: "bad tokens",
+			env:  map[string]string{"ADMIN_TOKENS": "root:short", "GATEWAY_TOKENS": "mi6:0123456789abcdef,mi6:fedcba9876543210"},
+			want: []string{
+				`admin_tokens: token of "root" must be at least 16 characters`,
+				`gateway_tokens: agency "mi6" is given twice`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleanEnv(t, tt.env)
+			args := tt.args
+			if tt.yaml != "" {
+				args = append([]string{"-config", writeFile(t, tt.yaml)}, args...)
+			}
+
+			_, _, err := Load(args)
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not report %q", err, want)
+				}
+			}
+			if strings.Contains(err.Error(), "0123456789abcdef") {
+				t.Errorf("error %q prints a token", err)
+			}
+		})
+	}
+}
+
+func TestPrintMasksSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.PostgregUrl = "postgres://sca:hunter2@db:5432/sca"
+	cfg.NotesKeys = []string{testNotesKeys}
+	cfg.NotesKeyID = "test"
+	cfg.NotesIndexKey = testIndexKey
+	cfg.AdminTokens = []string{"root:0123456789abcdef"}
+	cfg.GatewayTokens = []string{"mi6:fedcba9876543210"}
+
+	var out bytes.Buffer
+	if err := cfg.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{"hunter2", "OpILpy", "wxRysN", "0123456789abcdef", "fedcba9876543210"} {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("printed config shows %q:\n%s", secret, out.String())
+		}
+	}
+	for _, shown := range []string{
+		"postgres_url: postgres://sca:xxxxx@db:5432/sca",
+		"notes_key_id: test",
+		"notes_index_key: '******'",
+		"port: 1323",
+	} {
+		if !strings.Contains(out.String(), shown) {
+			t.Errorf("printed config lacks %q:\n%s", shown, out.String())
+		}
+	}
+	if cfg.PostgregUrl != "postgres://sca:hunter2@db:5432/sca" || cfg.NotesKeys[0] != testNotesKeys {
+		t.Error("Print changed the config it printed")
+	}
+}
+
+func TestMaskSecret(t *testing.T) {
+	tests := map[string]string{
+		"":                                 "",
+		"plain-secret":                     "******",
+		"postgres://sca:pw@db/sca":         "postgres://sca:xxxxx@db/sca",
+		"postgres://db/sca?sslmode=verify": "postgres://db/sca?sslmode=verify",
+		"root:0123456789abcdef":            "******",
+	}
+	for value, want := range tests {
+		if got := maskSecret(value); got != want {
+			t.Errorf("maskSecret(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting ties a Config field to its environment variable and flag. field
+// returns a pointer into the Config so one table drives parsing and printing.
+type setting struct {
+	key    string
+	env    string
+	usage  string
+	secret bool
+	field  func(c *Config) any
+}
+
+var settings = []setting{
+	{key: "postgres_url", env: "POSTGRES_URL", usage: "postgres connection url", secret: true,
+		field: func(c *Config) any { return &c.PostgregUrl }},
+	{key: "the_cat_api_url", env: "THE_CAT_API_URL", usage: "TheCatAPI breeds endpoint",
+		field: func(c *Config) any { return &c.TheCatApiUrl }},
+	{key: "port", env: "PORT", usage: "HTTP listen port",
+		field: func(c *Config) any { return &c.Port }},
+	{key: "log_level", env: "LOG_LEVEL", usage: "log level: debug, info, warn or error",
+		field: func(c *Config) any { return &c.LogLevel }},
+	{key: "db_max_conns", env: "DB_MAX_CONNS", usage: "maximum database pool size",
+		field: func(c *Config) any { return &c.DBMaxConns }},
+	{key: "db_min_conns", env: "DB_MIN_CONNS", usage: "minimum idle database connections",
+		field: func(c *Config) any { return &c.DBMinConns }},
+	{key: "db_connect_timeout", env: "DB_CONNECT_TIMEOUT", usage: "database connect timeout",
+		field: func(c *Config) any { return &c.DBConnectTimeout }},
+	{key: "http_read_timeout", env: "HTTP_READ_TIMEOUT", usage: "HTTP request read timeout",
+		field: func(c *Config) any { return &c.HTTPReadTimeout }},
+	{key: "http_write_timeout", env: "HTTP_WRITE_TIMEOUT", usage: "HTTP response write timeout",
+		field: func(c *Config) any { return &c.HTTPWriteTimeout }},
+	{key: "http_idle_timeout", env: "HTTP_IDLE_TIMEOUT", usage: "HTTP keep-alive idle timeout",
+		field: func(c *Config) any { return &c.HTTPIdleTimeout }},
+	{key: "cors_origins", env: "CORS_ORIGINS", usage: "comma-separated allowed CORS origins",
+		field: func(c *Config) any { return &c.CORSOrigins }},
+	{key: "shutdown_timeout", env: "SHUTDOWN_TIMEOUT", usage: "HTTP drain timeout on shutdown",
+		field: func(c *Config) any { return &c.ShutdownTimeout }},
+	{key: "worker_shutdown_timeout", env: "WORKER_SHUTDOWN_TIMEOUT", usage: "background worker stop timeout",
+		field: func(c *Config) any { return &c.WorkerShutdownTimeout }},
+	{key: "breeds_load_on_start", env: "BREEDS_LOAD_ON_START", usage: "import breeds from TheCatAPI at boot",
+		field: func(c *Config) any { return &c.BreedsLoadOnStart }},
+	{key: "breeds_sync_interval", env: "BREEDS_SYNC_INTERVAL", usage: "periodic breed re-sync interval, 0 disables",
+		field: func(c *Config) any { return &c.BreedsSyncInterval }},
+	{key: "breeds_http_timeout", env: "BREEDS_HTTP_TIMEOUT", usage: "timeout of one TheCatAPI request",
+		field: func(c *Config) any { return &c.BreedsHTTPTimeout }},
+	{key: "breeds_retries", env: "BREEDS_RETRIES", usage: "retries after a failed TheCatAPI request",
+		field: func(c *Config) any { return &c.BreedsRetries }},
+	{key: "breeds_retry_backoff", env: "BREEDS_RETRY_BACKOFF", usage: "initial retry backoff, doubled per attempt",
+		field: func(c *Config) any { return &c.BreedsRetryBackoff }},
+	{key: "breeds_snapshot_fallback", env: "BREEDS_SNAPSHOT_FALLBACK", usage: "import the bundled breed snapshot when TheCatAPI is unreachable",
+		field: func(c *Config) any { return &c.BreedsSnapshotFallback }},
+	{key: "skill_check", env: "SKILL_CHECK", usage: "skill coverage check on cat assignment: off, warn or block",
+		field: func(c *Config) any { return &c.SkillCheck }},
+	{key: "trash_retention", env: "TRASH_RETENTION", usage: "how long deleted missions and targets stay restorable",
+		field: func(c *Config) any { return &c.TrashRetention }},
+	{key: "trash_purge_interval", env: "TRASH_PURGE_INTERVAL", usage: "periodic trash purge interval, 0 disables",
+		field: func(c *Config) any { return &c.TrashPurgeInterval }},
+	{key: "storage_backend", env: "STORAGE_BACKEND", usage: "attachment storage backend: local",
+		field: func(c *Config) any { return &c.StorageBackend }},
+	{key: "storage_dir", env: "STORAGE_DIR", usage: "root directory of the local storage backend",
+		field: func(c *Config) any { return &c.StorageDir }},
+	{key: "attachment_max_bytes", env: "ATTACHMENT_MAX_BYTES", usage: "maximum size of one attachment in bytes",
+		field: func(c *Config) any { return &c.AttachmentMaxBytes }},
+	{key: "attachment_types", env: "ATTACHMENT_TYPES", usage: "comma-separated accepted attachment content types, type/* allowed",
+		field: func(c *Config) any { return &c.AttachmentTypes }},
+	{key: "require_evidence", env: "REQUIRE_EVIDENCE", usage: "refuse to complete a target without an attachment",
+		field: func(c *Config) any { return &c.RequireEvidence }},
+	{key: "default_agency", env: "DEFAULT_AGENCY", usage: "agency slug an operator acts for without an X-Agency header, empty requires the header",
+		field: func(c *Config) any { return &c.DefaultAgency }},
+	{key: "notes_keys", env: "NOTES_KEYS", usage: "comma-separated id:base64 32-byte keys that encrypt target notes", secret: true,
+		field: func(c *Config) any { return &c.NotesKeys }},
+	{key: "notes_key_id", env: "NOTES_KEY_ID", usage: "id of the notes key new notes are encrypted with",
+		field: func(c *Config) any { return &c.NotesKeyID }},
+	{key: "notes_index_key", env: "NOTES_INDEX_KEY", usage: "base64 32-byte key of the blind index that makes notes searchable", secret: true,
+		field: func(c *Config) any { return &c.NotesIndexKey }},
+	{key: "admin_tokens", env: "ADMIN_TOKENS", usage: "comma-separated name:token bearer tokens of operators", secret: true,
+		field: func(c *Config) any { return &c.AdminTokens }},
+	{key: "gateway_tokens", env: "GATEWAY_TOKENS", usage: "comma-separated agency:token bearer tokens of gateways", secret: true,
+		field: func(c *Config) any { return &c.GatewayTokens }},
+	{key: "tracing_exporter", env: "TRACING_EXPORTER", usage: "where spans go: none, otlp, stdout or file",
+		field: func(c *Config) any { return &c.TracingExporter }},
+	{key: "tracing_endpoint", env: "TRACING_ENDPOINT", usage: "OTLP/HTTP collector url for the otlp exporter",
+		field: func(c *Config) any { return &c.TracingEndpoint }},
+	{key: "tracing_file", env: "TRACING_FILE", usage: "file the file exporter appends spans to",
+		field: func(c *Config) any { return &c.TracingFile }},
+	{key: "tracing_sample_ratio", env: "TRACING_SAMPLE_RATIO", usage: "share of new traces recorded, 0 to 1",
+		field: func(c *Config) any { return &c.TracingSampleRatio }},
+}
+
+func (s setting) flagName() string {
+	return strings.ReplaceAll(s.key, "_", "-")
+}
+
+func (s setting) set(c *Config, value string) error {
+	value = strings.TrimSpace(value)
+
+	switch p := s.field(c).(type) {
+	case *string:
+		*p = value
+	case *int:
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*p = v
+	case *int64:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*p = v
+	case *int32:
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*p = int32(v)
+	case *float64:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*p = v
+	case *bool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		*p = v
+	case *time.Duration:
+		v, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 5s or 1m", value)
+		}
+		*p = v
+	case *[]string:
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*p = list
+	default:
+		return fmt.Errorf("unsupported setting type %T", p)
+	}
+	return nil
+}
+
+func (s setting) get(c *Config) string {
+	switch p := s.field(c).(type) {
+	case *string:
+		return *p
+	case *[]string:
+		return strings.Join(*p, ",")
+	default:
+		return fmt.Sprint(reflect.ValueOf(p).Elem().Interface())
+	}
+}
+
+// maskSecret hides credentials: a URL keeps everything but its password,
+// anything else is replaced entirely.
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" && u.Host != "" {
+		return u.Redacted()
+	}
+	return "******"
+}
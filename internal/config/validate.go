@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/keyring"
+	"github.com/sirupsen/logrus"
+)
+
+var agencySlug = regexp.MustCompile(`^[a-z][a-z0-9-]{0,62}$`)
+
+// Validate checks every option and reports all problems at once.
+func (c Config) Validate() error {
+	var errs []error
+	fail := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if c.PostgregUrl == "" {
+		fail("postgres_url", "is required")
+	} else if err := checkURL(c.PostgregUrl, "postgres", "postgresql"); err != nil {
+		fail("postgres_url", "%s", err)
+	}
+
+	if c.BreedsLoadOnStart || c.BreedsSyncInterval > 0 {
+		if c.TheCatApiUrl == "" {
+			fail("the_cat_api_url", "is required when breeds are loaded or synced")
+		} else if err := checkURL(c.TheCatApiUrl, "http", "https"); err != nil {
+			fail("the_cat_api_url", "%s", err)
+		}
+	}
+
+	if c.Port < 1 || c.Port > 65535 {
+		fail("port", "must be between 1 and 65535, got %d", c.Port)
+	}
+
+	if _, err := logrus.ParseLevel(c.LogLevel); err != nil {
+		fail("log_level", "%q is not a valid level", c.LogLevel)
+	}
+
+	if c.DBMaxConns < 1 {
+		fail("db_max_conns", "must be at least 1, got %d", c.DBMaxConns)
+	}
+	if c.DBMinConns < 0 || c.DBMinConns > c.DBMaxConns {
+		fail("db_min_conns", "must be between 0 and db_max_conns (%d), got %d", c.DBMaxConns, c.DBMinConns)
+	}
+
+	for _, timeout := range []struct {
+		key string
+		d   time.Duration
+	}{
+		{"db_connect_timeout", c.DBConnectTimeout},
+		{"http_read_timeout", c.HTTPReadTimeout},
+		{"http_write_timeout", c.HTTPWriteTimeout},
+		{"http_idle_timeout", c.HTTPIdleTimeout},
+		{"shutdown_timeout", c.ShutdownTimeout},
+		{"worker_shutdown_timeout", c.WorkerShutdownTimeout},
+		{"breeds_http_timeout", c.BreedsHTTPTimeout},
+		{"breeds_retry_backoff", c.BreedsRetryBackoff},
+		{"trash_retention", c.TrashRetention},
+	} {
+		if timeout.d <= 0 {
+			fail(timeout.key, "must be positive, got %s", timeout.d)
+		}
+	}
+
+	if c.BreedsSyncInterval < 0 {
+		fail("breeds_sync_interval", "cannot be negative")
+	} else if c.BreedsSyncInterval > 0 && c.BreedsSyncInterval < time.Minute {
+		fail("breeds_sync_interval", "must be 0 or at least 1m, got %s", c.BreedsSyncInterval)
+	}
+	if c.BreedsRetries < 0 {
+		fail("breeds_retries", "cannot be negative")
+	}
+
+	if c.TrashPurgeInterval < 0 {
+		fail("trash_purge_interval", "cannot be negative")
+	} else if c.TrashPurgeInterval > 0 && c.TrashPurgeInterval < time.Minute {
+		fail("trash_purge_interval", "must be 0 or at least 1m, got %s", c.TrashPurgeInterval)
+	}
+
+	switch c.StorageBackend {
+	case "local":
+		if c.StorageDir == "" {
+			fail("storage_dir", "is required for the local storage backend")
+		}
+	default:
+		fail("storage_backend", "must be local, got %q", c.StorageBackend)
+	}
+	if c.AttachmentMaxBytes < 1 {
+		fail("attachment_max_bytes", "must be positive, got %d", c.AttachmentMaxBytes)
+	}
+	if len(c.AttachmentTypes) == 0 {
+		fail("attachment_types", "must list at least one content type")
+	}
+	for _, t := range c.AttachmentTypes {
+		if major, minor, ok := strings.Cut(t, "/"); !ok || major == "" || major == "*" || minor == "" {
+			fail("attachment_types", "%q is not a content type like image/png or image/*", t)
+		}
+	}
+
+	switch c.SkillCheck {
+	case "off", "warn", "block":
+	default:
+		fail("skill_check", "must be off, warn or block, got %q", c.SkillCheck)
+	}
+
+	if c.DefaultAgency != "" && !agencySlug.MatchString(c.DefaultAgency) {
+		fail("default_agency", "%q is not an agency slug", c.DefaultAgency)
+	}
+
+	if len(c.NotesKeys) == 0 {
+		fail("notes_keys", "is required")
+	} else if c.NotesKeyID == "" {
+		fail("notes_key_id", "is required")
+	} else if _, err := keyring.Parse(c.NotesKeys, c.NotesKeyID); err != nil {
+		fail("notes_keys", "%s", err)
+	}
+	if c.NotesIndexKey == "" {
+		fail("notes_index_key", "is required")
+	} else if _, err := keyring.NewIndex(c.NotesIndexKey); err != nil {
+		fail("notes_index_key", "%s", err)
+	}
+
+	if _, err := ParseAdminTokens(c.AdminTokens); err != nil {
+		fail("admin_tokens", "%s", err)
+	}
+	if _, err := ParseGatewayTokens(c.GatewayTokens); err != nil {
+		fail("gateway_tokens", "%s", err)
+	}
+
+	switch c.TracingExporter {
+	case "none", "stdout":
+	case "otlp":
+		if c.TracingEndpoint != "" {
+			if err := checkURL(c.TracingEndpoint, "http", "https"); err != nil {
+				fail("tracing_endpoint", "%s", err)
+			}
+		}
+	case "file":
+		if c.TracingFile == "" {
+			fail("tracing_file", "is required for the file exporter")
+		}
+	default:
+		fail("tracing_exporter", "must be none, otlp, stdout or file, got %q", c.TracingExporter)
+	}
+	if math.IsNaN(c.TracingSampleRatio) || c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		fail("tracing_sample_ratio", "must be between 0 and 1, got %g", c.TracingSampleRatio)
+	}
+
+	if len(c.CORSOrigins) == 0 {
+		fail("cors_origins", "must list at least one origin")
+	}
+	for _, origin := range c.CORSOrigins {
+		if origin == "*" {
+			continue
+		}
+		if err := checkURL(origin, "http", "https"); err != nil {
+			fail("cors_origins", "%q: %s", origin, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func checkURL(value string, schemes ...string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return errors.New("is not a valid url")
+	}
+	if u.Host == "" {
+		return errors.New("url has no host")
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return nil
+		}
+	}
+	return fmt.Errorf("url scheme must be one of %v", schemes)
+}
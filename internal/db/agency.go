@@ -0,0 +1,229 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+const agencyColumns = `ag.id, ag.slug, ag.name, ag.settings, ag.created_at, ag.updated_at`
+
+const foreignKeyViolation = "23503"
+
+var (
+	errAgencySlugTaken = fault.Conflictf("agency slug is already taken")
+	errBreedNotFound   = fault.NotFoundf("breed not found")
+)
+
+// agency manages the agencies themselves. Unlike every other repository it
+// works across agencies and is meant for administration only.
+type agency struct {
+	conn *pgxpool.Pool
+}
+
+func newAgency(conn *pgxpool.Pool) *agency {
+	return &agency{
+		conn: conn,
+	}
+}
+
+func agencyFields(a *models.Agency) []any {
+	return []any{
+		&a.ID,
+		&a.Slug,
+		&a.Name,
+		&a.Settings,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	}
+}
+
+func (db *agency) Create(ctx context.Context, a models.Agency) (*models.Agency, error) {
+	defer metrics.ObserveQuery("agency", "Create")()
+
+	err := db.conn.QueryRow(
+		ctx,
+		`INSERT INTO agencies AS ag (slug, name, settings)
+		VALUES ($1, $2, $3)
+		RETURNING `+agencyColumns,
+		a.Slug,
+		a.Name,
+		a.Settings,
+	).Scan(agencyFields(&a)...)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, errAgencySlugTaken
+		}
+		return nil, ruleError(err)
+	}
+	return &a, nil
+}
+
+// GetAll lists every agency with the size of its records.
+func (db *agency) GetAll(ctx context.Context) ([]models.AgencySummary, error) {
+	defer metrics.ObserveQuery("agency", "GetAll")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT `+agencyColumns+`,
+			(SELECT COUNT(*) FROM cats WHERE agency_id = ag.id),
+			(SELECT COUNT(*) FROM missions WHERE agency_id = ag.id AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM missions WHERE agency_id = ag.id AND deleted_at IS NULL AND NOT completed),
+			(SELECT COUNT(*) FROM mission_templates WHERE agency_id = ag.id),
+			(SELECT COUNT(*) FROM agency_breeds WHERE agency_id = ag.id)
+		FROM agencies ag
+		ORDER BY ag.slug`,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	agencies := []models.AgencySummary{}
+	for rows.Next() {
+		var s models.AgencySummary
+		fields := append(agencyFields(&s.Agency), &s.Cats, &s.Missions, &s.ActiveMissions, &s.Templates, &s.BreedOverrides)
+		if err := rows.Scan(fields...); err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		agencies = append(agencies, s)
+	}
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return agencies, nil
+}
+
+// GetBySlug returns nil when there is no such agency.
+func (db *agency) GetBySlug(ctx context.Context, slug string) (*models.Agency, error) {
+	defer metrics.ObserveQuery("agency", "GetBySlug")()
+
+	var a models.Agency
+	err := db.conn.QueryRow(
+		ctx,
+		`SELECT `+agencyColumns+` FROM agencies ag WHERE ag.slug = $1`,
+		slug,
+	).Scan(agencyFields(&a)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return &a, nil
+}
+
+// UpdateSettings replaces the agency's settings; it returns nil when there
+// is no such agency.
+func (db *agency) UpdateSettings(ctx context.Context, id uuid.UUID, settings models.AgencySettings) (*models.Agency, error) {
+	defer metrics.ObserveQuery("agency", "UpdateSettings")()
+
+	var a models.Agency
+	err := db.conn.QueryRow(
+		ctx,
+		`UPDATE agencies AS ag SET settings = $2 WHERE ag.id = $1
+		RETURNING `+agencyColumns,
+		id,
+		settings,
+	).Scan(agencyFields(&a)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+	return &a, nil
+}
+
+func (db *agency) GetBreedOverrides(ctx context.Context, agencyID uuid.UUID) ([]models.BreedOverride, error) {
+	defer metrics.ObserveQuery("agency", "GetBreedOverrides")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT o.agency_id, o.breed_id, o.name, o.hidden
+		FROM agency_breeds o
+		JOIN breeds b ON b.id = o.breed_id
+		WHERE o.agency_id = $1
+		ORDER BY b.name`,
+		agencyID,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := []models.BreedOverride{}
+	for rows.Next() {
+		var o models.BreedOverride
+		if err := rows.Scan(&o.AgencyID, &o.BreedID, &o.Name, &o.Hidden); err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// SetBreedOverride creates or replaces how the agency sees a breed.
+func (db *agency) SetBreedOverride(ctx context.Context, o models.BreedOverride) error {
+	defer metrics.ObserveQuery("agency", "SetBreedOverride")()
+
+	_, err := db.conn.Exec(
+		ctx,
+		`INSERT INTO agency_breeds (agency_id, breed_id, name, hidden)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (agency_id, breed_id) DO UPDATE
+			SET name = EXCLUDED.name, hidden = EXCLUDED.hidden`,
+		o.AgencyID,
+		o.BreedID,
+		o.Name,
+		o.Hidden,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return errBreedNotFound
+		}
+		return ruleError(err)
+	}
+	return nil
+}
+
+// DeleteBreedOverride reports whether the agency had an override for the
+// breed.
+func (db *agency) DeleteBreedOverride(ctx context.Context, agencyID, breedID uuid.UUID) (bool, error) {
+	defer metrics.ObserveQuery("agency", "DeleteBreedOverride")()
+
+	tag, err := db.conn.Exec(
+		ctx,
+		"DELETE FROM agency_breeds WHERE agency_id = $1 AND breed_id = $2",
+		agencyID,
+		breedID,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
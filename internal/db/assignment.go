@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+)
+
+const uniqueViolation = "23505"
+
+// assignmentConflicts explains which unique index an assignment broke.
+var assignmentConflicts = map[string]error{
+	"uq_assignments_cat_active":   fault.Conflictf("cat is already on an active mission"),
+	"uq_assignments_mission_lead": fault.Conflictf("mission already has a lead"),
+	"uq_assignments_mission_cat":  fault.Conflictf("cat is already assigned to this mission"),
+}
+
+const assignmentColumns = `a.id, a.mission_id, a.cat_id, COALESCE(a.cat_name, ''), a.role,
+	a.assigned_at, a.assigned_by, a.unassigned_at, a.unassigned_by, a.unassign_reason`
+
+func insertAssignment(ctx context.Context, q querier, missionID, catID uuid.UUID, role, by string) (*models.Assignment, error) {
+	assignment := models.Assignment{
+		MissionID:  missionID,
+		CatID:      &catID,
+		Role:       role,
+		AssignedBy: by,
+	}
+
+	err := q.QueryRow(
+		ctx,
+		`INSERT INTO mission_assignments (agency_id, mission_id, cat_id, cat_name, role, assigned_by, mission_completed)
+		SELECT m.agency_id, m.id, $2, (SELECT name FROM cats WHERE id = $2 AND agency_id = $5), $3, $4, m.completed
+		FROM missions m WHERE m.id = $1 AND m.deleted_at IS NULL AND m.agency_id = $5
+		RETURNING id, COALESCE(cat_name, ''), assigned_at`,
+		missionID,
+		catID,
+		role,
+		by,
+		tenant.ID(ctx),
+	).Scan(&assignment.ID, &assignment.CatName, &assignment.AssignedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			if conflict, ok := assignmentConflicts[pgErr.ConstraintName]; ok {
+				return nil, conflict
+			}
+		}
+		return nil, ruleError(err)
+	}
+	return &assignment, nil
+}
+
+// closeAssignments unassigns the mission's active assignments matching cond,
+// which may refer to args from $5 on.
+func closeAssignments(ctx context.Context, q querier, missionID uuid.UUID, cond, by, reason string, args ...any) (int64, error) {
+	tag, err := q.Exec(
+		ctx,
+		`UPDATE mission_assignments
+		SET unassigned_at = now(), unassigned_by = $2, unassign_reason = $3
+		WHERE mission_id = $1 AND agency_id = $4 AND unassigned_at IS NULL AND `+cond,
+		append([]any{missionID, by, reason, tenant.ID(ctx)}, args...)...,
+	)
+	if err != nil {
+		return 0, ruleError(err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// missionAssignments lists a mission's assignments, only the active ones
+// unless history is set.
+func missionAssignments(ctx context.Context, q querier, missionID uuid.UUID, history bool) ([]models.Assignment, error) {
+	rows, err := q.Query(
+		ctx,
+		`SELECT `+assignmentColumns+`
+		FROM mission_assignments a
+		WHERE a.mission_id = $1 AND a.agency_id = $3 AND ($2 OR a.unassigned_at IS NULL)
+		ORDER BY CASE a.role WHEN 'lead' THEN 0 WHEN 'support' THEN 1 ELSE 2 END, a.assigned_at`,
+		missionID,
+		history,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []models.Assignment{}
+	for rows.Next() {
+		var a models.Assignment
+		if err := rows.Scan(assignmentFields(&a)...); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+
+	return assignments, rows.Err()
+}
+
+func assignmentFields(a *models.Assignment) []any {
+	return []any{
+		&a.ID,
+		&a.MissionID,
+		&a.CatID,
+		&a.CatName,
+		&a.Role,
+		&a.AssignedAt,
+		&a.AssignedBy,
+		&a.UnassignedAt,
+		&a.UnassignedBy,
+		&a.UnassignReason,
+	}
+}
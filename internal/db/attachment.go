@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+)
+
+const attachmentColumns = `id, target_id, filename, content_type, size_bytes, sha256, storage_key, uploaded_by, created_at`
+
+func attachmentFields(a *models.Attachment) []any {
+	return []any{
+		&a.ID,
+		&a.TargetID,
+		&a.Filename,
+		&a.ContentType,
+		&a.SizeBytes,
+		&a.SHA256,
+		&a.StorageKey,
+		&a.UploadedBy,
+		&a.CreatedAt,
+	}
+}
+
+type attachment struct {
+	conn *pgxpool.Pool
+}
+
+func newAttachment(conn *pgxpool.Pool) *attachment {
+	return &attachment{
+		conn: conn,
+	}
+}
+
+// Create records an uploaded file. ID and StorageKey are chosen by the
+// caller, who has already stored the blob.
+func (db *attachment) Create(ctx context.Context, attachment models.Attachment) (*models.Attachment, error) {
+	defer metrics.ObserveQuery("attachment", "Create")()
+
+	err := db.conn.QueryRow(
+		ctx,
+		`INSERT INTO target_attachments (agency_id, id, target_id, filename, content_type, size_bytes, sha256, storage_key, uploaded_by)
+		VALUES ($9, $1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at`,
+		attachment.ID,
+		attachment.TargetID,
+		attachment.Filename,
+		attachment.ContentType,
+		attachment.SizeBytes,
+		attachment.SHA256,
+		attachment.StorageKey,
+		attachment.UploadedBy,
+		tenant.ID(ctx),
+	).Scan(&attachment.CreatedAt)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+	return &attachment, nil
+}
+
+func (db *attachment) GetByTarget(ctx context.Context, targetID uuid.UUID) ([]models.Attachment, error) {
+	defer metrics.ObserveQuery("attachment", "GetByTarget")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT `+attachmentColumns+`
+		FROM target_attachments
+		WHERE target_id = $1 AND agency_id = $2
+		ORDER BY created_at`,
+		targetID,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []models.Attachment{}
+	for rows.Next() {
+		var a models.Attachment
+		if err := rows.Scan(attachmentFields(&a)...); err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// GetByID returns nil when the target has no such attachment.
+func (db *attachment) GetByID(ctx context.Context, targetID, id uuid.UUID) (*models.Attachment, error) {
+	defer metrics.ObserveQuery("attachment", "GetByID")()
+
+	var a models.Attachment
+	err := db.conn.QueryRow(
+		ctx,
+		`SELECT `+attachmentColumns+`
+		FROM target_attachments
+		WHERE target_id = $1 AND id = $2 AND agency_id = $3`,
+		targetID,
+		id,
+		tenant.ID(ctx),
+	).Scan(attachmentFields(&a)...)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (db *attachment) Count(ctx context.Context, targetID uuid.UUID) (int, error) {
+	defer metrics.ObserveQuery("attachment", "Count")()
+
+	var n int
+	err := db.conn.QueryRow(
+		ctx,
+		"SELECT COUNT(*) FROM target_attachments WHERE target_id = $1 AND agency_id = $2",
+		targetID,
+		tenant.ID(ctx),
+	).Scan(&n)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return 0, err
+	}
+	return n, nil
+}
+
+// Delete removes the record and reports whether it existed. The database
+// keeps the evidence of completed targets.
+func (db *attachment) Delete(ctx context.Context, targetID, id uuid.UUID) (bool, error) {
+	defer metrics.ObserveQuery("attachment", "Delete")()
+
+	tag, err := db.conn.Exec(
+		ctx,
+		"DELETE FROM target_attachments WHERE target_id = $1 AND id = $2 AND agency_id = $3",
+		targetID,
+		id,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+)
+
+type audit struct {
+	conn *pgxpool.Pool
+}
+
+func newAudit(conn *pgxpool.Pool) *audit {
+	return &audit{
+		conn: conn,
+	}
+}
+
+// Record stores the entries of one response together.
+func (db *audit) Record(ctx context.Context, entries []models.AuditEntry) error {
+	defer metrics.ObserveQuery("audit", "Record")()
+
+	batch := &pgx.Batch{}
+	for _, e := range entries {
+		batch.Queue(
+			`INSERT INTO classified_reads (agency_id, principal, clearance, kind, object_id, classification, outcome, path)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			tenant.ID(ctx),
+			e.Principal,
+			e.Clearance,
+			e.Kind,
+			e.ObjectID,
+			e.Classification,
+			e.Outcome,
+			e.Path,
+		)
+	}
+
+	if err := db.conn.SendBatch(ctx, batch).Close(); err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+	return nil
+}
+
+// GetAll returns the newest entries first.
+func (db *audit) GetAll(ctx context.Context, limit int) ([]models.AuditEntry, error) {
+	defer metrics.ObserveQuery("audit", "GetAll")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT id, principal, clearance, kind, object_id, classification, outcome, path, read_at
+		FROM classified_reads
+		WHERE agency_id = $1
+		ORDER BY read_at DESC
+		LIMIT $2`,
+		tenant.ID(ctx),
+		limit,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		err := rows.Scan(
+			&e.ID,
+			&e.Principal,
+			&e.Clearance,
+			&e.Kind,
+			&e.ObjectID,
+			&e.Classification,
+			&e.Outcome,
+			&e.Path,
+			&e.At,
+		)
+		if err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return entries, nil
+}
@@ -7,8 +7,10 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
 	"github.com/mksmstpck/spy_cat_agency/internal/models"
-	"github.com/sirupsen/logrus"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
 )
 
 type breed struct {
@@ -22,14 +24,17 @@ func newBreed(conn *pgxpool.Pool) *breed {
 }
 
 func (db *breed) Create(ctx context.Context, breed models.Breed) (*models.Breed, error) {
+	defer metrics.ObserveQuery("breed", "Create")()
+
 	row := db.conn.QueryRow(
 		ctx,
-		`INSERT INTO breeds (api_id, name)
-         VALUES ($1, $2)
+		`INSERT INTO breeds (api_id, name, profile)
+         VALUES ($1, $2, COALESCE($3, '{}'::jsonb))
          ON CONFLICT (api_id) DO NOTHING
          RETURNING id, created_at;`,
 		breed.ApiID,
 		breed.Name,
+		breed.Profile,
 	)
 
 	err := row.Scan(&breed.ID, &breed.CreatedAt)
@@ -52,14 +57,21 @@ func (db *breed) Create(ctx context.Context, breed models.Breed) (*models.Breed,
 }
 
 func (db *breed) GetAll(ctx context.Context) ([]models.Breed, error) {
+	defer metrics.ObserveQuery("breed", "GetAll")()
+
 	var breeds []models.Breed
 	rows, err := db.conn.Query(
 		ctx,
-		`SELECT id, name, api_id, created_at FROM breeds`,
+		`SELECT b.id, COALESCE(o.name, b.name), b.api_id, b.profile, b.created_at, b.removed_at
+		FROM breeds b
+		LEFT JOIN agency_breeds o ON o.breed_id = b.id AND o.agency_id = $1
+		WHERE NOT COALESCE(o.hidden, FALSE)
+		ORDER BY 2`,
+		tenant.ID(ctx),
 	)
 
 	if err != nil {
-		logrus.Error(err)
+		logging.From(ctx).Error(err)
 		return nil, err
 	}
 
@@ -72,11 +84,13 @@ func (db *breed) GetAll(ctx context.Context) ([]models.Breed, error) {
 			&breed.ID,
 			&breed.Name,
 			&breed.ApiID,
+			&breed.Profile,
 			&breed.CreatedAt,
+			&breed.RemovedAt,
 		)
 
 		if err != nil {
-			logrus.Error(err)
+			logging.From(ctx).Error(err)
 			return nil, err
 		}
 
@@ -86,11 +100,21 @@ func (db *breed) GetAll(ctx context.Context) ([]models.Breed, error) {
 	return breeds, nil
 }
 
+// GetByName finds a breed by its name in the agency, or nil if it has none
+// by that name.
 func (db *breed) GetByName(ctx context.Context, name string) (*models.Breed, error) {
+	defer metrics.ObserveQuery("breed", "GetByName")()
+
 	row := db.conn.QueryRow(
 		ctx,
-		`SELECT id, name, api_id, created_at FROM breeds WHERE name = $1;`,
+		`SELECT b.id, COALESCE(o.name, b.name), b.api_id, b.profile, b.created_at, b.removed_at
+		FROM breeds b
+		LEFT JOIN agency_breeds o ON o.breed_id = b.id AND o.agency_id = $2
+		WHERE (b.name = $1 OR o.name = $1) AND NOT COALESCE(o.hidden, FALSE)
+		ORDER BY o.name = $1, b.removed_at IS NOT NULL, b.created_at
+		LIMIT 1;`,
 		name,
+		tenant.ID(ctx),
 	)
 
 	var breed models.Breed
@@ -99,12 +123,109 @@ func (db *breed) GetByName(ctx context.Context, name string) (*models.Breed, err
 		&breed.ID,
 		&breed.Name,
 		&breed.ApiID,
+		&breed.Profile,
 		&breed.CreatedAt,
+		&breed.RemovedAt,
 	)
 
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	return &breed, nil
 }
+
+// Upsert inserts a breed or brings an existing one with the same api_id up to
+// date, reporting whether it was new, renamed, had its profile changed or was
+// back after being removed.
+func (db *breed) Upsert(ctx context.Context, breed models.Breed) (*models.Breed, models.BreedChange, error) {
+	defer metrics.ObserveQuery("breed", "Upsert")()
+
+	var oldName *string
+	var wasRemoved *bool
+	err := db.conn.QueryRow(
+		ctx,
+		`WITH old AS (
+			SELECT name, removed_at IS NOT NULL AS removed FROM breeds WHERE api_id = $1
+		)
+		INSERT INTO breeds (api_id, name, profile)
+		VALUES ($1, $2, COALESCE($3, '{}'::jsonb))
+		ON CONFLICT (api_id) DO UPDATE
+			SET name = EXCLUDED.name, profile = EXCLUDED.profile, removed_at = NULL
+			WHERE breeds.name IS DISTINCT FROM EXCLUDED.name
+			OR breeds.profile IS DISTINCT FROM EXCLUDED.profile
+			OR breeds.removed_at IS NOT NULL
+		RETURNING id, created_at, (SELECT name FROM old), (SELECT removed FROM old);`,
+		breed.ApiID,
+		breed.Name,
+		breed.Profile,
+	).Scan(&breed.ID, &breed.CreatedAt, &oldName, &wasRemoved)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The conflict update was skipped: the row is already current.
+		err = db.conn.QueryRow(
+			ctx,
+			`SELECT id, created_at FROM breeds WHERE api_id = $1;`,
+			breed.ApiID,
+		).Scan(&breed.ID, &breed.CreatedAt)
+		if err != nil {
+			return nil, "", fmt.Errorf("breed exists but failed to fetch: %w", err)
+		}
+		return &breed, models.BreedUnchanged, nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to upsert breed: %w", err)
+	}
+
+	switch {
+	case oldName == nil:
+		return &breed, models.BreedCreated, nil
+	case *oldName != breed.Name:
+		return &breed, models.BreedRenamed, nil
+	case wasRemoved != nil && *wasRemoved:
+		return &breed, models.BreedRestored, nil
+	default:
+		return &breed, models.BreedUpdated, nil
+	}
+}
+
+// MarkRemoved flags every TheCatAPI breed whose api_id is not in keep. Rows
+// are kept because cats still reference them.
+func (db *breed) MarkRemoved(ctx context.Context, keep []string) (int64, error) {
+	defer metrics.ObserveQuery("breed", "MarkRemoved")()
+
+	tag, err := db.conn.Exec(
+		ctx,
+		`UPDATE breeds
+		SET removed_at = now()
+		WHERE removed_at IS NULL
+		AND api_id IS NOT NULL
+		AND NOT (api_id = ANY($1))`,
+		keep,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return 0, ruleError(err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// MarkSynced records a breed sync that succeeded, replacing the previous one.
+func (db *breed) MarkSynced(ctx context.Context, source string) error {
+	defer metrics.ObserveQuery("breed", "MarkSynced")()
+
+	_, err := db.conn.Exec(
+		ctx,
+		`INSERT INTO breed_syncs (source, synced_at) VALUES ($1, now())
+		ON CONFLICT (id) DO UPDATE SET source = EXCLUDED.source, synced_at = EXCLUDED.synced_at`,
+		source,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+)
+
+type country struct {
+	conn *pgxpool.Pool
+}
+
+func newCountry(conn *pgxpool.Pool) *country {
+	return &country{
+		conn: conn,
+	}
+}
+
+func (db *country) GetAll(ctx context.Context) ([]models.Country, error) {
+	defer metrics.ObserveQuery("country", "GetAll")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT code, alpha3, name
+		FROM countries
+		ORDER BY code`,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var countries []models.Country
+	for rows.Next() {
+		var country models.Country
+		if err := rows.Scan(&country.Code, &country.Alpha3, &country.Name); err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		countries = append(countries, country)
+	}
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return countries, nil
+}
+
+// GetUnresolved lists live targets whose country is not a known code.
+func (db *country) GetUnresolved(ctx context.Context) ([]models.UnresolvedCountry, error) {
+	defer metrics.ObserveQuery("country", "GetUnresolved")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT u.target_id, u.mission_id, u.name, u.country
+		FROM unresolved_target_countries u
+		JOIN targets t ON t.id = u.target_id
+		WHERE t.deleted_at IS NULL AND t.agency_id = $1
+		ORDER BY u.country, u.name`,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var unresolved []models.UnresolvedCountry
+	for rows.Next() {
+		var u models.UnresolvedCountry
+		if err := rows.Scan(&u.TargetID, &u.MissionID, &u.Name, &u.Country); err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		unresolved = append(unresolved, u)
+	}
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return unresolved, nil
+}
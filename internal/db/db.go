@@ -1,19 +1,68 @@
 package db
 
-import "github.com/jackc/pgx/v5/pgxpool"
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/keyring"
+)
+
+// raiseException is the SQLSTATE of RAISE EXCEPTION, which the triggers
+// guarding the business rules use.
+const raiseException = "P0001"
 
 type DB struct {
-	Breed   breed
-	SpyCat  spyCat
-	Mission mission
-	Target  target
+	Breed      breed
+	SpyCat     spyCat
+	Mission    mission
+	Target     target
+	Stats      stats
+	Health     health
+	Skill      skill
+	Leave      leave
+	Trash      trash
+	Search     search
+	Country    country
+	Attachment attachment
+	Template   template
+	Agency     agency
+	Principal  principal
+	Audit      audit
 }
 
-func NewDB(conn *pgxpool.Pool) *DB {
+// NewDB builds the repositories. notes encrypts target notes on the way in
+// and decrypts them on the way out; nothing above this package sees them
+// sealed. index keeps the blind index search finds notes by.
+func NewDB(conn *pgxpool.Pool, notes *keyring.Keyring, index *keyring.Index) *DB {
 	return &DB{
-		Breed:   *newBreed(conn),
-		SpyCat:  *newSpyCat(conn),
-		Mission: *newMission(conn),
-		Target:  *newTarget(conn),
+		Breed:      *newBreed(conn),
+		SpyCat:     *newSpyCat(conn),
+		Mission:    *newMission(conn, notes, index),
+		Target:     *newTarget(conn, notes, index),
+		Stats:      *newStats(conn),
+		Health:     *newHealth(conn),
+		Skill:      *newSkill(conn),
+		Leave:      *newLeave(conn),
+		Trash:      *newTrash(conn),
+		Search:     *newSearch(conn, notes, index),
+		Country:    *newCountry(conn),
+		Attachment: *newAttachment(conn),
+		Template:   *newTemplate(conn, notes),
+		Agency:     *newAgency(conn),
+		Principal:  *newPrincipal(conn),
+		Audit:      *newAudit(conn),
+	}
+}
+
+// ruleError turns a rule a trigger refused into a conflict carrying the
+// trigger's message. Every write returns its errors through it; other
+// errors pass unchanged.
+func ruleError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == raiseException {
+		return fault.Conflictf("%s", pgErr.Message)
 	}
+	return err
 }
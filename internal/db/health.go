@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+// SchemaVersion is the newest migration in /migrations. Bump it together with
+// every new migration so readiness notices a database that was not migrated.
+const SchemaVersion = 21
+
+type health struct {
+	conn *pgxpool.Pool
+}
+
+func newHealth(conn *pgxpool.Pool) *health {
+	return &health{
+		conn: conn,
+	}
+}
+
+func (db *health) Ping(ctx context.Context) error {
+	return db.conn.Ping(ctx)
+}
+
+// SchemaVersion reads the state golang-migrate keeps in schema_migrations.
+func (db *health) SchemaVersion(ctx context.Context) (int64, bool, error) {
+	var version int64
+	var dirty bool
+	err := db.conn.QueryRow(
+		ctx,
+		`SELECT version, dirty FROM schema_migrations LIMIT 1`,
+	).Scan(&version, &dirty)
+	if err != nil {
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
+
+// LastBreedSync reads the marker of the last breed sync that succeeded, or
+// nil if none has since the database was created.
+func (db *health) LastBreedSync(ctx context.Context) (*models.BreedSync, error) {
+	var sync models.BreedSync
+	err := db.conn.QueryRow(
+		ctx,
+		`SELECT source, synced_at FROM breed_syncs`,
+	).Scan(&sync.Source, &sync.SyncedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sync, nil
+}
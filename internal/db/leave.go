@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+)
+
+type leave struct {
+	conn *pgxpool.Pool
+}
+
+func newLeave(conn *pgxpool.Pool) *leave {
+	return &leave{
+		conn: conn,
+	}
+}
+
+func (db *leave) Create(ctx context.Context, leave models.Leave) (*models.Leave, error) {
+	defer metrics.ObserveQuery("leave", "Create")()
+
+	err := db.conn.QueryRow(
+		ctx,
+		`INSERT INTO cat_leaves (agency_id, cat_id, kind, starts_on, ends_on, note)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`,
+		tenant.ID(ctx),
+		leave.CatID,
+		leave.Kind,
+		leave.StartsOn,
+		leave.EndsOn,
+		leave.Note,
+	).Scan(&leave.ID, &leave.CreatedAt)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+	return &leave, nil
+}
+
+// GetByCat returns the cat's leave that overlaps the given window. A nil
+// bound leaves the window open on that side.
+func (db *leave) GetByCat(ctx context.Context, catID uuid.UUID, from, to *time.Time) ([]models.Leave, error) {
+	defer metrics.ObserveQuery("leave", "GetByCat")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT id, cat_id, kind, starts_on, ends_on, note, created_at
+		FROM cat_leaves
+		WHERE cat_id = $1 AND agency_id = $4
+		AND ($2::date IS NULL OR ends_on >= $2)
+		AND ($3::date IS NULL OR starts_on <= $3)
+		ORDER BY starts_on`,
+		catID,
+		from,
+		to,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var leaves []models.Leave
+	for rows.Next() {
+		var leave models.Leave
+		err := rows.Scan(
+			&leave.ID,
+			&leave.CatID,
+			&leave.Kind,
+			&leave.StartsOn,
+			&leave.EndsOn,
+			&leave.Note,
+			&leave.CreatedAt,
+		)
+		if err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		leaves = append(leaves, leave)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return leaves, nil
+}
+
+// Delete removes one leave of a cat and reports whether it existed.
+func (db *leave) Delete(ctx context.Context, catID, id uuid.UUID) (bool, error) {
+	defer metrics.ObserveQuery("leave", "Delete")()
+
+	tag, err := db.conn.Exec(
+		ctx,
+		"DELETE FROM cat_leaves WHERE id = $1 AND cat_id = $2 AND agency_id = $3",
+		id,
+		catID,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
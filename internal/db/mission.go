@@ -2,68 +2,131 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/keyring"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
 	"github.com/mksmstpck/spy_cat_agency/internal/models"
-	"github.com/sirupsen/logrus"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
 )
 
+// leadCatColumn selects a mission's active lead as assigned_cat_id.
+const leadCatColumn = `(SELECT a.cat_id FROM mission_assignments a
+	WHERE a.mission_id = missions.id AND a.role = 'lead' AND a.unassigned_at IS NULL) AS assigned_cat_id`
+
+const missionColumns = `id, title, description, ` + leadCatColumn + `, completed, classification,
+	scheduled_start, scheduled_end, created_at, updated_at`
+
+func missionFields(m *models.Mission) []any {
+	return []any{
+		&m.ID,
+		&m.Title,
+		&m.Description,
+		&m.AssignedCatID,
+		&m.Completed,
+		&m.Classification,
+		&m.ScheduledStart,
+		&m.ScheduledEnd,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+	}
+}
+
 type mission struct {
-	conn *pgxpool.Pool
+	conn  *pgxpool.Pool
+	notes *keyring.Keyring
+	index *keyring.Index
 }
 
-func newMission(conn *pgxpool.Pool) *mission {
+func newMission(conn *pgxpool.Pool, notes *keyring.Keyring, index *keyring.Index) *mission {
 	return &mission{
-		conn: conn,
+		conn:  conn,
+		notes: notes,
+		index: index,
 	}
 }
 
-func (db *mission) Create(ctx context.Context, mission models.Mission, targets []models.Target) (*models.Mission, error) {
+// Create stores the mission with its targets; by records who assigned the
+// lead cat, if any.
+func (db *mission) Create(ctx context.Context, mission models.Mission, targets []models.Target, by string) (*models.Mission, error) {
+	defer metrics.ObserveQuery("mission", "Create")()
+
 	tx, err := db.conn.Begin(ctx)
 	if err != nil {
-		logrus.Error(err)
-		return nil, err
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
 	}
 	defer tx.Rollback(ctx)
 
 	err = tx.QueryRow(
 		ctx,
-		`INSERT INTO missions (title, description, assigned_cat_id)
-		VALUES ($1, $2, $3)
+		`INSERT INTO missions (agency_id, title, description, scheduled_start, scheduled_end, classification)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, completed, created_at, updated_at`,
+		tenant.ID(ctx),
 		mission.Title,
 		mission.Description,
-		mission.AssignedCatID,
+		mission.ScheduledStart,
+		mission.ScheduledEnd,
+		mission.Classification,
 	).Scan(&mission.ID, &mission.Completed, &mission.CreatedAt, &mission.UpdatedAt)
 	if err != nil {
-		logrus.Error(err)
-		return nil, err
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+
+	mission.Assignments = []models.Assignment{}
+	if mission.AssignedCatID != nil {
+		lead, err := insertAssignment(ctx, tx, mission.ID, *mission.AssignedCatID, models.RoleLead, by)
+		if err != nil {
+			logging.From(ctx).Error(err)
+			return nil, ruleError(err)
+		}
+		mission.Assignments = append(mission.Assignments, *lead)
 	}
 
-	
 	for i := range targets {
 		targets[i].MissionID = mission.ID
+		sealed, err := db.notes.Seal(targets[i].Notes)
+		if err != nil {
+			logging.From(ctx).Error(err)
+			return nil, ruleError(err)
+		}
 		err = tx.QueryRow(
 			ctx,
-			`INSERT INTO targets (mission_id, name, country, notes)
-			VALUES ($1, $2, $3, $4)
+			`INSERT INTO targets (agency_id, mission_id, name, country, notes, latitude, longitude, city, address, classification, notes_index)
+			VALUES ($9, $1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $10, $11)
 			RETURNING id, completed, created_at, updated_at`,
 			targets[i].MissionID,
 			targets[i].Name,
 			targets[i].Country,
-			targets[i].Notes,
+			sealed,
+			targets[i].Latitude,
+			targets[i].Longitude,
+			targets[i].City,
+			targets[i].Address,
+			tenant.ID(ctx),
+			targets[i].Classification,
+			db.index.Terms(targets[i].Notes),
 		).Scan(&targets[i].ID, &targets[i].Completed, &targets[i].CreatedAt, &targets[i].UpdatedAt)
 		if err != nil {
-			logrus.Error(err)
-			return nil, err
+			logging.From(ctx).Error(err)
+			return nil, ruleError(err)
+		}
+
+		if err = insertRequirements(ctx, tx, targets[i].ID, targets[i].RequiredSkills); err != nil {
+			logging.From(ctx).Error(err)
+			return nil, ruleError(err)
 		}
 	}
 
 	if err = tx.Commit(ctx); err != nil {
-		logrus.Error(err)
-		return nil, err
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
 	}
 
 	mission.Targets = targets
@@ -71,14 +134,18 @@ func (db *mission) Create(ctx context.Context, mission models.Mission, targets [
 }
 
 func (db *mission) GetAll(ctx context.Context) ([]models.Mission, error) {
+	defer metrics.ObserveQuery("mission", "GetAll")()
+
 	rows, err := db.conn.Query(
 		ctx,
-		`SELECT id, title, description, assigned_cat_id, completed, created_at, updated_at
+		`SELECT `+missionColumns+`
 		FROM missions
+		WHERE deleted_at IS NULL AND agency_id = $1
 		ORDER BY created_at DESC`,
+		tenant.ID(ctx),
 	)
 	if err != nil {
-		logrus.Error(err)
+		logging.From(ctx).Error(err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -86,27 +153,24 @@ func (db *mission) GetAll(ctx context.Context) ([]models.Mission, error) {
 	var missions []models.Mission
 	for rows.Next() {
 		var mission models.Mission
-		err := rows.Scan(
-			&mission.ID,
-			&mission.Title,
-			&mission.Description,
-			&mission.AssignedCatID,
-			&mission.Completed,
-			&mission.CreatedAt,
-			&mission.UpdatedAt,
-		)
+		err := rows.Scan(missionFields(&mission)...)
 		if err != nil {
-			logrus.Error(err)
+			logging.From(ctx).Error(err)
 			return nil, err
 		}
 
 		targets, err := db.getTargetsByMissionID(ctx, mission.ID)
 		if err != nil {
-			logrus.Error(err)
+			logging.From(ctx).Error(err)
 			return nil, err
 		}
 		mission.Targets = targets
 
+		if mission.Assignments, err = missionAssignments(ctx, db.conn, mission.ID, false); err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+
 		missions = append(missions, mission)
 	}
 
@@ -118,93 +182,322 @@ func (db *mission) GetAll(ctx context.Context) ([]models.Mission, error) {
 }
 
 func (db *mission) GetByID(ctx context.Context, id uuid.UUID) (*models.Mission, error) {
+	defer metrics.ObserveQuery("mission", "GetByID")()
+
 	var mission models.Mission
 	err := db.conn.QueryRow(
 		ctx,
-		`SELECT id, title, description, assigned_cat_id, completed, created_at, updated_at
+		`SELECT `+missionColumns+`
 		FROM missions
-		WHERE id = $1`,
+		WHERE id = $1 AND deleted_at IS NULL AND agency_id = $2`,
 		id,
-	).Scan(
-		&mission.ID,
-		&mission.Title,
-		&mission.Description,
-		&mission.AssignedCatID,
-		&mission.Completed,
-		&mission.CreatedAt,
-		&mission.UpdatedAt,
-	)
+		tenant.ID(ctx),
+	).Scan(missionFields(&mission)...)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
-		logrus.Error(err)
+		logging.From(ctx).Error(err)
 		return nil, err
 	}
 
 	targets, err := db.getTargetsByMissionID(ctx, mission.ID)
 	if err != nil {
-		logrus.Error(err)
+		logging.From(ctx).Error(err)
 		return nil, err
 	}
 	mission.Targets = targets
 
+	if mission.Assignments, err = missionAssignments(ctx, db.conn, mission.ID, false); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+
 	return &mission, nil
 }
 
 func (db *mission) UpdateCompleted(ctx context.Context, id uuid.UUID, completed bool) error {
+	defer metrics.ObserveQuery("mission", "UpdateCompleted")()
+
 	_, err := db.conn.Exec(
 		ctx,
 		`UPDATE missions
 		SET completed = $1
-		WHERE id = $2`,
+		WHERE id = $2 AND deleted_at IS NULL AND agency_id = $3`,
 		completed,
 		id,
+		tenant.ID(ctx),
 	)
 	if err != nil {
-		logrus.Error(err)
-		return err
+		logging.From(ctx).Error(err)
+		return ruleError(err)
 	}
 	return nil
 }
 
-func (db *mission) UpdateAssignedCat(ctx context.Context, id uuid.UUID, catID *uuid.UUID) error {
+// UpdateAssignedCat makes catID the mission's lead, or leaves the mission
+// without a lead when catID is nil, and reports whether the mission exists.
+// The previous lead is unassigned, and a cat already on the mission in
+// another role is promoted.
+func (db *mission) UpdateAssignedCat(ctx context.Context, id uuid.UUID, catID *uuid.UUID, by, reason string) (bool, error) {
+	defer metrics.ObserveQuery("mission", "UpdateAssignedCat")()
+
+	tx, err := db.conn.Begin(ctx)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the mission serialises lead changes and tells a missing
+	// mission from one without a lead.
+	var lead *uuid.UUID
+	err = tx.QueryRow(
+		ctx,
+		`SELECT `+leadCatColumn+`
+		FROM missions
+		WHERE id = $1 AND agency_id = $2 AND deleted_at IS NULL
+		FOR UPDATE`,
+		id,
+		tenant.ID(ctx),
+	).Scan(&lead)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+
+	if lead != nil && catID != nil && *lead == *catID {
+		return true, nil
+	}
+
+	if _, err := closeAssignments(ctx, tx, id, `role = 'lead'`, by, reason); err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+
+	if catID != nil {
+		if _, err := closeAssignments(ctx, tx, id, `cat_id = $5`, by, "promoted to lead", *catID); err != nil {
+			logging.From(ctx).Error(err)
+			return false, ruleError(err)
+		}
+		if _, err := insertAssignment(ctx, tx, id, *catID, models.RoleLead, by); err != nil {
+			logging.From(ctx).Error(err)
+			return false, ruleError(err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+	return true, nil
+}
+
+// Assign adds a cat to the mission in a non-lead role.
+func (db *mission) Assign(ctx context.Context, id, catID uuid.UUID, role, by string) (*models.Assignment, error) {
+	defer metrics.ObserveQuery("mission", "Assign")()
+
+	assignment, err := insertAssignment(ctx, db.conn, id, catID, role, by)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+	return assignment, nil
+}
+
+// Unassign takes a cat off the mission and reports whether it was on it.
+func (db *mission) Unassign(ctx context.Context, id, catID uuid.UUID, by, reason string) (bool, error) {
+	defer metrics.ObserveQuery("mission", "Unassign")()
+
+	n, err := closeAssignments(ctx, db.conn, id, `cat_id = $5`, by, reason, catID)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+	return n > 0, nil
+}
+
+// GetAssignments lists the mission's assignments, including past ones when
+// history is set.
+func (db *mission) GetAssignments(ctx context.Context, id uuid.UUID, history bool) ([]models.Assignment, error) {
+	defer metrics.ObserveQuery("mission", "GetAssignments")()
+
+	assignments, err := missionAssignments(ctx, db.conn, id, history)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// GetCareer lists every assignment a cat has had, newest first.
+func (db *mission) GetCareer(ctx context.Context, catID uuid.UUID) ([]models.CareerEntry, error) {
+	defer metrics.ObserveQuery("mission", "GetCareer")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT `+assignmentColumns+`, m.title, m.completed, m.completed_at
+		FROM mission_assignments a
+		JOIN missions m ON m.id = a.mission_id
+		WHERE a.cat_id = $1 AND a.agency_id = $2 AND m.deleted_at IS NULL
+		ORDER BY a.assigned_at DESC`,
+		catID,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	career := []models.CareerEntry{}
+	for rows.Next() {
+		var entry models.CareerEntry
+		fields := append(assignmentFields(&entry.Assignment), &entry.MissionTitle, &entry.MissionCompleted, &entry.MissionCompletedAt)
+		if err := rows.Scan(fields...); err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		career = append(career, entry)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return career, nil
+}
+
+func (db *mission) UpdateSchedule(ctx context.Context, id uuid.UUID, start, end *time.Time) error {
+	defer metrics.ObserveQuery("mission", "UpdateSchedule")()
+
 	_, err := db.conn.Exec(
 		ctx,
 		`UPDATE missions
-		SET assigned_cat_id = $1
-		WHERE id = $2`,
-		catID,
+		SET scheduled_start = $1, scheduled_end = $2
+		WHERE id = $3 AND deleted_at IS NULL AND agency_id = $4`,
+		start,
+		end,
 		id,
+		tenant.ID(ctx),
 	)
 	if err != nil {
-		logrus.Error(err)
-		return err
+		logging.From(ctx).Error(err)
+		return ruleError(err)
 	}
 	return nil
 }
 
-func (db *mission) Delete(ctx context.Context, id uuid.UUID) error {
+func (db *mission) UpdateClassification(ctx context.Context, id uuid.UUID, level models.Classification) error {
+	defer metrics.ObserveQuery("mission", "UpdateClassification")()
+
 	_, err := db.conn.Exec(
 		ctx,
-		"DELETE FROM missions WHERE id = $1",
+		`UPDATE missions
+		SET classification = $1
+		WHERE id = $2 AND deleted_at IS NULL AND agency_id = $3`,
+		level,
 		id,
+		tenant.ID(ctx),
 	)
 	if err != nil {
-		logrus.Error(err)
-		return err
+		logging.From(ctx).Error(err)
+		return ruleError(err)
 	}
 	return nil
 }
 
+// GetScheduledByCat returns the cat's missions whose schedule overlaps the
+// given days.
+func (db *mission) GetScheduledByCat(ctx context.Context, catID uuid.UUID, from, to time.Time) ([]models.Mission, error) {
+	defer metrics.ObserveQuery("mission", "GetScheduledByCat")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT `+missionColumns+`
+		FROM missions
+		WHERE deleted_at IS NULL AND agency_id = $4
+		AND EXISTS (
+			SELECT 1 FROM mission_assignments a
+			WHERE a.mission_id = missions.id AND a.cat_id = $1 AND a.unassigned_at IS NULL
+		)
+		AND (scheduled_start IS NOT NULL OR scheduled_end IS NOT NULL)
+		AND (scheduled_start IS NULL OR scheduled_start <= $3)
+		AND (scheduled_end IS NULL OR scheduled_end >= $2)
+		ORDER BY scheduled_start NULLS FIRST`,
+		catID,
+		from,
+		to,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var missions []models.Mission
+	for rows.Next() {
+		var mission models.Mission
+		err := rows.Scan(missionFields(&mission)...)
+		if err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		missions = append(missions, mission)
+	}
+
+	return missions, rows.Err()
+}
+
+// Delete moves the mission to the trash and reports whether it was live;
+// Restore brings it back and Purge removes it for good.
+func (db *mission) Delete(ctx context.Context, id uuid.UUID) (bool, error) {
+	defer metrics.ObserveQuery("mission", "Delete")()
+
+	tag, err := db.conn.Exec(
+		ctx,
+		"UPDATE missions SET deleted_at = now() WHERE id = $1 AND agency_id = $2 AND deleted_at IS NULL",
+		id,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Restore takes the mission out of the trash and reports whether it was
+// there. The database re-checks the 1-3 target rule.
+func (db *mission) Restore(ctx context.Context, id uuid.UUID) (bool, error) {
+	defer metrics.ObserveQuery("mission", "Restore")()
+
+	tag, err := db.conn.Exec(
+		ctx,
+		"UPDATE missions SET deleted_at = NULL WHERE id = $1 AND agency_id = $2 AND deleted_at IS NOT NULL",
+		id,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
 func (db *mission) getTargetsByMissionID(ctx context.Context, missionID uuid.UUID) ([]models.Target, error) {
 	rows, err := db.conn.Query(
 		ctx,
-		`SELECT id, mission_id, name, country, notes, completed, created_at, updated_at
-		FROM targets
-		WHERE mission_id = $1
-		ORDER BY created_at ASC`,
+		`SELECT `+targetColumns+`
+		FROM targets t
+		WHERE t.mission_id = $1 AND t.agency_id = $2 AND t.deleted_at IS NULL
+		ORDER BY t.created_at ASC`,
 		missionID,
+		tenant.ID(ctx),
 	)
 	if err != nil {
 		return nil, err
@@ -214,17 +507,10 @@ func (db *mission) getTargetsByMissionID(ctx context.Context, missionID uuid.UUI
 	var targets []models.Target
 	for rows.Next() {
 		var target models.Target
-		err := rows.Scan(
-			&target.ID,
-			&target.MissionID,
-			&target.Name,
-			&target.Country,
-			&target.Notes,
-			&target.Completed,
-			&target.CreatedAt,
-			&target.UpdatedAt,
-		)
-		if err != nil {
+		if err := rows.Scan(targetFields(&target)...); err != nil {
+			return nil, err
+		}
+		if err := openNotes(db.notes, &target); err != nil {
 			return nil, err
 		}
 		targets = append(targets, target)
@@ -234,5 +520,18 @@ func (db *mission) getTargetsByMissionID(ctx context.Context, missionID uuid.UUI
 		return nil, rows.Err()
 	}
 
+	ids := make([]uuid.UUID, len(targets))
+	for i := range targets {
+		ids[i] = targets[i].ID
+	}
+
+	reqs, err := targetRequirements(ctx, db.conn, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range targets {
+		targets[i].RequiredSkills = reqs[targets[i].ID]
+	}
+
 	return targets, nil
 }
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+)
+
+type principal struct {
+	conn *pgxpool.Pool
+}
+
+func newPrincipal(conn *pgxpool.Pool) *principal {
+	return &principal{
+		conn: conn,
+	}
+}
+
+func (db *principal) GetAll(ctx context.Context) ([]models.Principal, error) {
+	defer metrics.ObserveQuery("principal", "GetAll")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT name, clearance, updated_at FROM principals
+		WHERE agency_id = $1
+		ORDER BY name`,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var principals []models.Principal
+	for rows.Next() {
+		var p models.Principal
+		if err := rows.Scan(&p.Name, &p.Clearance, &p.UpdatedAt); err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		principals = append(principals, p)
+	}
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return principals, nil
+}
+
+// GetByName returns nil when the agency has no record of the principal.
+func (db *principal) GetByName(ctx context.Context, name string) (*models.Principal, error) {
+	defer metrics.ObserveQuery("principal", "GetByName")()
+
+	var p models.Principal
+	err := db.conn.QueryRow(
+		ctx,
+		`SELECT name, clearance, updated_at FROM principals
+		WHERE agency_id = $1 AND name = $2`,
+		tenant.ID(ctx),
+		name,
+	).Scan(&p.Name, &p.Clearance, &p.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Set creates the principal or changes its clearance.
+func (db *principal) Set(ctx context.Context, p models.Principal) (*models.Principal, error) {
+	defer metrics.ObserveQuery("principal", "Set")()
+
+	err := db.conn.QueryRow(
+		ctx,
+		`INSERT INTO principals (agency_id, name, clearance)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (agency_id, name) DO UPDATE SET clearance = EXCLUDED.clearance
+		RETURNING updated_at`,
+		tenant.ID(ctx),
+		p.Name,
+		p.Clearance,
+	).Scan(&p.UpdatedAt)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+	return &p, nil
+}
+
+func (db *principal) Delete(ctx context.Context, name string) (bool, error) {
+	defer metrics.ObserveQuery("principal", "Delete")()
+
+	tag, err := db.conn.Exec(
+		ctx,
+		"DELETE FROM principals WHERE agency_id = $1 AND name = $2",
+		tenant.ID(ctx),
+		name,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/keyring"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+)
+
+const (
+	// headlineOptions keeps snippets short enough for a result list.
+	headlineOptions = "StartSel=<b>, StopSel=</b>, MaxWords=30, MinWords=10, MaxFragments=2"
+	// notesRank is what a target's notes add to its rank when they hold
+	// every word of the query, about what a match in a name scores.
+	notesRank = 0.1
+	// notesSnippetWords is how many words of the notes a snippet quotes.
+	notesSnippetWords = 30
+)
+
+type search struct {
+	conn  *pgxpool.Pool
+	notes *keyring.Keyring
+	index *keyring.Index
+}
+
+func newSearch(conn *pgxpool.Pool, notes *keyring.Keyring, index *keyring.Index) *search {
+	return &search{
+		conn:  conn,
+		notes: notes,
+		index: index,
+	}
+}
+
+// Search ranks live missions and targets against the query. The cat filter
+// matches missions the cat is on, or was on when they were completed, and
+// their targets. A target also matches when each word the query asks for is
+// in its name and country or, found through the blind index, in its notes,
+// and never when an excluded word is in either. Notes holding query words
+// add to the rank and are quoted in the snippet. Rows classified above the
+// query's clearance never match.
+func (db *search) Search(ctx context.Context, query models.SearchQuery) ([]models.SearchHit, error) {
+	defer metrics.ObserveQuery("search", "Search")()
+
+	want, exclude := keyring.QueryWords(query.Text)
+
+	rows, err := db.conn.Query(
+		ctx,
+		`WITH q AS (SELECT websearch_to_tsquery('english', $1) AS query)
+		SELECT 'mission', m.id, m.id, m.title,
+			ts_headline('english', concat_ws(' ', m.title, m.description), q.query, $6),
+			m.completed, ts_rank(m.search_vector, q.query), m.classification, NULL
+		FROM missions m, q
+		WHERE m.search_vector @@ q.query
+		AND m.deleted_at IS NULL AND m.agency_id = $7
+		AND m.classification = ANY($8::text[])
+		AND ($2 = '' OR $2 = 'mission')
+		AND ($3::boolean IS NULL OR m.completed = $3)
+		AND ($4::uuid IS NULL OR EXISTS (
+			SELECT 1 FROM mission_assignments a
+			WHERE a.mission_id = m.id AND a.cat_id = $4 AND a.unassigned_at IS NULL))
+		UNION ALL
+		SELECT 'target', t.id, t.mission_id, t.name,
+			ts_headline('english', concat_ws(' ', t.name, coalesce(c.name, t.country)), q.query, $6),
+			t.completed,
+			ts_rank(t.search_vector, q.query) + $13::real * (
+				SELECT count(*) FROM unnest($10::text[]) AS term
+				WHERE t.notes_index @> ARRAY[term])::real / greatest(cardinality($10::text[]), 1),
+			t.classification, t.notes
+		FROM targets t
+		JOIN missions m ON m.id = t.mission_id
+		LEFT JOIN countries c ON c.code = t.country, q
+		WHERE (t.search_vector @@ q.query OR (
+			cardinality($9::text[]) > 0
+			AND NOT EXISTS (
+				SELECT 1 FROM unnest($9::text[], $10::text[]) AS w(word, term)
+				WHERE NOT (t.search_vector @@ plainto_tsquery('english', w.word)
+					OR t.notes_index @> ARRAY[w.term]))))
+		AND NOT EXISTS (
+			SELECT 1 FROM unnest($11::text[], $12::text[]) AS x(word, term)
+			WHERE t.search_vector @@ plainto_tsquery('english', x.word)
+				OR t.notes_index @> ARRAY[x.term])
+		AND t.deleted_at IS NULL AND m.deleted_at IS NULL AND t.agency_id = $7
+		AND t.classification = ANY($8::text[])
+		AND ($2 = '' OR $2 = 'target')
+		AND ($3::boolean IS NULL OR t.completed = $3)
+		AND ($4::uuid IS NULL OR EXISTS (
+			SELECT 1 FROM mission_assignments a
+			WHERE a.mission_id = m.id AND a.cat_id = $4 AND a.unassigned_at IS NULL))
+		ORDER BY 7 DESC, 4
+		LIMIT $5`,
+		query.Text,
+		query.Kind,
+		query.Completed,
+		query.CatID,
+		query.Limit,
+		headlineOptions,
+		tenant.ID(ctx),
+		levels(query.Clearance),
+		want,
+		db.index.Query(want),
+		exclude,
+		db.index.Query(exclude),
+		notesRank,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []models.SearchHit
+	for rows.Next() {
+		var hit models.SearchHit
+		var notes *string
+		err := rows.Scan(
+			&hit.Kind,
+			&hit.ID,
+			&hit.MissionID,
+			&hit.Title,
+			&hit.Snippet,
+			&hit.Completed,
+			&hit.Rank,
+			&hit.Classification,
+			&notes,
+		)
+		if err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		if notes != nil && *notes != "" {
+			plain, err := db.notes.Open(*notes)
+			if err != nil {
+				logging.From(ctx).Error(err)
+				return nil, fmt.Errorf("notes of target %s: %w", hit.ID, err)
+			}
+			hit.Snippet = joinSnippets(hit.Snippet, notesSnippet(plain, want))
+		}
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return hits, nil
+}
+
+// levels is the clearance as the text[] of the levels it covers.
+func levels(clearance models.Classification) []string {
+	var out []string
+	for _, level := range clearance.Cleared() {
+		out = append(out, string(level))
+	}
+	return out
+}
+
+// notesSnippet quotes the notes from just before the first word of want they
+// hold, with every such word in bold as ts_headline marks them. It is empty
+// when the notes hold none.
+func notesSnippet(notes string, want []string) string {
+	fields := strings.Fields(notes)
+	first := -1
+	marked := make([]string, len(fields))
+	for i, field := range fields {
+		marked[i] = field
+		for _, word := range keyring.Words(field) {
+			if slices.Contains(want, word) {
+				marked[i] = "<b>" + field + "</b>"
+				if first < 0 {
+					first = i
+				}
+				break
+			}
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	start := max(0, first-notesSnippetWords/3)
+	end := min(len(fields), start+notesSnippetWords)
+	return strings.Join(marked[start:end], " ")
+}
+
+// joinSnippets puts the fragments together the way ts_headline separates
+// its own, leaving out a fragment that highlights nothing.
+func joinSnippets(fields, notes string) string {
+	switch {
+	case notes == "":
+		return fields
+	case !strings.Contains(fields, "<b>"):
+		return notes
+	default:
+		return fields + " ... " + notes
+	}
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNotesSnippet(t *testing.T) {
+	tests := []struct {
+		name  string
+		notes string
+		want  []string
+		out   string
+	}{
+		{"no match", "Meets the courier", []string{"safehouse"}, ""},
+		{"match", "Meets the courier at the Safehouse.", []string{"safehouse"}, "Meets the courier at the <b>Safehouse.</b>"},
+		{"every match", "safehouse, then a second safehouse", []string{"safehouse", "second"},
+			"<b>safehouse,</b> then a <b>second</b> <b>safehouse</b>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notesSnippet(tt.notes, tt.want); got != tt.out {
+				t.Errorf("got %q, want %q", got, tt.out)
+			}
+		})
+	}
+}
+
+func TestNotesSnippetStartsNearTheFirstMatch(t *testing.T) {
+	notes := strings.Repeat("filler ", 40) + "safehouse"
+
+	got := notesSnippet(notes, []string{"safehouse"})
+	if !strings.HasSuffix(got, " filler <b>safehouse</b>") || len(strings.Fields(got)) != notesSnippetWords/3+1 {
+		t.Errorf("got %q, want the words just before the match", got)
+	}
+}
+
+func TestJoinSnippets(t *testing.T) {
+	tests := []struct{ fields, notes, want string }{
+		{"Mr. Whiskers <b>Austria</b>", "", "Mr. Whiskers <b>Austria</b>"},
+		{"Mr. Whiskers Austria", "the <b>safehouse</b>", "the <b>safehouse</b>"},
+		{"Mr. Whiskers <b>Austria</b>", "the <b>safehouse</b>", "Mr. Whiskers <b>Austria</b> ... the <b>safehouse</b>"},
+	}
+	for _, tt := range tests {
+		if got := joinSnippets(tt.fields, tt.notes); got != tt.want {
+			t.Errorf("joinSnippets(%q, %q) = %q, want %q", tt.fields, tt.notes, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+)
+
+// querier is implemented by both the pool and a transaction, so helpers can
+// run inside whichever the caller holds.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type skill struct {
+	conn *pgxpool.Pool
+}
+
+func newSkill(conn *pgxpool.Pool) *skill {
+	return &skill{
+		conn: conn,
+	}
+}
+
+func (db *skill) Create(ctx context.Context, skill models.Skill) (*models.Skill, error) {
+	defer metrics.ObserveQuery("skill", "Create")()
+
+	err := db.conn.QueryRow(
+		ctx,
+		`INSERT INTO skills (code, name, category)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at`,
+		skill.Code,
+		skill.Name,
+		skill.Category,
+	).Scan(&skill.ID, &skill.CreatedAt)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+	return &skill, nil
+}
+
+func (db *skill) GetAll(ctx context.Context) ([]models.Skill, error) {
+	defer metrics.ObserveQuery("skill", "GetAll")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT id, code, name, category, created_at
+		FROM skills
+		ORDER BY category, code`,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var skills []models.Skill
+	for rows.Next() {
+		var skill models.Skill
+		err := rows.Scan(&skill.ID, &skill.Code, &skill.Name, &skill.Category, &skill.CreatedAt)
+		if err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		skills = append(skills, skill)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return skills, nil
+}
+
+func (db *skill) GetCatSkills(ctx context.Context, catID uuid.UUID) ([]models.CatSkill, error) {
+	defer metrics.ObserveQuery("skill", "GetCatSkills")()
+
+	skills, err := catSkills(ctx, db.conn, []uuid.UUID{catID})
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return skills[catID], nil
+}
+
+// SetCatSkills replaces every skill of a cat.
+func (db *skill) SetCatSkills(ctx context.Context, catID uuid.UUID, skills []models.CatSkill) error {
+	defer metrics.ObserveQuery("skill", "SetCatSkills")()
+
+	tx, err := db.conn.Begin(ctx)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(
+		ctx,
+		`DELETE FROM cat_skills WHERE cat_id = (SELECT id FROM cats WHERE id = $1 AND agency_id = $2)`,
+		catID,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+
+	for _, s := range skills {
+		_, err := tx.Exec(
+			ctx,
+			`INSERT INTO cat_skills (cat_id, skill_id, proficiency)
+			SELECT id, $2, $3 FROM cats WHERE id = $1 AND agency_id = $4`,
+			catID,
+			s.Skill.ID,
+			s.Proficiency,
+			tenant.ID(ctx),
+		)
+		if err != nil {
+			logging.From(ctx).Error(err)
+			return ruleError(err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+	return nil
+}
+
+// SetTargetRequirements replaces every skill requirement of a target.
+func (db *skill) SetTargetRequirements(ctx context.Context, targetID uuid.UUID, reqs []models.SkillRequirement) error {
+	defer metrics.ObserveQuery("skill", "SetTargetRequirements")()
+
+	tx, err := db.conn.Begin(ctx)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(
+		ctx,
+		`DELETE FROM target_required_skills
+		WHERE target_id = (SELECT id FROM targets WHERE id = $1 AND agency_id = $2)`,
+		targetID,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+
+	if err := insertRequirements(ctx, tx, targetID, reqs); err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+	return nil
+}
+
+func insertRequirements(ctx context.Context, q querier, targetID uuid.UUID, reqs []models.SkillRequirement) error {
+	for _, req := range reqs {
+		_, err := q.Exec(
+			ctx,
+			`INSERT INTO target_required_skills (target_id, skill_id, min_proficiency)
+			SELECT id, $2, $3 FROM targets WHERE id = $1 AND agency_id = $4`,
+			targetID,
+			req.Skill.ID,
+			req.MinProficiency,
+			tenant.ID(ctx),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// targetRequirements loads the skill requirements of the given targets, keyed by target.
+func targetRequirements(ctx context.Context, q querier, targetIDs []uuid.UUID) (map[uuid.UUID][]models.SkillRequirement, error) {
+	rows, err := q.Query(
+		ctx,
+		`SELECT r.target_id, r.min_proficiency, s.id, s.code, s.name, s.category, s.created_at
+		FROM target_required_skills r
+		JOIN skills s ON s.id = r.skill_id
+		JOIN targets t ON t.id = r.target_id
+		WHERE r.target_id = ANY($1) AND t.agency_id = $2
+		ORDER BY s.code`,
+		targetIDs,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reqs := make(map[uuid.UUID][]models.SkillRequirement)
+	for rows.Next() {
+		var targetID uuid.UUID
+		var req models.SkillRequirement
+		err := rows.Scan(
+			&targetID,
+			&req.MinProficiency,
+			&req.Skill.ID,
+			&req.Skill.Code,
+			&req.Skill.Name,
+			&req.Skill.Category,
+			&req.Skill.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reqs[targetID] = append(reqs[targetID], req)
+	}
+
+	return reqs, rows.Err()
+}
+
+// catSkills loads the skills of the given cats, keyed by cat.
+func catSkills(ctx context.Context, q querier, catIDs []uuid.UUID) (map[uuid.UUID][]models.CatSkill, error) {
+	rows, err := q.Query(
+		ctx,
+		`SELECT cs.cat_id, cs.proficiency, s.id, s.code, s.name, s.category, s.created_at
+		FROM cat_skills cs
+		JOIN skills s ON s.id = cs.skill_id
+		JOIN cats c ON c.id = cs.cat_id
+		WHERE cs.cat_id = ANY($1) AND c.agency_id = $2
+		ORDER BY s.code`,
+		catIDs,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	skills := make(map[uuid.UUID][]models.CatSkill)
+	for rows.Next() {
+		var catID uuid.UUID
+		var skill models.CatSkill
+		err := rows.Scan(
+			&catID,
+			&skill.Proficiency,
+			&skill.Skill.ID,
+			&skill.Skill.Code,
+			&skill.Skill.Name,
+			&skill.Skill.Category,
+			&skill.Skill.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		skills[catID] = append(skills[catID], skill)
+	}
+
+	return skills, rows.Err()
+}
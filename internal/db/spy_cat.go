@@ -2,11 +2,16 @@ package db
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
 	"github.com/mksmstpck/spy_cat_agency/internal/models"
-	"github.com/sirupsen/logrus"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
 )
 
 type spyCat struct {
@@ -20,25 +25,43 @@ func newSpyCat(conn *pgxpool.Pool) *spyCat {
 }
 
 func (db *spyCat) Create(ctx context.Context, cat models.SpyCat) (*models.SpyCat, error) {
+	defer metrics.ObserveQuery("spy_cat", "Create")()
+
 	err := db.conn.QueryRow(
 		ctx,
-		`INSERT INTO cats (name, breed_id, years_experience, salary)
-		VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`,
+		`INSERT INTO cats (agency_id, name, breed_id, years_experience, salary, clearance)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, status, created_at, updated_at`,
+		tenant.ID(ctx),
 		cat.Name,
 		cat.Breed.ID,
 		cat.ExpYears,
 		cat.Salary,
-	).Scan(&cat.ID, &cat.CreatedAt, &cat.UpdatedAt)
+		cat.Clearance,
+	).Scan(&cat.ID, &cat.Status, &cat.CreatedAt, &cat.UpdatedAt)
 
 	if err != nil {
-		logrus.Error(err)
-		return nil, err
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
 	}
 
 	return &cat, nil
 }
 
-func (db *spyCat) GetAll(ctx context.Context) ([]models.SpyCat, error) {
+// GetAll lists cats whose breed profile matches every trait filter and
+// whose status is one of filter.Statuses, or not retired if none are given.
+func (db *spyCat) GetAll(ctx context.Context, filter models.CatFilter) ([]models.SpyCat, error) {
+	defer metrics.ObserveQuery("spy_cat", "GetAll")()
+
+	where, args := breedTraitConditions(filter.Traits)
+	args = append(args, tenant.ID(ctx))
+	where += fmt.Sprintf(" AND c.agency_id = $%d", len(args))
+	if len(filter.Statuses) > 0 {
+		args = append(args, filter.Statuses)
+		where += fmt.Sprintf(" AND c.status = ANY($%d)", len(args))
+	} else {
+		where += " AND c.status <> 'retired'"
+	}
+
 	rows, err := db.conn.Query(
 		ctx,
 		`SELECT
@@ -46,17 +69,27 @@ func (db *spyCat) GetAll(ctx context.Context) ([]models.SpyCat, error) {
 			c.name,
 			c.years_experience,
 			c.salary,
+			c.status,
+			c.clearance,
+			c.retired_at,
+			c.retirement_reason,
 			c.created_at,
 			c.updated_at,
 			b.id,
 			b.api_id,
-			b.name,
-			b.created_at
+			COALESCE(ab.name, b.name),
+			b.profile,
+			b.created_at,
+			b.removed_at
 		FROM cats c
-		LEFT JOIN breeds b ON c.breed_id = b.id`,
+		LEFT JOIN breeds b ON c.breed_id = b.id
+		LEFT JOIN agency_breeds ab ON ab.breed_id = b.id AND ab.agency_id = c.agency_id
+		WHERE `+where+`
+		ORDER BY c.name`,
+		args...,
 	)
 	if err != nil {
-		logrus.Error(err)
+		logging.From(ctx).Error(err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -72,15 +105,21 @@ func (db *spyCat) GetAll(ctx context.Context) ([]models.SpyCat, error) {
 			&cat.Name,
 			&cat.ExpYears,
 			&cat.Salary,
+			&cat.Status,
+			&cat.Clearance,
+			&cat.RetiredAt,
+			&cat.RetirementReason,
 			&cat.CreatedAt,
 			&cat.UpdatedAt,
 			&breed.ID,
 			&breed.ApiID,
 			&breed.Name,
+			&breed.Profile,
 			&breed.CreatedAt,
+			&breed.RemovedAt,
 		)
 		if err != nil {
-			logrus.Error(err)
+			logging.From(ctx).Error(err)
 			return nil, err
 		}
 
@@ -92,10 +131,26 @@ func (db *spyCat) GetAll(ctx context.Context) ([]models.SpyCat, error) {
 		return nil, rows.Err()
 	}
 
+	ids := make([]uuid.UUID, len(cats))
+	for i := range cats {
+		ids[i] = cats[i].ID
+	}
+
+	skills, err := catSkills(ctx, db.conn, ids)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	for i := range cats {
+		cats[i].Skills = skills[cats[i].ID]
+	}
+
 	return cats, nil
 }
 
 func (db *spyCat) GetByID(ctx context.Context, id uuid.UUID) (*models.SpyCat, error) {
+	defer metrics.ObserveQuery("spy_cat", "GetByID")()
+
 	var cat models.SpyCat
 
 	err := db.conn.QueryRow(
@@ -104,83 +159,280 @@ func (db *spyCat) GetByID(ctx context.Context, id uuid.UUID) (*models.SpyCat, er
 		c.name,
 		c.years_experience,
 		c.salary,
+		c.status,
+		c.clearance,
+		c.retired_at,
+		c.retirement_reason,
 		c.created_at,
 		c.updated_at,
 		b.id,
 		b.api_id,
-		b.name,
-		b.created_at
+		COALESCE(ab.name, b.name),
+		b.profile,
+		b.created_at,
+		b.removed_at
 		 FROM cats c
 		 LEFT JOIN breeds b ON c.breed_id = b.id
-		 WHERE c.id = $1;`,
+		 LEFT JOIN agency_breeds ab ON ab.breed_id = b.id AND ab.agency_id = c.agency_id
+		 WHERE c.id = $1 AND c.agency_id = $2;`,
 		id,
+		tenant.ID(ctx),
 	).Scan(
 		&cat.ID,
 		&cat.Name,
 		&cat.ExpYears,
 		&cat.Salary,
+		&cat.Status,
+		&cat.Clearance,
+		&cat.RetiredAt,
+		&cat.RetirementReason,
 		&cat.CreatedAt,
 		&cat.UpdatedAt,
 		&cat.Breed.ID,
 		&cat.Breed.ApiID,
 		&cat.Breed.Name,
+		&cat.Breed.Profile,
 		&cat.Breed.CreatedAt,
+		&cat.Breed.RemovedAt,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	skills, err := catSkills(ctx, db.conn, []uuid.UUID{cat.ID})
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	cat.Skills = skills[cat.ID]
+
 	return &cat, nil
 }
 
 func (db *spyCat) UpdateSalary(ctx context.Context, id uuid.UUID, salary float32) error {
+	defer metrics.ObserveQuery("spy_cat", "UpdateSalary")()
+
 	_, err := db.conn.Exec(
 		ctx,
 		`UPDATE cats
 		SET salary = $1
-		WHERE id = $2`,
+		WHERE id = $2 AND agency_id = $3`,
 		salary,
 		id,
+		tenant.ID(ctx),
 	)
 
 	if err != nil {
-		logrus.Error(err)
-		return err
+		logging.From(ctx).Error(err)
+		return ruleError(err)
 	}
 
 	return nil
 }
 
 func (db *spyCat) UpdateExperience(ctx context.Context, id uuid.UUID, exp int) error {
+	defer metrics.ObserveQuery("spy_cat", "UpdateExperience")()
+
 	_, err := db.conn.Exec(
 		ctx,
 		`UPDATE cats
 		SET years_experience = $1
-		WHERE id = $2`,
+		WHERE id = $2 AND agency_id = $3`,
 		exp,
 		id,
+		tenant.ID(ctx),
 	)
 
 	if err != nil {
-		logrus.Error(err)
-		return err
+		logging.From(ctx).Error(err)
+		return ruleError(err)
 	}
 
 	return nil
 }
 
 func (db *spyCat) Delete(ctx context.Context, id uuid.UUID) error {
+	defer metrics.ObserveQuery("spy_cat", "Delete")()
+
+	_, err := db.conn.Exec(
+		ctx,
+		"DELETE FROM cats WHERE id = $1 AND agency_id = $2",
+		id,
+		tenant.ID(ctx),
+	)
+
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+
+	return nil
+}
+
+func (db *spyCat) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
+	defer metrics.ObserveQuery("spy_cat", "UpdateStatus")()
+
+	_, err := db.conn.Exec(
+		ctx,
+		`UPDATE cats
+		SET status = $1
+		WHERE id = $2 AND agency_id = $3`,
+		status,
+		id,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+	return nil
+}
+
+func (db *spyCat) UpdateClearance(ctx context.Context, id uuid.UUID, clearance models.Classification) error {
+	defer metrics.ObserveQuery("spy_cat", "UpdateClearance")()
+
 	_, err := db.conn.Exec(
 		ctx,
-		"DELETE FROM cats WHERE id = $1",
+		`UPDATE cats
+		SET clearance = $1
+		WHERE id = $2 AND agency_id = $3`,
+		clearance,
 		id,
+		tenant.ID(ctx),
 	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+	return nil
+}
+
+// Retire retires a cat. Its active assignments are handed over to
+// replacementID in the same roles, in the same transaction; without a
+// replacement the database refuses to retire a cat that is on a mission.
+func (db *spyCat) Retire(ctx context.Context, id uuid.UUID, reason, by string, replacementID *uuid.UUID) error {
+	defer metrics.ObserveQuery("spy_cat", "Retire")()
+
+	tx, err := db.conn.Begin(ctx)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	if replacementID != nil {
+		rows, err := tx.Query(
+			ctx,
+			`SELECT mission_id, role FROM mission_assignments
+			WHERE cat_id = $1 AND agency_id = $2 AND unassigned_at IS NULL AND mission_completed = FALSE
+			FOR UPDATE`,
+			id,
+			tenant.ID(ctx),
+		)
+		if err != nil {
+			logging.From(ctx).Error(err)
+			return ruleError(err)
+		}
+
+		type handover struct {
+			missionID uuid.UUID
+			role      string
+		}
+		var handovers []handover
+		for rows.Next() {
+			var h handover
+			if err := rows.Scan(&h.missionID, &h.role); err != nil {
+				rows.Close()
+				logging.From(ctx).Error(err)
+				return ruleError(err)
+			}
+			handovers = append(handovers, h)
+		}
+		rows.Close()
+		if rows.Err() != nil {
+			return rows.Err()
+		}
+
+		for _, h := range handovers {
+			if _, err := closeAssignments(ctx, tx, h.missionID, `cat_id = $5`, by, "cat retired", id); err != nil {
+				logging.From(ctx).Error(err)
+				return ruleError(err)
+			}
+			if _, err := insertAssignment(ctx, tx, h.missionID, *replacementID, h.role, by); err != nil {
+				logging.From(ctx).Error(err)
+				return ruleError(err)
+			}
+		}
+	}
 
+	_, err = tx.Exec(
+		ctx,
+		`UPDATE cats
+		SET status = 'retired', retired_at = now(), retirement_reason = $1
+		WHERE id = $2 AND agency_id = $3`,
+		reason,
+		id,
+		tenant.ID(ctx),
+	)
 	if err != nil {
-		logrus.Error(err)
-		return err
+		logging.From(ctx).Error(err)
+		return ruleError(err)
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
 	return nil
 }
+
+// HasHistory reports whether the cat has ever been assigned to a mission.
+func (db *spyCat) HasHistory(ctx context.Context, id uuid.UUID) (bool, error) {
+	defer metrics.ObserveQuery("spy_cat", "HasHistory")()
+
+	var exists bool
+	err := db.conn.QueryRow(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM mission_assignments WHERE cat_id = $1 AND agency_id = $2)`,
+		id,
+		tenant.ID(ctx),
+	).Scan(&exists)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return false, err
+	}
+	return exists, nil
+}
+
+// breedTraitConditions turns trait filters into a WHERE clause over b.profile.
+// Trait names are checked against models.TextTraits and models.NumericTraits
+// when parsed, so they are safe to inline; values are always bound.
+func breedTraitConditions(filters []models.BreedTraitFilter) (string, []any) {
+	conditions := []string{"TRUE"}
+	var args []any
+
+	for _, f := range filters {
+		if f.Numeric {
+			value, _ := strconv.Atoi(f.Value)
+			args = append(args, value)
+		} else {
+			args = append(args, f.Value)
+		}
+		param := fmt.Sprintf("$%d", len(args))
+		field := fmt.Sprintf("b.profile->>'%s'", f.Trait)
+
+		switch {
+		case f.Numeric:
+			conditions = append(conditions, fmt.Sprintf("(%s)::int %s %s", field, f.Op, param))
+		case f.Op == "~":
+			conditions = append(conditions, fmt.Sprintf("%s ILIKE '%%' || %s || '%%'", field, param))
+		case f.Op == "!=":
+			conditions = append(conditions, fmt.Sprintf("lower(%s) IS DISTINCT FROM lower(%s)", field, param))
+		default:
+			conditions = append(conditions, fmt.Sprintf("lower(%s) = lower(%s)", field, param))
+		}
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+type stats struct {
+	conn *pgxpool.Pool
+}
+
+func newStats(conn *pgxpool.Pool) *stats {
+	return &stats{
+		conn: conn,
+	}
+}
+
+// Get returns the numbers of every agency. It is not scoped to one, as it
+// feeds the service's own metrics.
+func (db *stats) Get(ctx context.Context) ([]models.AgencyStats, error) {
+	defer metrics.ObserveQuery("stats", "Get")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT
+			ag.slug,
+			(SELECT COUNT(*) FROM missions
+				WHERE agency_id = ag.id AND completed = FALSE AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM cats c
+				WHERE c.agency_id = ag.id AND c.status = 'active' AND NOT EXISTS (
+					SELECT 1 FROM mission_assignments a
+					WHERE a.cat_id = c.id AND a.unassigned_at IS NULL AND a.mission_completed = FALSE
+				)),
+			(SELECT COUNT(*) FROM targets
+				WHERE agency_id = ag.id AND completed = TRUE
+				AND completed_at >= date_trunc('day', now()) AND deleted_at IS NULL)
+		FROM agencies ag
+		ORDER BY ag.slug`,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []models.AgencyStats
+	for rows.Next() {
+		var s models.AgencyStats
+		err := rows.Scan(
+			&s.Agency,
+			&s.ActiveMissions,
+			&s.IdleCats,
+			&s.TargetsCompletedToday,
+		)
+		if err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+
+	return stats, nil
+}
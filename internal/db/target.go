@@ -2,111 +2,537 @@ package db
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/keyring"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
 	"github.com/mksmstpck/spy_cat_agency/internal/models"
-	"github.com/sirupsen/logrus"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
 )
 
+const targetColumns = `t.id, t.mission_id, t.name, t.country, t.latitude, t.longitude,
+	COALESCE(t.city, ''), COALESCE(t.address, ''), t.notes, t.completed, t.classification, t.created_at, t.updated_at`
+
+func targetFields(t *models.Target) []any {
+	return []any{
+		&t.ID,
+		&t.MissionID,
+		&t.Name,
+		&t.Country,
+		&t.Latitude,
+		&t.Longitude,
+		&t.City,
+		&t.Address,
+		&t.Notes,
+		&t.Completed,
+		&t.Classification,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	}
+}
+
+// openNotes decrypts the notes of targets scanned with targetFields.
+func openNotes(notes *keyring.Keyring, targets ...*models.Target) error {
+	for _, t := range targets {
+		plain, err := notes.Open(t.Notes)
+		if err != nil {
+			return fmt.Errorf("notes of target %s: %w", t.ID, err)
+		}
+		t.Notes = plain
+	}
+	return nil
+}
+
 type target struct {
-	conn *pgxpool.Pool
+	conn  *pgxpool.Pool
+	notes *keyring.Keyring
+	index *keyring.Index
 }
 
-func newTarget(conn *pgxpool.Pool) *target {
+func newTarget(conn *pgxpool.Pool, notes *keyring.Keyring, index *keyring.Index) *target {
 	return &target{
-		conn: conn,
+		conn:  conn,
+		notes: notes,
+		index: index,
 	}
 }
 
 func (db *target) Create(ctx context.Context, target models.Target) (*models.Target, error) {
-	err := db.conn.QueryRow(
+	defer metrics.ObserveQuery("target", "Create")()
+
+	sealed, err := db.notes.Seal(target.Notes)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+
+	tx, err := db.conn.Begin(ctx)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(
 		ctx,
-		`INSERT INTO targets (mission_id, name, country, notes)
-		VALUES ($1, $2, $3, $4)
+		`INSERT INTO targets (agency_id, mission_id, name, country, notes, latitude, longitude, city, address, classification, notes_index)
+		VALUES ($9, $1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $10, $11)
 		RETURNING id, completed, created_at, updated_at`,
 		target.MissionID,
 		target.Name,
 		target.Country,
-		target.Notes,
+		sealed,
+		target.Latitude,
+		target.Longitude,
+		target.City,
+		target.Address,
+		tenant.ID(ctx),
+		target.Classification,
+		db.index.Terms(target.Notes),
 	).Scan(&target.ID, &target.Completed, &target.CreatedAt, &target.UpdatedAt)
 	if err != nil {
-		logrus.Error(err)
-		return nil, err
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+
+	if err = insertRequirements(ctx, tx, target.ID, target.RequiredSkills); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
 	}
 	return &target, nil
 }
 
 func (db *target) GetByID(ctx context.Context, id uuid.UUID) (*models.Target, error) {
+	defer metrics.ObserveQuery("target", "GetByID")()
+
 	var target models.Target
 	err := db.conn.QueryRow(
 		ctx,
-		`SELECT id, mission_id, name, country, notes, completed, created_at, updated_at
-		FROM targets
-		WHERE id = $1`,
+		`SELECT `+targetColumns+`
+		FROM targets t
+		WHERE t.id = $1 AND t.agency_id = $2 AND t.deleted_at IS NULL
+		AND EXISTS (SELECT 1 FROM missions m WHERE m.id = t.mission_id AND m.deleted_at IS NULL)`,
 		id,
-	).Scan(
-		&target.ID,
-		&target.MissionID,
-		&target.Name,
-		&target.Country,
-		&target.Notes,
-		&target.Completed,
-		&target.CreatedAt,
-		&target.UpdatedAt,
-	)
+		tenant.ID(ctx),
+	).Scan(targetFields(&target)...)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
-		logrus.Error(err)
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	if err := openNotes(db.notes, &target); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+
+	reqs, err := targetRequirements(ctx, db.conn, []uuid.UUID{target.ID})
+	if err != nil {
+		logging.From(ctx).Error(err)
 		return nil, err
 	}
+	target.RequiredSkills = reqs[target.ID]
+
 	return &target, nil
 }
 
 func (db *target) UpdateCompleted(ctx context.Context, id uuid.UUID, completed bool) error {
+	defer metrics.ObserveQuery("target", "UpdateCompleted")()
+
 	_, err := db.conn.Exec(
 		ctx,
 		`UPDATE targets
 		SET completed = $1
-		WHERE id = $2`,
+		WHERE id = $2 AND agency_id = $3 AND deleted_at IS NULL`,
 		completed,
 		id,
+		tenant.ID(ctx),
 	)
 	if err != nil {
-		logrus.Error(err)
-		return err
+		logging.From(ctx).Error(err)
+		return ruleError(err)
 	}
 	return nil
 }
 
 func (db *target) UpdateNotes(ctx context.Context, id uuid.UUID, notes string) error {
+	defer metrics.ObserveQuery("target", "UpdateNotes")()
+
+	sealed, err := db.notes.Seal(notes)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+
+	_, err = db.conn.Exec(
+		ctx,
+		`UPDATE targets
+		SET notes = $1, notes_index = $4
+		WHERE id = $2 AND agency_id = $3 AND deleted_at IS NULL`,
+		sealed,
+		id,
+		tenant.ID(ctx),
+		db.index.Terms(notes),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return ruleError(err)
+	}
+	return nil
+}
+
+func (db *target) UpdateClassification(ctx context.Context, id uuid.UUID, level models.Classification) error {
+	defer metrics.ObserveQuery("target", "UpdateClassification")()
+
 	_, err := db.conn.Exec(
 		ctx,
 		`UPDATE targets
-		SET notes = $1
-		WHERE id = $2`,
-		notes,
+		SET classification = $1
+		WHERE id = $2 AND agency_id = $3 AND deleted_at IS NULL`,
+		level,
 		id,
+		tenant.ID(ctx),
 	)
 	if err != nil {
-		logrus.Error(err)
-		return err
+		logging.From(ctx).Error(err)
+		return ruleError(err)
 	}
 	return nil
 }
 
+// UpdateCountry sets the target's country code and reports whether the
+// target exists.
+func (db *target) UpdateCountry(ctx context.Context, id uuid.UUID, country string) (bool, error) {
+	defer metrics.ObserveQuery("target", "UpdateCountry")()
+
+	tag, err := db.conn.Exec(
+		ctx,
+		`UPDATE targets
+		SET country = $1
+		WHERE id = $2 AND agency_id = $3 AND deleted_at IS NULL`,
+		country,
+		id,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UpdateLocation sets or, with nil coordinates, clears where the target is
+// and reports whether the target exists.
+func (db *target) UpdateLocation(ctx context.Context, id uuid.UUID, location models.TargetLocation) (bool, error) {
+	defer metrics.ObserveQuery("target", "UpdateLocation")()
+
+	tag, err := db.conn.Exec(
+		ctx,
+		`UPDATE targets
+		SET latitude = $1, longitude = $2, city = NULLIF($3, ''), address = NULLIF($4, '')
+		WHERE id = $5 AND agency_id = $6 AND deleted_at IS NULL`,
+		location.Latitude,
+		location.Longitude,
+		location.City,
+		location.Address,
+		id,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Nearby returns live targets within radiusKm of the point, nearest first.
+// box narrows the scan to a bounding box around the point; a nil longitude
+// range means the box wraps the antimeridian or a pole and is not used.
+func (db *target) Nearby(ctx context.Context, lat, lon, radiusKm float64, box models.BoundingBox, limit int) ([]models.NearbyTarget, error) {
+	defer metrics.ObserveQuery("target", "Nearby")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT `+targetColumns+`, d.km
+		FROM targets t
+		JOIN missions m ON m.id = t.mission_id,
+		LATERAL (SELECT 2 * 6371.0088 * asin(LEAST(1, sqrt(
+			power(sin(radians(t.latitude - $1) / 2), 2) +
+			cos(radians($1)) * cos(radians(t.latitude)) * power(sin(radians(t.longitude - $2) / 2), 2)
+		))) AS km) d
+		WHERE t.latitude IS NOT NULL
+		AND t.agency_id = $9
+		AND t.deleted_at IS NULL AND m.deleted_at IS NULL
+		AND t.latitude BETWEEN $4 AND $5
+		AND ($6::float8 IS NULL OR t.longitude BETWEEN $6 AND $7)
+		AND d.km <= $3
+		ORDER BY d.km
+		LIMIT $8`,
+		lat,
+		lon,
+		radiusKm,
+		box.MinLatitude,
+		box.MaxLatitude,
+		box.MinLongitude,
+		box.MaxLongitude,
+		limit,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []models.NearbyTarget
+	for rows.Next() {
+		var target models.NearbyTarget
+		if err := rows.Scan(append(targetFields(&target.Target), &target.DistanceKm)...); err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		if err := openNotes(db.notes, &target.Target); err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return targets, nil
+}
+
+// Delete moves the target to the trash. The database refuses to trash a
+// completed target or a mission's last one.
 func (db *target) Delete(ctx context.Context, id uuid.UUID) error {
+	defer metrics.ObserveQuery("target", "Delete")()
+
 	_, err := db.conn.Exec(
 		ctx,
-		"DELETE FROM targets WHERE id = $1",
+		"UPDATE targets SET deleted_at = now() WHERE id = $1 AND agency_id = $2 AND deleted_at IS NULL",
 		id,
+		tenant.ID(ctx),
 	)
 	if err != nil {
-		logrus.Error(err)
-		return err
+		logging.From(ctx).Error(err)
+		return ruleError(err)
 	}
 	return nil
 }
+
+// Restore takes the target out of the trash and reports whether it was
+// there. The database re-checks the 1-3 target rule and refuses to add an
+// open target to a completed or deleted mission.
+func (db *target) Restore(ctx context.Context, id uuid.UUID) (bool, error) {
+	defer metrics.ObserveQuery("target", "Restore")()
+
+	tag, err := db.conn.Exec(
+		ctx,
+		"UPDATE targets SET deleted_at = NULL WHERE id = $1 AND agency_id = $2 AND deleted_at IS NOT NULL",
+		id,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// NotesKeyUsage counts non-empty target and template placeholder notes by
+// the key they are sealed with, across every agency and the trash. A key
+// that no longer appears can be dropped from the configuration.
+func (db *target) NotesKeyUsage(ctx context.Context) ([]models.NotesKeyUsage, error) {
+	defer metrics.ObserveQuery("target", "NotesKeyUsage")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`WITH notes AS (
+			SELECT notes, false AS placeholder FROM targets WHERE notes <> ''
+			UNION ALL
+			SELECT p->>'notes', true FROM mission_templates, jsonb_array_elements(targets) p
+			WHERE coalesce(p->>'notes', '') <> '')
+		SELECT CASE WHEN starts_with(notes, 'sca1:') THEN split_part(notes, ':', 2) ELSE '' END AS key_id,
+			count(*) FILTER (WHERE NOT placeholder), count(*) FILTER (WHERE placeholder)
+		FROM notes
+		GROUP BY key_id
+		ORDER BY key_id`,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []models.NotesKeyUsage
+	for rows.Next() {
+		var u models.NotesKeyUsage
+		if err := rows.Scan(&u.KeyID, &u.Targets, &u.Templates); err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return usage, nil
+}
+
+// RotateNotes re-seals with the active key up to limit notes that are sealed
+// with another key or still in plain text, across every agency and the
+// trash, and returns how many it rewrote. sca.maintenance lets it rewrite
+// notes of completed targets without touching updated_at.
+func (db *target) RotateNotes(ctx context.Context, limit int) (int, error) {
+	defer metrics.ObserveQuery("target", "RotateNotes")()
+
+	tx, err := db.conn.Begin(ctx)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return 0, ruleError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SET LOCAL sca.maintenance = 'on'"); err != nil {
+		logging.From(ctx).Error(err)
+		return 0, ruleError(err)
+	}
+
+	rows, err := tx.Query(
+		ctx,
+		`SELECT id, notes FROM targets
+		WHERE notes <> '' AND NOT starts_with(notes, $1)
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`,
+		db.notes.ActivePrefix(),
+		limit,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return 0, ruleError(err)
+	}
+
+	var stale []models.Target
+	for rows.Next() {
+		var t models.Target
+		if err := rows.Scan(&t.ID, &t.Notes); err != nil {
+			rows.Close()
+			logging.From(ctx).Error(err)
+			return 0, ruleError(err)
+		}
+		stale = append(stale, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return 0, ruleError(err)
+	}
+
+	for i := range stale {
+		if err := openNotes(db.notes, &stale[i]); err != nil {
+			logging.From(ctx).Error(err)
+			return 0, ruleError(err)
+		}
+		sealed, err := db.notes.Seal(stale[i].Notes)
+		if err != nil {
+			logging.From(ctx).Error(err)
+			return 0, ruleError(err)
+		}
+		if _, err := tx.Exec(ctx, "UPDATE targets SET notes = $1 WHERE id = $2", sealed, stale[i].ID); err != nil {
+			logging.From(ctx).Error(err)
+			return 0, ruleError(err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.From(ctx).Error(err)
+		return 0, ruleError(err)
+	}
+	return len(stale), nil
+}
+
+// ReindexNotes rewrites the blind index of up to limit targets with ids
+// after after, across every agency and the trash, and returns the last id it
+// reached and how many it rewrote. It reads the notes, so it runs in
+// maintenance like RotateNotes.
+func (db *target) ReindexNotes(ctx context.Context, after uuid.UUID, limit int) (uuid.UUID, int, error) {
+	defer metrics.ObserveQuery("target", "ReindexNotes")()
+
+	tx, err := db.conn.Begin(ctx)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return after, 0, ruleError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SET LOCAL sca.maintenance = 'on'"); err != nil {
+		logging.From(ctx).Error(err)
+		return after, 0, ruleError(err)
+	}
+
+	rows, err := tx.Query(
+		ctx,
+		`SELECT id, notes FROM targets
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE`,
+		after,
+		limit,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return after, 0, ruleError(err)
+	}
+
+	var batch []models.Target
+	for rows.Next() {
+		var t models.Target
+		if err := rows.Scan(&t.ID, &t.Notes); err != nil {
+			rows.Close()
+			logging.From(ctx).Error(err)
+			return after, 0, ruleError(err)
+		}
+		batch = append(batch, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return after, 0, ruleError(err)
+	}
+
+	for i := range batch {
+		if err := openNotes(db.notes, &batch[i]); err != nil {
+			logging.From(ctx).Error(err)
+			return after, 0, ruleError(err)
+		}
+		terms := db.index.Terms(batch[i].Notes)
+		if _, err := tx.Exec(ctx, "UPDATE targets SET notes_index = $1 WHERE id = $2", terms, batch[i].ID); err != nil {
+			logging.From(ctx).Error(err)
+			return after, 0, ruleError(err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.From(ctx).Error(err)
+		return after, 0, ruleError(err)
+	}
+	if len(batch) > 0 {
+		after = batch[len(batch)-1].ID
+	}
+	return after, len(batch), nil
+}
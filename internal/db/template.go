@@ -0,0 +1,308 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/keyring"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+)
+
+const templateColumns = `id, name, title, description, targets, created_by, created_at, updated_at`
+
+var errTemplateNameTaken = fault.Conflictf("template name is already taken")
+
+type template struct {
+	conn  *pgxpool.Pool
+	notes *keyring.Keyring
+}
+
+func newTemplate(conn *pgxpool.Pool, notes *keyring.Keyring) *template {
+	return &template{
+		conn:  conn,
+		notes: notes,
+	}
+}
+
+func templateFields(t *models.MissionTemplate) []any {
+	return []any{
+		&t.ID,
+		&t.Name,
+		&t.Title,
+		&t.Description,
+		&t.Targets,
+		&t.CreatedBy,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	}
+}
+
+// sealPlaceholders returns a copy of placeholders with the notes sealed, as
+// they are stored.
+func sealPlaceholders(notes *keyring.Keyring, placeholders []models.TemplateTarget) ([]models.TemplateTarget, error) {
+	sealed := slices.Clone(placeholders)
+	for i := range sealed {
+		var err error
+		if sealed[i].Notes, err = notes.Seal(sealed[i].Notes); err != nil {
+			return nil, err
+		}
+	}
+	return sealed, nil
+}
+
+// openPlaceholders decrypts the placeholder notes of templates scanned with
+// templateFields.
+func openPlaceholders(notes *keyring.Keyring, templates ...*models.MissionTemplate) error {
+	for _, t := range templates {
+		for i := range t.Targets {
+			plain, err := notes.Open(t.Targets[i].Notes)
+			if err != nil {
+				return fmt.Errorf("notes of template %s: %w", t.ID, err)
+			}
+			t.Targets[i].Notes = plain
+		}
+	}
+	return nil
+}
+
+// templateError turns a clash on the unique name into a readable error.
+func templateError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return errTemplateNameTaken
+	}
+	return err
+}
+
+func (db *template) Create(ctx context.Context, t models.MissionTemplate) (*models.MissionTemplate, error) {
+	defer metrics.ObserveQuery("template", "Create")()
+
+	placeholders, err := sealPlaceholders(db.notes, t.Targets)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+
+	err = db.conn.QueryRow(
+		ctx,
+		`INSERT INTO mission_templates (agency_id, name, title, description, targets, created_by)
+		VALUES ($6, $1, $2, $3, $4, $5)
+		RETURNING `+templateColumns,
+		t.Name,
+		t.Title,
+		t.Description,
+		placeholders,
+		t.CreatedBy,
+		tenant.ID(ctx),
+	).Scan(templateFields(&t)...)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, templateError(err)
+	}
+	if err := openPlaceholders(db.notes, &t); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+	return &t, nil
+}
+
+func (db *template) GetAll(ctx context.Context) ([]models.MissionTemplate, error) {
+	defer metrics.ObserveQuery("template", "GetAll")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT `+templateColumns+` FROM mission_templates WHERE agency_id = $1 ORDER BY name`,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []models.MissionTemplate{}
+	for rows.Next() {
+		var t models.MissionTemplate
+		if err := rows.Scan(templateFields(&t)...); err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		if err := openPlaceholders(db.notes, &t); err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return templates, nil
+}
+
+// GetByID returns nil when there is no such template.
+func (db *template) GetByID(ctx context.Context, id uuid.UUID) (*models.MissionTemplate, error) {
+	defer metrics.ObserveQuery("template", "GetByID")()
+
+	var t models.MissionTemplate
+	err := db.conn.QueryRow(
+		ctx,
+		`SELECT `+templateColumns+` FROM mission_templates WHERE id = $1 AND agency_id = $2`,
+		id,
+		tenant.ID(ctx),
+	).Scan(templateFields(&t)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	if err := openPlaceholders(db.notes, &t); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Update replaces everything but the creator; it returns nil when there is
+// no such template.
+func (db *template) Update(ctx context.Context, t models.MissionTemplate) (*models.MissionTemplate, error) {
+	defer metrics.ObserveQuery("template", "Update")()
+
+	placeholders, err := sealPlaceholders(db.notes, t.Targets)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+
+	err = db.conn.QueryRow(
+		ctx,
+		`UPDATE mission_templates
+		SET name = $2, title = $3, description = $4, targets = $5
+		WHERE id = $1 AND agency_id = $6
+		RETURNING `+templateColumns,
+		t.ID,
+		t.Name,
+		t.Title,
+		t.Description,
+		placeholders,
+		tenant.ID(ctx),
+	).Scan(templateFields(&t)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, templateError(err)
+	}
+	if err := openPlaceholders(db.notes, &t); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, ruleError(err)
+	}
+	return &t, nil
+}
+
+// Delete removes a template and reports whether it existed. Missions created
+// from it are not affected.
+func (db *template) Delete(ctx context.Context, id uuid.UUID) (bool, error) {
+	defer metrics.ObserveQuery("template", "Delete")()
+
+	tag, err := db.conn.Exec(
+		ctx,
+		"DELETE FROM mission_templates WHERE id = $1 AND agency_id = $2",
+		id,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return false, ruleError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RotateNotes re-seals with the active key the placeholder notes of up to
+// limit templates holding one sealed with another key or still in plain
+// text, across every agency, and returns how many templates it rewrote.
+// sca.maintenance keeps updated_at as it was.
+func (db *template) RotateNotes(ctx context.Context, limit int) (int, error) {
+	defer metrics.ObserveQuery("template", "RotateNotes")()
+
+	tx, err := db.conn.Begin(ctx)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return 0, ruleError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SET LOCAL sca.maintenance = 'on'"); err != nil {
+		logging.From(ctx).Error(err)
+		return 0, ruleError(err)
+	}
+
+	rows, err := tx.Query(
+		ctx,
+		`SELECT id, targets FROM mission_templates
+		WHERE EXISTS (
+			SELECT 1 FROM jsonb_array_elements(targets) p
+			WHERE coalesce(p->>'notes', '') <> '' AND NOT starts_with(p->>'notes', $1))
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`,
+		db.notes.ActivePrefix(),
+		limit,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return 0, ruleError(err)
+	}
+
+	var stale []models.MissionTemplate
+	for rows.Next() {
+		var t models.MissionTemplate
+		if err := rows.Scan(&t.ID, &t.Targets); err != nil {
+			rows.Close()
+			logging.From(ctx).Error(err)
+			return 0, ruleError(err)
+		}
+		stale = append(stale, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return 0, ruleError(err)
+	}
+
+	for i := range stale {
+		if err := openPlaceholders(db.notes, &stale[i]); err != nil {
+			logging.From(ctx).Error(err)
+			return 0, ruleError(err)
+		}
+		placeholders, err := sealPlaceholders(db.notes, stale[i].Targets)
+		if err != nil {
+			logging.From(ctx).Error(err)
+			return 0, ruleError(err)
+		}
+		if _, err := tx.Exec(ctx, "UPDATE mission_templates SET targets = $1 WHERE id = $2", placeholders, stale[i].ID); err != nil {
+			logging.From(ctx).Error(err)
+			return 0, ruleError(err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logging.From(ctx).Error(err)
+		return 0, ruleError(err)
+	}
+	return len(stale), nil
+}
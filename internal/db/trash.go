@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+)
+
+type trash struct {
+	conn *pgxpool.Pool
+}
+
+func newTrash(conn *pgxpool.Pool) *trash {
+	return &trash{
+		conn: conn,
+	}
+}
+
+// GetAll lists deleted missions and the deleted targets of live missions,
+// newest first. Targets of a deleted mission come back with it and are not
+// listed on their own.
+func (db *trash) GetAll(ctx context.Context) ([]models.TrashItem, error) {
+	defer metrics.ObserveQuery("trash", "GetAll")()
+
+	rows, err := db.conn.Query(
+		ctx,
+		`SELECT 'mission', id, NULL::uuid, title, deleted_at
+		FROM missions
+		WHERE deleted_at IS NOT NULL AND agency_id = $1
+		UNION ALL
+		SELECT 'target', t.id, t.mission_id, t.name, t.deleted_at
+		FROM targets t
+		JOIN missions m ON m.id = t.mission_id
+		WHERE t.deleted_at IS NOT NULL AND m.deleted_at IS NULL AND t.agency_id = $1
+		ORDER BY 5 DESC`,
+		tenant.ID(ctx),
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.TrashItem
+	for rows.Next() {
+		var item models.TrashItem
+		err := rows.Scan(
+			&item.Kind,
+			&item.ID,
+			&item.MissionID,
+			&item.Name,
+			&item.DeletedAt,
+		)
+		if err != nil {
+			logging.From(ctx).Error(err)
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// Levels returns the classification of a trashed mission and of the live
+// targets it takes back on restore, or of a trashed target; none when kind has nothing with that id in the trash.
+func (db *trash) Levels(ctx context.Context, kind string, id uuid.UUID) ([]models.Classification, error) {
+	defer metrics.ObserveQuery("trash", "Levels")()
+
+	query := `SELECT classification FROM missions
+		WHERE id = $1 AND agency_id = $2 AND deleted_at IS NOT NULL
+		UNION ALL
+		SELECT t.classification FROM targets t
+		JOIN missions m ON m.id = t.mission_id
+		WHERE m.id = $1 AND t.agency_id = $2 AND m.deleted_at IS NOT NULL AND t.deleted_at IS NULL`
+	if kind == models.TrashTarget {
+		query = `SELECT classification FROM targets
+			WHERE id = $1 AND agency_id = $2 AND deleted_at IS NOT NULL`
+	}
+
+	rows, err := db.conn.Query(ctx, query, id, tenant.ID(ctx))
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	levels, err := pgx.CollectRows(rows, pgx.RowTo[models.Classification])
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, err
+	}
+	return levels, nil
+}
+
+// Purge permanently removes missions and targets deleted before the cutoff,
+// of one agency or, with a nil agencyID, of all of them. It returns the
+// storage keys of the attachments that went with them, whose blobs the
+// caller should delete.
+func (db *trash) Purge(ctx context.Context, agencyID *uuid.UUID, before time.Time) (*models.PurgeResult, []string, error) {
+	defer metrics.ObserveQuery("trash", "Purge")()
+
+	tx, err := db.conn.Begin(ctx)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, nil, ruleError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(
+		ctx,
+		`SELECT a.storage_key
+		FROM target_attachments a
+		JOIN targets t ON t.id = a.target_id
+		JOIN missions m ON m.id = t.mission_id
+		WHERE (t.deleted_at < $1 OR m.deleted_at < $1)
+		AND ($2::uuid IS NULL OR t.agency_id = $2)`,
+		before,
+		agencyID,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, nil, ruleError(err)
+	}
+	keys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, nil, ruleError(err)
+	}
+
+	result := models.PurgeResult{Attachments: int64(len(keys))}
+
+	tag, err := tx.Exec(
+		ctx,
+		"DELETE FROM missions WHERE deleted_at < $1 AND ($2::uuid IS NULL OR agency_id = $2)",
+		before,
+		agencyID,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, nil, ruleError(err)
+	}
+	result.Missions = tag.RowsAffected()
+
+	tag, err = tx.Exec(
+		ctx,
+		"DELETE FROM targets WHERE deleted_at < $1 AND ($2::uuid IS NULL OR agency_id = $2)",
+		before,
+		agencyID,
+	)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return nil, nil, ruleError(err)
+	}
+	result.Targets = tag.RowsAffected()
+
+	if err = tx.Commit(ctx); err != nil {
+		logging.From(ctx).Error(err)
+		return nil, nil, ruleError(err)
+	}
+	return &result, keys, nil
+}
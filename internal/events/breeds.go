@@ -0,0 +1,279 @@
+package events
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+// breedSnapshot is a copy of the TheCatAPI catalogue (ids and names only),
+// imported when the API cannot be reached so a fresh database is not stuck
+// with the seed rows. Profiles arrive with the next successful sync.
+//
+//go:embed breeds.json
+var breedSnapshot []byte
+
+const maxBreedsBody = 10 << 20
+
+var errNotModified = errors.New("breeds not modified")
+
+type breed struct {
+	models.BreedProfile
+	Name  string `json:"name"`
+	ApiID string `json:"id"`
+}
+
+type breedsResponse struct {
+	breeds       []breed
+	etag         string
+	lastModified string
+}
+
+// retryableError marks failures worth another attempt: network errors, 5xx and 429.
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// LoadBreeds syncs the breed catalogue with TheCatAPI. New breeds are
+// inserted, renamed breeds and changed profiles updated and breeds gone from
+// the API are marked removed. If the API stays unreachable after all retries, the bundled
+// snapshot fills in breeds that are missing, without touching existing rows.
+// Every sync that succeeds, from either source, is recorded for readiness.
+func (e *Events) LoadBreeds(ctx context.Context) (*models.BreedSyncResult, error) {
+	ctx, span := tracing.Job(ctx, "LoadBreeds")
+	defer span.End()
+
+	resp, err := e.fetchBreeds(ctx)
+	if errors.Is(err, errNotModified) {
+		return e.synced(ctx, &models.BreedSyncResult{Source: "not_modified"})
+	}
+	if err != nil {
+		if !e.config.BreedsSnapshotFallback || ctx.Err() != nil {
+			return nil, err
+		}
+		logging.From(ctx).Warnf("TheCatAPI unavailable, importing bundled breed snapshot: %s", err)
+		return e.importSnapshot(ctx)
+	}
+
+	result := &models.BreedSyncResult{Source: "api"}
+	keep := make([]string, 0, len(resp.breeds))
+	for _, b := range resp.breeds {
+		keep = append(keep, b.ApiID)
+
+		profile := b.BreedProfile
+		_, change, err := e.breeds.Upsert(ctx, models.Breed{ApiID: b.ApiID, Name: b.Name, Profile: &profile})
+		if err != nil {
+			logging.From(ctx).Errorf("breed %s: %s", b.ApiID, err)
+			result.Failed++
+			continue
+		}
+		switch change {
+		case models.BreedCreated:
+			result.Created++
+		case models.BreedRenamed:
+			result.Renamed++
+		case models.BreedUpdated:
+			result.Updated++
+		case models.BreedRestored:
+			result.Restored++
+		default:
+			result.Unchanged++
+		}
+	}
+
+	if result.Failed > 0 {
+		// Leave removals and cache validators alone so the next sync retries in full.
+		return result, fmt.Errorf("%d of %d breeds failed to import", result.Failed, len(resp.breeds))
+	}
+
+	removed, err := e.breeds.MarkRemoved(ctx, keep)
+	if err != nil {
+		return result, err
+	}
+	result.Removed = int(removed)
+
+	if _, err := e.synced(ctx, result); err != nil {
+		return result, err
+	}
+
+	e.mu.Lock()
+	e.etag, e.lastModified = resp.etag, resp.lastModified
+	e.mu.Unlock()
+
+	return result, nil
+}
+
+// RunBreedSync re-syncs breeds every interval until ctx is cancelled.
+func (e *Events) RunBreedSync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := e.LoadBreeds(ctx)
+			if err != nil {
+				logging.From(ctx).Errorf("breed sync: %s", err)
+				continue
+			}
+			logging.From(ctx).WithFields(logrus.Fields{
+				"source":   result.Source,
+				"created":  result.Created,
+				"renamed":  result.Renamed,
+				"updated":  result.Updated,
+				"restored": result.Restored,
+				"removed":  result.Removed,
+			}).Info("Breeds synced")
+		}
+	}
+}
+
+func (e *Events) importSnapshot(ctx context.Context) (*models.BreedSyncResult, error) {
+	var breeds []breed
+	if err := json.Unmarshal(breedSnapshot, &breeds); err != nil {
+		return nil, fmt.Errorf("bundled breed snapshot: %w", err)
+	}
+
+	result := &models.BreedSyncResult{Source: "snapshot"}
+	for _, b := range breeds {
+		// Create leaves existing rows untouched, so a stale snapshot never
+		// reverts names or removals learned from the API.
+		if _, err := e.breeds.Create(ctx, models.Breed{ApiID: b.ApiID, Name: b.Name}); err != nil {
+			logging.From(ctx).Errorf("breed %s: %s", b.ApiID, err)
+			result.Failed++
+			continue
+		}
+		result.Unchanged++
+	}
+
+	if result.Failed > 0 {
+		return result, fmt.Errorf("%d of %d snapshot breeds failed to import", result.Failed, len(breeds))
+	}
+	return e.synced(ctx, result)
+}
+
+// synced records a sync that succeeded, which readiness waits for.
+func (e *Events) synced(ctx context.Context, result *models.BreedSyncResult) (*models.BreedSyncResult, error) {
+	if err := e.breeds.MarkSynced(ctx, result.Source); err != nil {
+		return result, fmt.Errorf("record breed sync: %w", err)
+	}
+	return result, nil
+}
+
+func (e *Events) fetchBreeds(ctx context.Context) (*breedsResponse, error) {
+	backoff := e.config.BreedsRetryBackoff
+
+	var err error
+	for attempt := 0; attempt <= e.config.BreedsRetries; attempt++ {
+		if attempt > 0 {
+			wait := backoff + rand.N(backoff/2+1)
+			logging.From(ctx).Warnf("TheCatAPI request failed: %s, retry %d/%d in %s", err, attempt, e.config.BreedsRetries, wait)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			backoff *= 2
+		}
+
+		var resp *breedsResponse
+		resp, err = e.fetchOnce(ctx)
+		if err == nil {
+			return resp, nil
+		}
+
+		var retryable *retryableError
+		if !errors.As(err, &retryable) {
+			return nil, err
+		}
+	}
+
+	return nil, err
+}
+
+func (e *Events) fetchOnce(ctx context.Context) (*breedsResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.config.TheCatApiUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	e.mu.Lock()
+	if e.etag != "" {
+		req.Header.Set("If-None-Match", e.etag)
+	}
+	if e.lastModified != "" {
+		req.Header.Set("If-Modified-Since", e.lastModified)
+	}
+	e.mu.Unlock()
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &retryableError{err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return nil, errNotModified
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, &retryableError{statusError(resp)}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, statusError(resp)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBreedsBody))
+	if err != nil {
+		return nil, &retryableError{err}
+	}
+
+	var breeds []breed
+	if err := json.Unmarshal(body, &breeds); err != nil {
+		return nil, fmt.Errorf("decode TheCatAPI breeds: %w", err)
+	}
+
+	valid := breeds[:0]
+	for _, b := range breeds {
+		if strings.TrimSpace(b.ApiID) == "" || strings.TrimSpace(b.Name) == "" {
+			logging.From(ctx).Warnf("skipping TheCatAPI breed without id or name: %+v", b)
+			continue
+		}
+		valid = append(valid, b)
+	}
+
+	// An empty list would mark the whole catalogue removed; treat it as an outage.
+	if len(valid) == 0 {
+		return nil, &retryableError{errors.New("TheCatAPI returned no breeds")}
+	}
+
+	return &breedsResponse{
+		breeds:       valid,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+func statusError(resp *http.Response) error {
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("TheCatAPI responded %s: %s", resp.Status, strings.TrimSpace(string(snippet)))
+}
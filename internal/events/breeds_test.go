@@ -0,0 +1,341 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+// memoryBreeds keeps breeds by api_id with the semantics of the breeds
+// table: Create leaves existing rows alone, Upsert reports what it changed
+// and MarkRemoved flags what the API no longer lists. MarkSynced keeps the
+// source of every sync recorded.
+type memoryBreeds struct {
+	mu      sync.Mutex
+	rows    map[string]*models.Breed
+	upserts int
+	syncs   []string
+}
+
+func newMemoryBreeds(breeds ...models.Breed) *memoryBreeds {
+	m := &memoryBreeds{rows: map[string]*models.Breed{}}
+	for _, b := range breeds {
+		b.ID = uuid.New()
+		m.rows[b.ApiID] = &b
+	}
+	return m
+}
+
+func (m *memoryBreeds) Create(_ context.Context, breed models.Breed) (*models.Breed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if row, ok := m.rows[breed.ApiID]; ok {
+		return row, nil
+	}
+	breed.ID = uuid.New()
+	m.rows[breed.ApiID] = &breed
+	return &breed, nil
+}
+
+func (m *memoryBreeds) Upsert(_ context.Context, breed models.Breed) (*models.Breed, models.BreedChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.upserts++
+
+	row, ok := m.rows[breed.ApiID]
+	if !ok {
+		breed.ID = uuid.New()
+		m.rows[breed.ApiID] = &breed
+		return &breed, models.BreedCreated, nil
+	}
+
+	change := models.BreedUnchanged
+	switch {
+	case row.Name != breed.Name:
+		change = models.BreedRenamed
+	case row.RemovedAt != nil:
+		change = models.BreedRestored
+	case !reflect.DeepEqual(row.Profile, breed.Profile):
+		change = models.BreedUpdated
+	}
+	row.Name, row.Profile, row.RemovedAt = breed.Name, breed.Profile, nil
+	return row, change, nil
+}
+
+func (m *memoryBreeds) MarkRemoved(_ context.Context, keep []string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed int64
+	now := time.Now()
+	for id, row := range m.rows {
+		if row.RemovedAt == nil && !slices.Contains(keep, id) {
+			row.RemovedAt = &now
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (m *memoryBreeds) MarkSynced(_ context.Context, source string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.syncs = append(m.syncs, source)
+	return nil
+}
+
+func (m *memoryBreeds) get(apiID string) models.Breed {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return *m.rows[apiID]
+}
+
+func testEvents(t *testing.T, api http.Handler, store breedStore) *Events {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	cfg := config.Default()
+	cfg.TheCatApiUrl = srv.URL
+	cfg.BreedsRetries = 3
+	cfg.BreedsRetryBackoff = 20 * time.Millisecond
+	cfg.BreedsSnapshotFallback = false
+	return &Events{breeds: store, config: cfg, client: srv.Client()}
+}
+
+func writeBreeds(t *testing.T, w http.ResponseWriter, breeds ...breed) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(breeds); err != nil {
+		t.Error(err)
+	}
+}
+
+func apiBreed(id, name string) breed {
+	return breed{ApiID: id, Name: name, BreedProfile: models.BreedProfile{Origin: "Egypt"}}
+}
+
+func TestLoadBreedsRetriesServerErrorsWithBackoff(t *testing.T) {
+	var mu sync.Mutex
+	var at []time.Time
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		at = append(at, time.Now())
+		attempt := len(at)
+		mu.Unlock()
+
+		switch attempt {
+		case 1:
+			http.Error(w, "upstream down", http.StatusServiceUnavailable)
+		case 2:
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		default:
+			writeBreeds(t, w, apiBreed("abys", "Abyssinian"))
+		}
+	})
+	store := newMemoryBreeds()
+	e := testEvents(t, api, store)
+
+	result, err := e.LoadBreeds(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Source != "api" || result.Created != 1 {
+		t.Fatalf("got %+v, want one breed created from the api", result)
+	}
+	if !slices.Equal(store.syncs, []string{"api"}) {
+		t.Errorf("recorded syncs %q, want the api sync", store.syncs)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(at) != 3 {
+		t.Fatalf("got %d requests, want 3", len(at))
+	}
+	backoff := e.config.BreedsRetryBackoff
+	if gap := at[1].Sub(at[0]); gap < backoff {
+		t.Errorf("first retry after %s, want at least %s", gap, backoff)
+	}
+	if gap := at[2].Sub(at[1]); gap < 2*backoff {
+		t.Errorf("second retry after %s, want at least %s", gap, 2*backoff)
+	}
+}
+
+func TestLoadBreedsGivesUpOnClientErrorsAndAfterRetries(t *testing.T) {
+	for _, tc := range []struct {
+		status   int
+		requests int
+	}{
+		{http.StatusNotFound, 1},
+		{http.StatusBadGateway, 4},
+	} {
+		var mu sync.Mutex
+		requests := 0
+		api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requests++
+			mu.Unlock()
+			http.Error(w, "no", tc.status)
+		})
+		store := newMemoryBreeds()
+		e := testEvents(t, api, store)
+		e.config.BreedsRetryBackoff = time.Millisecond
+
+		if _, err := e.LoadBreeds(context.Background()); err == nil {
+			t.Errorf("status %d: want an error", tc.status)
+		}
+		mu.Lock()
+		if requests != tc.requests {
+			t.Errorf("status %d: got %d requests, want %d", tc.status, requests, tc.requests)
+		}
+		mu.Unlock()
+		if len(store.rows) != 0 {
+			t.Errorf("status %d: stored %d breeds, want none", tc.status, len(store.rows))
+		}
+		if len(store.syncs) != 0 {
+			t.Errorf("status %d: recorded syncs %q after a failed sync", tc.status, store.syncs)
+		}
+	}
+}
+
+func TestLoadBreedsSendsValidatorsAndHonoursNotModified(t *testing.T) {
+	const (
+		etag         = `"v1"`
+		lastModified = "Mon, 19 Oct 2026 08:00:00 GMT"
+	)
+	var mu sync.Mutex
+	var validators [][2]string
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		validators = append(validators, [2]string{r.Header.Get("If-None-Match"), r.Header.Get("If-Modified-Since")})
+		mu.Unlock()
+
+		if r.Header.Get("If-None-Match") == etag || r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		writeBreeds(t, w, apiBreed("abys", "Abyssinian"), apiBreed("beng", "Bengal"))
+	})
+	store := newMemoryBreeds()
+	e := testEvents(t, api, store)
+
+	first, err := e.LoadBreeds(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Source != "api" || first.Created != 2 {
+		t.Fatalf("first sync got %+v, want two breeds created", first)
+	}
+
+	second, err := e.LoadBreeds(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Source != "not_modified" {
+		t.Fatalf("second sync got source %q, want not_modified", second.Source)
+	}
+	if store.upserts != 2 {
+		t.Errorf("got %d upserts, want the 2 of the first sync only", store.upserts)
+	}
+	if want := []string{"api", "not_modified"}; !slices.Equal(store.syncs, want) {
+		t.Errorf("recorded syncs %q, want %q", store.syncs, want)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if validators[0] != [2]string{} {
+		t.Errorf("first request sent validators %q, want none", validators[0])
+	}
+	if want := [2]string{etag, lastModified}; validators[1] != want {
+		t.Errorf("second request sent %q, want %q", validators[1], want)
+	}
+}
+
+func TestLoadBreedsFallsBackToSnapshot(t *testing.T) {
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusInternalServerError)
+	})
+	// A breed renamed by an earlier sync keeps its name: the snapshot only
+	// fills in what is missing.
+	store := newMemoryBreeds(models.Breed{ApiID: "abys", Name: "Abyssinian Cat"})
+	e := testEvents(t, api, store)
+	e.config.BreedsRetries = 0
+	e.config.BreedsSnapshotFallback = true
+
+	result, err := e.LoadBreeds(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var snapshot []breed
+	if err := json.Unmarshal(breedSnapshot, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if result.Source != "snapshot" || result.Unchanged != len(snapshot) {
+		t.Fatalf("got %+v, want all %d snapshot breeds from the snapshot", result, len(snapshot))
+	}
+	if len(store.rows) != len(snapshot) {
+		t.Errorf("stored %d breeds, want %d", len(store.rows), len(snapshot))
+	}
+	if got := store.get("abys").Name; got != "Abyssinian Cat" {
+		t.Errorf("existing breed renamed to %q by the snapshot", got)
+	}
+	if store.upserts != 0 {
+		t.Errorf("snapshot import upserted %d breeds, want none", store.upserts)
+	}
+	if !slices.Equal(store.syncs, []string{"snapshot"}) {
+		t.Errorf("recorded syncs %q, want the snapshot import", store.syncs)
+	}
+}
+
+func TestLoadBreedsReconcilesRenamedAndRemovedBreeds(t *testing.T) {
+	removed := time.Now().Add(-24 * time.Hour)
+	egypt := &models.BreedProfile{Origin: "Egypt"}
+	store := newMemoryBreeds(
+		models.Breed{ApiID: "abys", Name: "Abyssinian", Profile: egypt},
+		models.Breed{ApiID: "beng", Name: "Bengal", Profile: egypt},
+		models.Breed{ApiID: "chau", Name: "Chausie", Profile: egypt, RemovedAt: &removed},
+		models.Breed{ApiID: "aege", Name: "Aegean", Profile: egypt},
+	)
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeBreeds(t, w,
+			apiBreed("abys", "Abyssinian"),
+			apiBreed("beng", "Bengal Cat"),
+			apiBreed("chau", "Chausie"),
+			apiBreed("cypr", "Cyprus"),
+		)
+	})
+	e := testEvents(t, api, store)
+
+	result, err := e.LoadBreeds(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.BreedSyncResult{Source: "api", Created: 1, Renamed: 1, Restored: 1, Unchanged: 1, Removed: 1}
+	if *result != want {
+		t.Fatalf("got %+v, want %+v", *result, want)
+	}
+
+	if got := store.get("beng").Name; got != "Bengal Cat" {
+		t.Errorf("beng is named %q, want the new name", got)
+	}
+	if store.get("chau").RemovedAt != nil {
+		t.Error("chau is back in the API but still removed")
+	}
+	if store.get("aege").RemovedAt == nil {
+		t.Error("aege is gone from the API but not marked removed")
+	}
+}
@@ -2,65 +2,41 @@ package events
 
 import (
 	"context"
-	"encoding/json"
-	"io"
 	"net/http"
+	"sync"
 
 	"github.com/mksmstpck/spy_cat_agency/internal/config"
 	"github.com/mksmstpck/spy_cat_agency/internal/models"
 	"github.com/mksmstpck/spy_cat_agency/internal/services"
-	"github.com/sirupsen/logrus"
 )
 
+// breedStore is what the breed import writes to: the breed service, or a
+// stand-in in tests.
+type breedStore interface {
+	Create(ctx context.Context, breed models.Breed) (*models.Breed, error)
+	Upsert(ctx context.Context, breed models.Breed) (*models.Breed, models.BreedChange, error)
+	MarkRemoved(ctx context.Context, keep []string) (int64, error)
+	MarkSynced(ctx context.Context, source string) error
+}
+
 type Events struct {
 	services services.Services
+	breeds   breedStore
 	config   config.Config
+	client   *http.Client
+
+	// mu guards the cache validators of the last successful breed import.
+	mu           sync.Mutex
+	etag         string
+	lastModified string
 }
 
 func NewEvents(services services.Services, config config.Config) *Events {
-	return &Events{
+	e := &Events{
 		services: services,
 		config:   config,
+		client:   &http.Client{Timeout: config.BreedsHTTPTimeout},
 	}
-}
-
-type breed struct {
-	Name  string `json:"name"`
-	ApiID string `json:"id"`
-}
-
-func (e *Events) LoadBreeds(ctx context.Context) error {
-	resp, err := http.Get(e.config.TheCatApiUrl)
-	if err != nil {
-		logrus.Error(err)
-		return err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		logrus.Error(err)
-		return err
-	}
-
-	var breeds []breed
-
-	if err := json.Unmarshal(body, &breeds); err != nil {
-		logrus.Error(err)
-		return err
-	}
-
-	mBreed := models.Breed{}
-	for _, breed := range breeds {
-		mBreed.ApiID = breed.ApiID
-		mBreed.Name = breed.Name
-
-		_, err := e.services.Breed.Create(ctx, mBreed)
-		if err != nil {
-			logrus.Error(err)
-			return err
-		}
-	}
-
-	return nil
+	e.breeds = &e.services.Breed
+	return e
 }
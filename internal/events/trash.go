@@ -0,0 +1,46 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+// RunTrashPurge permanently removes expired trash every interval until ctx
+// is cancelled.
+func (e *Events) RunTrashPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := e.purgeTrash(ctx)
+			if err != nil {
+				logging.From(ctx).Errorf("trash purge: %s", err)
+				continue
+			}
+			if result.Missions > 0 || result.Targets > 0 {
+				logging.From(ctx).WithFields(logrus.Fields{
+					"missions": result.Missions,
+					"targets":  result.Targets,
+				}).Info("Trash purged")
+			}
+		}
+	}
+}
+
+func (e *Events) purgeTrash(ctx context.Context) (*models.PurgeResult, error) {
+	ctx, span := tracing.Job(ctx, "PurgeTrash")
+	defer span.End()
+
+	result, err := e.services.Trash.PurgeAll(ctx)
+	tracing.Fail(span, err)
+	return result, err
+}
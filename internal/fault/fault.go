@@ -0,0 +1,53 @@
+// Package fault marks errors with the kind of rule they broke, so the HTTP
+// API can pick a status with errors.As instead of reading messages. The
+// message of a marked error is unchanged, and wrapping it with %w keeps
+// the mark.
+package fault
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind is what went wrong, as far as the caller is concerned.
+type Kind int
+
+const (
+	// Conflict is a request that breaks a business rule.
+	Conflict Kind = iota + 1
+	// Forbidden is a caller acting above its clearance.
+	Forbidden
+	// NotFound is a record that does not exist, or not for this agency.
+	NotFound
+)
+
+// Error is an error marked with its kind.
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Conflictf formats a business rule violation.
+func Conflictf(format string, args ...any) error {
+	return &Error{Kind: Conflict, Err: fmt.Errorf(format, args...)}
+}
+
+// Forbiddenf formats a clearance violation.
+func Forbiddenf(format string, args ...any) error {
+	return &Error{Kind: Forbidden, Err: fmt.Errorf(format, args...)}
+}
+
+// NotFoundf formats a missing record.
+func NotFoundf(format string, args ...any) error {
+	return &Error{Kind: NotFound, Err: fmt.Errorf(format, args...)}
+}
+
+// Is reports whether err, or an error it wraps, is marked with kind.
+func Is(err error, kind Kind) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Kind == kind
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/services"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+)
+
+type agency struct {
+	config   config.Config
+	services *services.Services
+}
+
+func newAgency(
+	config config.Config,
+	services *services.Services,
+) *agency {
+	return &agency{
+		config:   config,
+		services: services,
+	}
+}
+
+type agencyInput struct {
+	Slug     string                `json:"slug" binding:"required"`
+	Name     string                `json:"name" binding:"required"`
+	Settings models.AgencySettings `json:"settings"`
+}
+
+type breedOverrideInput struct {
+	Name   *string `json:"name,omitempty"`
+	Hidden bool    `json:"hidden"`
+}
+
+// Scope makes the request act for the agency of its gateway token. Only an
+// operator may pick the agency with the X-Agency header, or act for the
+// configured default agency without it. It runs after Authenticate or
+// RequireOperator.
+func (h *agency) Scope(c *gin.Context) {
+	header := strings.TrimSpace(c.GetHeader("X-Agency"))
+	slug := c.GetString(gatewayKey)
+	switch {
+	case slug != "":
+		if header != "" && !strings.EqualFold(header, slug) {
+			abortProblem(c, http.StatusForbidden, "the credentials are for agency "+slug)
+			return
+		}
+	case c.GetString(operatorKey) != "":
+		slug = header
+		if slug == "" {
+			slug = h.config.DefaultAgency
+		}
+		if slug == "" {
+			abortProblem(c, http.StatusBadRequest, "set the X-Agency header to the agency slug")
+			return
+		}
+	default:
+		abortUnauthenticated(c, "gateway or operator credentials are required")
+		return
+	}
+
+	a, err := h.services.Agency.GetBySlug(c.Request.Context(), slug)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "no agency has the slug "+slug)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to resolve agency")
+		return
+	}
+
+	ctx := tenant.WithAgency(c.Request.Context(), *a)
+	ctx = logging.With(ctx, logger(c).WithField("agency", a.Slug))
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+}
+
+func (h *agency) Create(c *gin.Context) {
+	var input agencyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger(c).Error(err)
+		abortBinding(c, err)
+		return
+	}
+
+	created, err := h.services.Agency.Create(c.Request.Context(), models.Agency{
+		Slug:     input.Slug,
+		Name:     input.Name,
+		Settings: input.Settings,
+	})
+	if err != nil {
+		logger(c).Error(err)
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to create agency")
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *agency) GetAll(c *gin.Context) {
+	agencies, err := h.services.Agency.GetAll(c.Request.Context())
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve agencies")
+		return
+	}
+
+	c.JSON(http.StatusOK, agencies)
+}
+
+func (h *agency) GetBySlug(c *gin.Context) {
+	a, err := h.services.Agency.GetBySlug(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Agency not found")
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve agency")
+		return
+	}
+
+	c.JSON(http.StatusOK, a)
+}
+
+func (h *agency) UpdateSettings(c *gin.Context) {
+	var settings models.AgencySettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		logger(c).Error(err)
+		abortBinding(c, err)
+		return
+	}
+
+	updated, err := h.services.Agency.UpdateSettings(c.Request.Context(), c.Param("slug"), settings)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Agency not found")
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to update agency settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *agency) GetBreedOverrides(c *gin.Context) {
+	overrides, err := h.services.Agency.GetBreedOverrides(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Agency not found")
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve breed overrides")
+		return
+	}
+
+	c.JSON(http.StatusOK, overrides)
+}
+
+func parseBreedID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("breed_id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "breed ID must be a valid UUID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *agency) SetBreedOverride(c *gin.Context) {
+	breedID, ok := parseBreedID(c)
+	if !ok {
+		return
+	}
+
+	var input breedOverrideInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger(c).Error(err)
+		abortBinding(c, err)
+		return
+	}
+
+	override, err := h.services.Agency.SetBreedOverride(c.Request.Context(), c.Param("slug"), models.BreedOverride{
+		BreedID: breedID,
+		Name:    input.Name,
+		Hidden:  input.Hidden,
+	})
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortError(c, http.StatusNotFound, err)
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to set breed override")
+		return
+	}
+
+	c.JSON(http.StatusOK, override)
+}
+
+func (h *agency) DeleteBreedOverride(c *gin.Context) {
+	breedID, ok := parseBreedID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Agency.DeleteBreedOverride(c.Request.Context(), c.Param("slug"), breedID); err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortError(c, http.StatusNotFound, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to delete breed override")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+)
+
+const (
+	gatewayToken  = "mi6-gateway-token-0001"
+	operatorToken = "root-operator-token-01"
+)
+
+var agencyRow = reply{
+	match: "FROM agencies ag WHERE ag.slug",
+	columns: []column{
+		{"id", uuidOID}, {"slug", textOID}, {"name", textOID},
+		{"settings", pgtype.JSONBOID}, {"created_at", timestamptzOID}, {"updated_at", timestamptzOID},
+	},
+	rows: [][]any{{testAgency.ID, testAgency.Slug, "Test", "{}", testTime, testTime}},
+}
+
+// credentialRouter serves GET / with handler behind the middleware of the
+// agency routes, for a mi6 gateway and a root operator.
+func credentialRouter(t *testing.T, pool *pgxpool.Pool, handler gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	cfg := config.Default()
+	cfg.GatewayTokens = []string{"mi6:" + gatewayToken}
+	cfg.AdminTokens = []string{"root:" + operatorToken}
+	s := testServices(t, pool)
+	principals, agency := newPrincipals(cfg, s), newAgency(cfg, s)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", principals.Authenticate, agency.Scope, principals.Identify, handler)
+	return r
+}
+
+// serveAs requests GET / with token as the bearer token and the non-empty
+// headers.
+func serveAs(r http.Handler, token string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for name, value := range headers {
+		if value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestScopeTakesTheAgencyFromTheCredentials(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		agency     string
+		wantStatus int
+		wantSlug   string
+	}{
+		{"no credentials", "", "", http.StatusUnauthorized, ""},
+		{"header alone", "", "mi6", http.StatusUnauthorized, ""},
+		{"unknown token", "not-a-known-token-at-all", "mi6", http.StatusUnauthorized, ""},
+		{"gateway", gatewayToken, "", http.StatusOK, "mi6"},
+		{"gateway naming its agency", gatewayToken, "mi6", http.StatusOK, "mi6"},
+		{"gateway naming another agency", gatewayToken, "cia", http.StatusForbidden, ""},
+		{"operator", operatorToken, "cia", http.StatusOK, "cia"},
+		{"operator without header", operatorToken, "", http.StatusOK, "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pg, pool := newFakePostgres(t, agencyRow)
+			r := credentialRouter(t, pool, func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := serveAs(r, tt.token, map[string]string{"X-Agency": tt.agency})
+
+			if tt.wantStatus != http.StatusOK {
+				problemOf(t, w, tt.wantStatus)
+				if pg.ran("FROM agencies") {
+					t.Error("the agency was looked up for a request that may not act for it")
+				}
+				return
+			}
+			if w.Code != http.StatusOK {
+				t.Fatalf("got status %d, want 200: %s", w.Code, w.Body)
+			}
+			if !pg.ran("'" + tt.wantSlug + "'") {
+				t.Errorf("the request did not act for %s", tt.wantSlug)
+			}
+		})
+	}
+}
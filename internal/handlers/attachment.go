@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/services"
+)
+
+// multipartOverhead allows for the multipart framing around the file.
+const multipartOverhead = 1 << 20
+
+type attachment struct {
+	config   config.Config
+	services *services.Services
+}
+
+func newAttachment(
+	config config.Config,
+	services *services.Services,
+) *attachment {
+	return &attachment{
+		config:   config,
+		services: services,
+	}
+}
+
+func parseTargetID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "target ID must be a valid UUID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func parseAttachmentID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("attachment_id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "attachment ID must be a valid UUID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// Create stores the multipart form field "file" on the target. The body is
+// streamed to storage, never held in memory.
+func (h *attachment) Create(c *gin.Context) {
+	targetID, ok := parseTargetID(c)
+	if !ok {
+		return
+	}
+	if !h.disclose(c, targetID) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.services.Attachment.MaxBytes(c.Request.Context())+multipartOverhead)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		abortProblem(c, http.StatusBadRequest, "expected a multipart/form-data upload with a file field")
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			details := "file field is required"
+			if !errors.Is(err, io.EOF) {
+				details = err.Error()
+			}
+			h.abortUpload(c, err, details)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		created, err := h.services.Attachment.Create(c.Request.Context(), targetID, part.FileName(), part, actor(c))
+		part.Close()
+		if err != nil {
+			logger(c).Error(err)
+			h.abortUpload(c, err, err.Error())
+			return
+		}
+
+		c.JSON(http.StatusCreated, created)
+		return
+	}
+}
+
+func (h *attachment) abortUpload(c *gin.Context, err error, details string) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case isForbiddenError(err):
+		abortForbidden(c, err)
+	case isNotFoundError(err):
+		abortProblem(c, http.StatusNotFound, "Target not found")
+	case errors.As(err, &tooLarge), errors.Is(err, services.ErrAttachmentTooLarge):
+		abortProblem(c, http.StatusRequestEntityTooLarge, details)
+	case errors.Is(err, services.ErrAttachmentType):
+		abortProblem(c, http.StatusUnsupportedMediaType, details)
+	case errors.Is(err, services.ErrAttachmentEmpty), errors.Is(err, io.EOF):
+		abortProblem(c, http.StatusUnprocessableEntity, details)
+	default:
+		abortProblem(c, http.StatusInternalServerError, "Failed to store attachment")
+	}
+}
+
+// disclose checks the caller is cleared for the target's attachments, which
+// are withheld as a whole, and audits the access either way. Uploads and
+// deletions go through it too.
+func (h *attachment) disclose(c *gin.Context, targetID uuid.UUID) bool {
+	target, err := h.services.Target.GetByID(c.Request.Context(), targetID)
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve target")
+		return false
+	}
+	if target == nil {
+		abortProblem(c, http.StatusNotFound, "Target not found")
+		return false
+	}
+
+	d := newDisclosure(c)
+	withheld := d.withhold("target", target.ID, target.Classification)
+	if !d.record(c, h.services) {
+		return false
+	}
+	if withheld {
+		abortForbidden(c, fault.Forbiddenf("%s is not cleared for %s", d.principal.Name, target.Classification))
+		return false
+	}
+	return true
+}
+
+func (h *attachment) GetByTarget(c *gin.Context) {
+	targetID, ok := parseTargetID(c)
+	if !ok {
+		return
+	}
+	if !h.disclose(c, targetID) {
+		return
+	}
+
+	attachments, err := h.services.Attachment.GetByTarget(c.Request.Context(), targetID)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Target not found")
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve attachments")
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// Download streams the file with its sniffed content type. The SHA-256 is
+// the ETag and is also sent as X-Checksum-SHA256 for verification.
+func (h *attachment) Download(c *gin.Context) {
+	targetID, ok := parseTargetID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAttachmentID(c)
+	if !ok {
+		return
+	}
+	if !h.disclose(c, targetID) {
+		return
+	}
+
+	attachment, content, err := h.services.Attachment.Open(c.Request.Context(), targetID, id)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Attachment not found")
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to read attachment")
+		return
+	}
+	defer content.Close()
+
+	etag := `"` + attachment.SHA256 + `"`
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, attachment.SizeBytes, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+		"ETag":                   etag,
+		"X-Checksum-SHA256":      attachment.SHA256,
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func (h *attachment) Delete(c *gin.Context) {
+	targetID, ok := parseTargetID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAttachmentID(c)
+	if !ok {
+		return
+	}
+	if !h.disclose(c, targetID) {
+		return
+	}
+
+	err := h.services.Attachment.Delete(c.Request.Context(), targetID, id)
+	if err != nil {
+		logger(c).Error(err)
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Attachment not found")
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to delete attachment")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/principal"
+	"github.com/mksmstpck/spy_cat_agency/internal/services"
+)
+
+// disclosure shapes a response to the caller's clearance. Fields of missions
+// and targets classified above it are emptied and named in Withheld, and
+// every classified object in the response, shown or withheld, is audited.
+type disclosure struct {
+	principal models.Principal
+	path      string
+	entries   []models.AuditEntry
+}
+
+func newDisclosure(c *gin.Context) *disclosure {
+	return &disclosure{
+		principal: principal.From(c.Request.Context()),
+		path:      c.Request.Method + " " + c.Request.URL.Path,
+	}
+}
+
+// withhold audits a classified object and reports whether the caller must
+// not see its classified fields.
+func (d *disclosure) withhold(kind string, id uuid.UUID, level models.Classification) bool {
+	if level == "" || level == models.Unclassified {
+		return false
+	}
+
+	cleared := d.principal.Clearance.Covers(level)
+	outcome := models.AuditRead
+	if !cleared {
+		outcome = models.AuditWithheld
+	}
+	d.entries = append(d.entries, models.AuditEntry{
+		Principal:      d.principal.Name,
+		Clearance:      d.principal.Clearance,
+		Kind:           kind,
+		ObjectID:       id,
+		Classification: level,
+		Outcome:        outcome,
+		Path:           d.path,
+	})
+	return !cleared
+}
+
+func (d *disclosure) mission(m *models.Mission) {
+	if d.withhold("mission", m.ID, m.Classification) {
+		m.Description = nil
+		m.Withheld = []string{"Description"}
+	}
+	for i := range m.Targets {
+		d.target(&m.Targets[i])
+	}
+}
+
+func (d *disclosure) missions(missions []models.Mission) {
+	for i := range missions {
+		d.mission(&missions[i])
+	}
+}
+
+func (d *disclosure) target(t *models.Target) {
+	if d.withhold("target", t.ID, t.Classification) {
+		t.Notes = ""
+		t.Withheld = []string{"Notes"}
+	}
+}
+
+// hit withholds the snippet, which quotes the mission description.
+func (d *disclosure) hit(h *models.SearchHit) {
+	if d.withhold(h.Kind, h.ID, h.Classification) {
+		h.Snippet = ""
+		h.Withheld = []string{"Snippet"}
+	}
+}
+
+// record writes the audit entries. Nothing classified may be sent when it
+// returns false; the response has already been aborted.
+func (d *disclosure) record(c *gin.Context, s *services.Services) bool {
+	if err := s.Audit.Record(c.Request.Context(), d.entries); err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to record classified access")
+		return false
+	}
+	return true
+}
+
+// send writes the audit entries, then the response.
+func (d *disclosure) send(c *gin.Context, s *services.Services, status int, v any) {
+	if d.record(c, s) {
+		c.JSON(status, v)
+	}
+}
+
+// isForbiddenError reports a caller acting above its clearance.
+func isForbiddenError(err error) bool {
+	return fault.Is(err, fault.Forbidden)
+}
+
+func abortForbidden(c *gin.Context, err error) {
+	abortError(c, http.StatusForbidden, err)
+}
+
+type classificationInput struct {
+	Classification models.Classification `json:"classification" binding:"required"`
+}
+
+type clearanceInput struct {
+	Clearance models.Classification `json:"clearance" binding:"required"`
+}
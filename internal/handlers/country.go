@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/services"
+)
+
+type country struct {
+	config   config.Config
+	services *services.Services
+}
+
+func newCountry(
+	config config.Config,
+	services *services.Services,
+) *country {
+	return &country{
+		config:   config,
+		services: services,
+	}
+}
+
+func (h *country) GetAll(c *gin.Context) {
+	countries, err := h.services.Country.GetAll(c.Request.Context())
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve countries")
+		return
+	}
+
+	c.JSON(http.StatusOK, countries)
+}
+
+func (h *country) GetUnresolved(c *gin.Context) {
+	unresolved, err := h.services.Country.GetUnresolved(c.Request.Context())
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve unresolved countries")
+		return
+	}
+
+	c.JSON(http.StatusOK, unresolved)
+}
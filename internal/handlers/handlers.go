@@ -5,20 +5,34 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/metrics"
 	"github.com/mksmstpck/spy_cat_agency/internal/services"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Handlers struct {
-	spyCat  *spyCat
-	mission *mission
-	target  *target
-	config  config.Config
+	spyCat     *spyCat
+	mission    *mission
+	target     *target
+	health     *health
+	skill      *skill
+	leave      *leave
+	trash      *trash
+	search     *search
+	country    *country
+	attachment *attachment
+	template   *template
+	agency     *agency
+	principals *principals
+	config     config.Config
 }
 
 func NewHandlers(
@@ -26,63 +40,74 @@ func NewHandlers(
 	services *services.Services,
 ) *Handlers {
 	return &Handlers{
-		spyCat:  newSpyCat(config, services),
-		mission: newMission(config, services),
-		target:  newTarget(config, services),
-		config:  config,
+		spyCat:     newSpyCat(config, services),
+		mission:    newMission(config, services),
+		target:     newTarget(config, services),
+		health:     newHealth(config, services),
+		skill:      newSkill(config, services),
+		leave:      newLeave(config, services),
+		trash:      newTrash(config, services),
+		search:     newSearch(config, services),
+		country:    newCountry(config, services),
+		attachment: newAttachment(config, services),
+		template:   newTemplate(config, services),
+		agency:     newAgency(config, services),
+		principals: newPrincipals(config, services),
+		config:     config,
 	}
 }
 
-type ValidationError struct {
-	Field   string `json:"field"`
-	Message string `json:"message"`
-	Index   *int   `json:"index,omitempty"`
-}
-
-func (e *ValidationError) Error() string {
-	if e.Index != nil {
-		return e.Field + "[" + string(rune(*e.Index)) + "]: " + e.Message
+// actor names the caller: the authenticated operator, else the X-Actor
+// header, which only a gateway token vouches for. Anyone else is anonymous.
+func actor(c *gin.Context) string {
+	if name := c.GetString(operatorKey); name != "" {
+		return name
+	}
+	if c.GetString(gatewayKey) == "" {
+		return "anonymous"
+	}
+	if name := strings.TrimSpace(c.GetHeader("X-Actor")); name != "" {
+		return name
 	}
-	return e.Field + ": " + e.Message
+	return "anonymous"
 }
 
+// isBusinessLogicError reports a request the domain rules refuse.
 func isBusinessLogicError(err error) bool {
-	errMsg := strings.ToLower(err.Error())
-	businessLogicKeywords := []string{
-		"cannot delete",
-		"cannot add target",
-		"cannot update notes",
-		"must have at least",
-		"cannot have more than",
-		"mission completed",
-		"targets still incomplete",
-		"assigned to cat",
-		"salary must be",
-		"experience cannot be",
-	}
-
-	for _, keyword := range businessLogicKeywords {
-		if strings.Contains(errMsg, keyword) {
-			return true
-		}
-	}
-	return false
+	return fault.Is(err, fault.Conflict)
 }
 
-func (h *Handlers) HandleAll(ctx context.Context) {
+// HandleAll serves the API until ctx is cancelled, then drains in-flight
+// requests for up to ShutdownTimeout. It returns early if the listener fails.
+func (h *Handlers) HandleAll(ctx context.Context) error {
 	r := gin.New()
-	r.Use(gin.Recovery())
+	r.Use(gin.CustomRecovery(problemRecovery))
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(problemNoRoute)
+	r.NoMethod(problemNoMethod)
 
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*", "https://accounts.google.com"},
-		AllowMethods:     []string{"GET", "POST", "OPTIONS", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Authorization", "Content-Type", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowOrigins: h.config.CORSOrigins,
+		AllowMethods: []string{"GET", "POST", "OPTIONS", "PUT", "DELETE"},
+		AllowHeaders: []string{"Authorization", "Content-Type", "X-Requested-With", "X-Agency", "X-Actor", "X-Request-ID",
+			"traceparent", "tracestate", "baggage"},
+		ExposeHeaders:    []string{"Content-Length", "X-Request-ID"},
 		AllowCredentials: true,
 	}))
+	r.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithGinFilter(traced)))
 	r.Use(RequestLogger())
+	r.Use(metrics.HTTPMiddleware())
+
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	r.GET("/healthz", h.health.Live)
+	r.GET("/readyz", h.health.Ready)
+	r.GET("/startupz", h.health.Startup)
 
-	cat := r.Group("cat")
+	// Everything below acts for the agency of the caller's credentials.
+	scoped := r.Group("", h.principals.Authenticate, h.agency.Scope, h.principals.Identify)
+	scoped.GET("/search", h.search.Search)
+
+	cat := scoped.Group("cat")
 	{
 		cat.GET("/", h.spyCat.GetAll)
 		cat.GET("/:id", h.spyCat.GetByID)
@@ -90,45 +115,136 @@ func (h *Handlers) HandleAll(ctx context.Context) {
 		cat.PUT("/salary/:id", h.spyCat.UpdateSalary)
 		cat.PUT("/experience/:id", h.spyCat.UpdateExpYears)
 		cat.DELETE("/:id", h.spyCat.Delete)
+		cat.GET("/:id/skills", h.skill.GetCatSkills)
+		cat.PUT("/:id/skills", h.skill.SetCatSkills)
+		cat.GET("/:id/leave", h.leave.GetByCat)
+		cat.POST("/:id/leave", h.leave.Create)
+		cat.DELETE("/:id/leave/:leave_id", h.leave.Delete)
+		cat.GET("/:id/availability", h.leave.Calendar)
+		cat.GET("/:id/missions", h.spyCat.GetCareer)
+		cat.PUT("/:id/status", h.spyCat.UpdateStatus)
+		cat.POST("/:id/retire", h.spyCat.Retire)
+		cat.PUT("/:id/clearance", h.spyCat.UpdateClearance)
 	}
 
-	mission := r.Group("mission")
+	mission := scoped.Group("mission")
 	{
 		mission.GET("/", h.mission.GetAll)
 		mission.GET("/:id", h.mission.GetByID)
 		mission.POST("/", h.mission.Create)
+		mission.POST("/from-template/:id", h.mission.CreateFromTemplate)
 		mission.PUT("/:id/completed", h.mission.UpdateCompleted)
 		mission.PUT("/:id/assign", h.mission.AssignCat)
+		mission.PUT("/:id/schedule", h.mission.UpdateSchedule)
+		mission.GET("/:id/cats", h.mission.GetAssignments)
+		mission.POST("/:id/cats", h.mission.Assign)
+		mission.DELETE("/:id/cats/:cat_id", h.mission.Unassign)
 		mission.DELETE("/:id", h.mission.Delete)
+		mission.POST("/:id/restore", h.mission.Restore)
+		mission.GET("/:id/coverage", h.skill.Coverage)
+		mission.GET("/:id/route", h.mission.Route)
+		mission.PUT("/:id/classification", h.mission.UpdateClassification)
 	}
 
-	target := r.Group("target")
+	target := scoped.Group("target")
 	{
+		target.GET("/nearby", h.target.Nearby)
 		target.GET("/:id", h.target.GetByID)
 		target.POST("/", h.target.Create)
 		target.PUT("/:id/completed", h.target.UpdateCompleted)
 		target.PUT("/:id/notes", h.target.UpdateNotes)
+		target.PUT("/:id/country", h.target.UpdateCountry)
+		target.PUT("/:id/location", h.target.UpdateLocation)
+		target.GET("/:id/attachments", h.attachment.GetByTarget)
+		target.POST("/:id/attachments", h.attachment.Create)
+		target.GET("/:id/attachments/:attachment_id", h.attachment.Download)
+		target.DELETE("/:id/attachments/:attachment_id", h.attachment.Delete)
 		target.DELETE("/:id", h.target.Delete)
+		target.POST("/:id/restore", h.target.Restore)
+		target.PUT("/:id/skills", h.skill.SetTargetRequirements)
+		target.PUT("/:id/classification", h.target.UpdateClassification)
+	}
+
+	// The taxonomy is shared by every agency, so only operators extend it.
+	skill := scoped.Group("skill")
+	{
+		skill.GET("/", h.skill.GetAll)
+	}
+
+	template := scoped.Group("template")
+	{
+		template.GET("/", h.template.GetAll)
+		template.GET("/:id", h.template.GetByID)
+		template.POST("/", h.template.Create)
+		template.PUT("/:id", h.template.Update)
+		template.DELETE("/:id", h.template.Delete)
+	}
+
+	country := scoped.Group("country")
+	{
+		country.GET("/", h.country.GetAll)
+	}
+
+	// Operators only. They are authenticated before the principal is
+	// resolved, so their clearance is their own.
+	admin := r.Group("admin", h.principals.RequireOperator, h.agency.Scope, h.principals.Identify)
+	{
+		admin.GET("/trash", h.trash.GetAll)
+		admin.POST("/trash/purge", h.trash.Purge)
+		admin.GET("/countries/unresolved", h.country.GetUnresolved)
+		admin.GET("/principals", h.principals.GetAll)
+		admin.PUT("/principals/:name", h.principals.Set)
+		admin.DELETE("/principals/:name", h.principals.Delete)
+		admin.GET("/audit", h.principals.Audit)
+	}
+
+	// Operators only, across agencies.
+	skills := r.Group("admin/skills", h.principals.RequireOperator)
+	{
+		skills.POST("/", h.skill.Create)
+	}
+
+	agencies := r.Group("admin/agencies", h.principals.RequireOperator)
+	{
+		agencies.GET("/", h.agency.GetAll)
+		agencies.POST("/", h.agency.Create)
+		agencies.GET("/:slug", h.agency.GetBySlug)
+		agencies.PUT("/:slug/settings", h.agency.UpdateSettings)
+		agencies.GET("/:slug/breeds", h.agency.GetBreedOverrides)
+		agencies.PUT("/:slug/breeds/:breed_id", h.agency.SetBreedOverride)
+		agencies.DELETE("/:slug/breeds/:breed_id", h.agency.DeleteBreedOverride)
 	}
 
 	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%d", h.config.Port),
-		Handler: r,
+		Addr:         fmt.Sprintf(":%d", h.config.Port),
+		Handler:      r,
+		ReadTimeout:  h.config.HTTPReadTimeout,
+		WriteTimeout: h.config.HTTPWriteTimeout,
+		IdleTimeout:  h.config.HTTPIdleTimeout,
 	}
 
+	serverErr := make(chan error, 1)
 	go func() {
+		logrus.Infof("Gin server listening on %s", srv.Addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logrus.Errorf("Gin server error: %s", err)
+			serverErr <- err
 		}
 	}()
 
-	<-ctx.Done()
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("gin server: %w", err)
+	case <-ctx.Done():
+	}
 
 	logrus.Info("Shutting down Gin server...")
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), h.config.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		logrus.Errorf("Gin server forced to shut down: %s", err)
+		return err
 	}
+
+	return nil
 }
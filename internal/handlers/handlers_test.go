@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/keyring"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/principal"
+	"github.com/mksmstpck/spy_cat_agency/internal/services"
+	"github.com/mksmstpck/spy_cat_agency/internal/storage"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+)
+
+var testAgency = models.Agency{ID: uuid.MustParse("6f1c2a9e-0b7d-4d3a-9a51-2f0e8c4b7d10"), Slug: "test"}
+
+const testTime = "2026-01-02 03:04:05+00"
+
+var (
+	missionColumns = []column{
+		{"id", uuidOID}, {"title", textOID}, {"description", textOID}, {"assigned_cat_id", uuidOID},
+		{"completed", boolOID}, {"classification", textOID}, {"scheduled_start", dateOID},
+		{"scheduled_end", dateOID}, {"created_at", timestamptzOID}, {"updated_at", timestamptzOID},
+	}
+	targetColumns = []column{
+		{"id", uuidOID}, {"mission_id", uuidOID}, {"name", textOID}, {"country", textOID},
+		{"latitude", float8OID}, {"longitude", float8OID}, {"city", textOID}, {"address", textOID},
+		{"notes", textOID}, {"completed", boolOID}, {"classification", textOID},
+		{"created_at", timestamptzOID}, {"updated_at", timestamptzOID},
+	}
+)
+
+// missionRow answers the lookup of a live mission with one at level.
+func missionRow(id string, level models.Classification) reply {
+	return reply{
+		match:   "SELECT id, title, description",
+		columns: missionColumns,
+		rows:    [][]any{{id, "Operation Yarn", nil, nil, false, level, nil, nil, testTime, testTime}},
+	}
+}
+
+// targetRow answers queries containing match with one live target at level.
+func targetRow(match, id, missionID string, level models.Classification) reply {
+	return reply{
+		match:   match,
+		columns: targetColumns,
+		rows:    [][]any{{id, missionID, "Mr. Whiskers", "AT", nil, nil, "", "", "", false, level, testTime, testTime}},
+	}
+}
+
+func testServices(t *testing.T, pool *pgxpool.Pool) *services.Services {
+	t.Helper()
+	cfg := config.Default()
+	cfg.StorageDir = t.TempDir()
+
+	notes := testNotes(t)
+	index, err := keyring.NewIndex("wxRysNCcRuz0VRh734lFEHNjdNbtI9JrkTUrL4yGf5U=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs, err := storage.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return services.NewServices(*db.NewDB(pool, notes, index), cfg, blobs)
+}
+
+// testNotes is the keyring testServices seals target notes with.
+func testNotes(t *testing.T) *keyring.Keyring {
+	t.Helper()
+	notes, err := keyring.Parse([]string{"test:OpILpyRQhXwVnQXx/hf3S3LspYzzZDvnaPMujshesNQ="}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return notes
+}
+
+// testRouter serves the routes register adds for the test agency, acting as
+// p: what Scope and Identify settle before a handler runs.
+func testRouter(p models.Principal, register func(r gin.IRoutes)) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		ctx := tenant.WithAgency(c.Request.Context(), testAgency)
+		c.Request = c.Request.WithContext(principal.With(ctx, p))
+		c.Next()
+	})
+	register(r)
+	return r
+}
+
+func serve(r http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// problemOf decodes a problem+json response with the given status.
+func problemOf(t *testing.T, w *httptest.ResponseRecorder, status int) Problem {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("got status %d, want %d: %s", w.Code, status, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != problemContentType {
+		t.Fatalf("got content type %q, want %q", ct, problemContentType)
+	}
+	var p Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != status {
+		t.Errorf("problem says status %d, want %d", p.Status, status)
+	}
+	return p
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/services"
+)
+
+type health struct {
+	config   config.Config
+	services *services.Services
+}
+
+func newHealth(
+	config config.Config,
+	services *services.Services,
+) *health {
+	return &health{
+		config:   config,
+		services: services,
+	}
+}
+
+// Live only says the process is up and serving HTTP; it never touches the database.
+func (h *health) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, models.HealthReport{Status: models.HealthOK})
+}
+
+func (h *health) Ready(c *gin.Context) {
+	writeReport(c, h.services.Health.Readiness(c.Request.Context()))
+}
+
+func (h *health) Startup(c *gin.Context) {
+	writeReport(c, h.services.Health.Startup())
+}
+
+func writeReport(c *gin.Context, report models.HealthReport) {
+	status := http.StatusOK
+	if report.Status != models.HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
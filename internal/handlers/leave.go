@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/services"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
+)
+
+// defaultCalendarDays is the calendar window when no "to" is given.
+const defaultCalendarDays = 30
+
+type leave struct {
+	config   config.Config
+	services *services.Services
+}
+
+func newLeave(
+	config config.Config,
+	services *services.Services,
+) *leave {
+	return &leave{
+		config:   config,
+		services: services,
+	}
+}
+
+type leaveInput struct {
+	Kind     string `json:"kind" binding:"required"`
+	StartsOn string `json:"starts_on" binding:"required"`
+	EndsOn   string `json:"ends_on" binding:"required"`
+	Note     string `json:"note"`
+}
+
+// parseDate reads a YYYY-MM-DD day from the body field; an empty value is
+// nil.
+func parseDate(r *validate.Report, field, value string) *time.Time {
+	day, err := parseDay(value)
+	if err != nil {
+		r.Add(validate.Pointer(field), "%s", err)
+	}
+	return day
+}
+
+// queryDate reads a YYYY-MM-DD day from the query parameter.
+func queryDate(c *gin.Context, r *validate.Report, name string) *time.Time {
+	day, err := parseDay(c.Query(name))
+	if err != nil {
+		r.AddParam(name, "%s", err)
+	}
+	return day
+}
+
+func parseDay(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a YYYY-MM-DD date", value)
+	}
+	return &day, nil
+}
+
+func parseCatID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "cat ID must be a valid UUID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *leave) GetByCat(c *gin.Context) {
+	catID, ok := parseCatID(c)
+	if !ok {
+		return
+	}
+
+	leaves, err := h.services.Leave.GetByCat(c.Request.Context(), catID)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Cat not found")
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve leave")
+		return
+	}
+
+	c.JSON(http.StatusOK, leaves)
+}
+
+func (h *leave) Create(c *gin.Context) {
+	catID, ok := parseCatID(c)
+	if !ok {
+		return
+	}
+
+	var input leaveInput
+	var r validate.Report
+	if !bind(c, &input, &r) {
+		return
+	}
+
+	startsOn := parseDate(&r, "starts_on", input.StartsOn)
+	endsOn := parseDate(&r, "ends_on", input.EndsOn)
+	if err := r.Err(); err != nil {
+		abortError(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	created, err := h.services.Leave.Create(c.Request.Context(), models.Leave{
+		CatID:    catID,
+		Kind:     input.Kind,
+		StartsOn: *startsOn,
+		EndsOn:   *endsOn,
+		Note:     input.Note,
+	})
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Cat not found")
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to create leave")
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *leave) Delete(c *gin.Context) {
+	catID, ok := parseCatID(c)
+	if !ok {
+		return
+	}
+
+	leaveID, err := uuid.Parse(c.Param("leave_id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "leave ID must be a valid UUID")
+		return
+	}
+
+	if err := h.services.Leave.Delete(c.Request.Context(), catID, leaveID); err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Leave not found")
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to delete leave")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// Calendar shows when a cat is busy between ?from and ?to (YYYY-MM-DD),
+// defaulting to the next 30 days.
+func (h *leave) Calendar(c *gin.Context) {
+	catID, ok := parseCatID(c)
+	if !ok {
+		return
+	}
+
+	var r validate.Report
+	from := queryDate(c, &r, "from")
+	to := queryDate(c, &r, "to")
+	if err := r.Err(); err != nil {
+		abortError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if from == nil {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		from = &today
+	}
+	if to == nil {
+		end := from.AddDate(0, 0, defaultCalendarDays)
+		to = &end
+	}
+
+	calendar, err := h.services.Leave.Calendar(c.Request.Context(), catID, *from, *to)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Cat not found")
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusBadRequest, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to build availability calendar")
+		return
+	}
+
+	c.JSON(http.StatusOK, calendar)
+}
@@ -2,38 +2,74 @@ package handlers
 
 import (
 	"bytes"
-	"fmt"
 	"io"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// maxCapturedBody bounds how much of a response is kept for the log, which
+// shows at most 1000 characters anyway; file downloads are never buffered.
+const maxCapturedBody = 8 << 10
+
+// requestIDKey holds the request ID in the gin context. It is also sent
+// back as X-Request-ID and quoted in problem responses.
+const requestIDKey = "request_id"
+
+// requestIDHeader carries the request ID both ways. An ID set by a proxy or
+// client is kept so their logs and ours can be joined.
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID bounds an incoming ID, which ends up in logs and headers.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
 type responseWriter struct {
 	gin.ResponseWriter
 	body *bytes.Buffer
 }
 
 func (w *responseWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	w.capture(b)
 	return w.ResponseWriter.Write(b)
 }
 
 func (w *responseWriter) WriteString(s string) (int, error) {
-	w.body.WriteString(s)
+	w.capture([]byte(s))
 	return w.ResponseWriter.WriteString(s)
 }
 
+func (w *responseWriter) capture(b []byte) {
+	if room := maxCapturedBody - w.body.Len(); room > 0 {
+		w.body.Write(b[:min(len(b), room)])
+	}
+}
+
 func RequestLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 
-		requestID := generateRequestID()
+		requestID := c.GetHeader(requestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = generateRequestID()
+		}
+		c.Set(requestIDKey, requestID)
+		c.Header(requestIDHeader, requestID)
+
+		log := logrus.WithField("request_id", requestID)
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+			log = log.WithField("trace_id", span.TraceID().String())
+		}
+		c.Request = c.Request.WithContext(logging.With(c.Request.Context(), log))
 
 		var requestBody string
-		if c.Request.Body != nil {
+		// Uploads are streamed by their handlers and never logged.
+		if c.Request.Body != nil && !isBinaryContentType(c.ContentType()) {
 			bodyBytes, err := io.ReadAll(c.Request.Body)
 			if err == nil {
 				requestBody = string(bodyBytes)
@@ -42,7 +78,7 @@ func RequestLogger() gin.HandlerFunc {
 			}
 		}
 
-		logRequestStart(c, requestID, requestBody)
+		logRequestStart(c, log, requestBody)
 
 		// Wrap response writer to capture response body
 		responseBuffer := &bytes.Buffer{}
@@ -59,13 +95,12 @@ func RequestLogger() gin.HandlerFunc {
 		duration := time.Since(start)
 
 		// Log response
-		logRequestComplete(c, requestID, responseBuffer.String(), duration)
+		logRequestComplete(c, log, responseBuffer.String(), duration)
 	}
 }
 
-func logRequestStart(c *gin.Context, requestID, requestBody string) {
+func logRequestStart(c *gin.Context, log *logrus.Entry, requestBody string) {
 	fields := logrus.Fields{
-		"request_id":     requestID,
 		"method":         c.Request.Method,
 		"path":           c.Request.URL.Path,
 		"query":          c.Request.URL.RawQuery,
@@ -91,12 +126,11 @@ func logRequestStart(c *gin.Context, requestID, requestBody string) {
 		fields["request_body"] = truncateAndSanitize(requestBody, 1000)
 	}
 
-	logrus.WithFields(fields).Info("HTTP request started")
+	log.WithFields(fields).Info("HTTP request started")
 }
 
-func logRequestComplete(c *gin.Context, requestID, responseBody string, duration time.Duration) {
+func logRequestComplete(c *gin.Context, log *logrus.Entry, responseBody string, duration time.Duration) {
 	fields := logrus.Fields{
-		"request_id":    requestID,
 		"method":        c.Request.Method,
 		"path":          c.Request.URL.Path,
 		"status_code":   c.Writer.Status(),
@@ -134,16 +168,32 @@ func logRequestComplete(c *gin.Context, requestID, responseBody string, duration
 	logLevel := getLogLevel(c.Writer.Status())
 	switch logLevel {
 	case logrus.ErrorLevel:
-		logrus.WithFields(fields).Error("HTTP request completed with error")
+		log.WithFields(fields).Error("HTTP request completed with error")
 	case logrus.WarnLevel:
-		logrus.WithFields(fields).Warn("HTTP request completed with warning")
+		log.WithFields(fields).Warn("HTTP request completed with warning")
 	default:
-		logrus.WithFields(fields).Info("HTTP request completed")
+		log.WithFields(fields).Info("HTTP request completed")
 	}
 }
 
+// generateRequestID returns a random UUID, unique across instances.
 func generateRequestID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+	return uuid.NewString()
+}
+
+// traced leaves scrapes and probes out of tracing; they would drown the
+// requests worth looking at.
+func traced(c *gin.Context) bool {
+	switch c.Request.URL.Path {
+	case "/metrics", "/healthz", "/readyz", "/startupz":
+		return false
+	}
+	return true
+}
+
+// logger returns the request-scoped logger of c.
+func logger(c *gin.Context) *logrus.Entry {
+	return logging.From(c.Request.Context())
 }
 
 func getLogLevel(statusCode int) logrus.Level {
@@ -283,22 +333,14 @@ func truncateAndSanitize(content string, maxLength int) string {
 	return content
 }
 
-func sanitizeSensitiveData(content string) string {
-	sensitivePatterns := map[string]string{
-		`"password":\s*"[^"]*"`:      `"password": "[REDACTED]"`,
-		`"token":\s*"[^"]*"`:         `"token": "[REDACTED]"`,
-		`"secret":\s*"[^"]*"`:        `"secret": "[REDACTED]"`,
-		`"api_key":\s*"[^"]*"`:       `"api_key": "[REDACTED]"`,
-		`"authorization":\s*"[^"]*"`: `"authorization": "[REDACTED]"`,
-	}
+// sensitiveFields matches JSON string fields whose values never reach the
+// log. Target notes hold intelligence; responses spell the key "Notes". A
+// value cut off by maxCapturedBody has no closing quote, so it matches up to
+// the end of the body.
+var sensitiveFields = regexp.MustCompile(`(?i)"(password|token|secret|api_key|authorization|notes)"\s*:\s*"(?:[^"\\]|\\.)*(?:"|\\?$)`)
 
-	result := content
-	for pattern, replacement := range sensitivePatterns {
-		// Note: In a production environment, you might want to use compiled regex for better performance
-		result = strings.ReplaceAll(result, pattern, replacement)
-	}
-
-	return result
+func sanitizeSensitiveData(content string) string {
+	return sensitiveFields.ReplaceAllString(content, `"$1": "[REDACTED]"`)
 }
 
 func RequestLoggerLite() gin.HandlerFunc {
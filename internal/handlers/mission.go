@@ -9,7 +9,7 @@ import (
 	"github.com/mksmstpck/spy_cat_agency/internal/config"
 	"github.com/mksmstpck/spy_cat_agency/internal/models"
 	"github.com/mksmstpck/spy_cat_agency/internal/services"
-	"github.com/sirupsen/logrus"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
 )
 
 type mission struct {
@@ -28,144 +28,246 @@ func newMission(
 }
 
 type missionInput struct {
-	Title         string        `json:"title" binding:"required"`
-	Description   *string       `json:"description,omitempty"`
-	AssignedCatID *uuid.UUID    `json:"assigned_cat_id,omitempty"`
-	Targets       []targetInput `json:"targets" binding:"required,min=1,max=3,dive"`
+	Title          string     `json:"title" binding:"required"`
+	Description    *string    `json:"description,omitempty"`
+	AssignedCatID  *uuid.UUID `json:"assigned_cat_id,omitempty"`
+	ScheduledStart string     `json:"scheduled_start,omitempty"`
+	ScheduledEnd   string     `json:"scheduled_end,omitempty"`
+	// Classification defaults to unclassified; targets default to it.
+	Classification models.Classification `json:"classification,omitempty"`
+	Targets        []targetInput         `json:"targets" binding:"required,min=1,max=3,dive"`
 }
 
 type targetInput struct {
-	Name    string `json:"name" binding:"required"`
-	Country string `json:"country" binding:"required"`
-	Notes   string `json:"notes"`
+	Name           string                  `json:"name" binding:"required"`
+	Country        string                  `json:"country" binding:"required"`
+	Latitude       *float64                `json:"latitude"`
+	Longitude      *float64                `json:"longitude"`
+	City           string                  `json:"city"`
+	Address        string                  `json:"address"`
+	Notes          string                  `json:"notes"`
+	Classification models.Classification   `json:"classification"`
+	RequiredSkills []skillRequirementInput `json:"required_skills" binding:"dive"`
 }
 
-func (input *missionInput) Validate() error {
-	if strings.TrimSpace(input.Title) == "" {
-		return &ValidationError{Field: "title", Message: "title cannot be empty"}
+func (h *mission) Create(c *gin.Context) {
+	var missionCreate missionInput
+	var r validate.Report
+	if !bind(c, &missionCreate, &r) {
+		return
 	}
 
-	if len(input.Targets) < 1 || len(input.Targets) > 3 {
-		return &ValidationError{Field: "targets", Message: "mission must have between 1 and 3 targets"}
+	mission := models.Mission{
+		Title:          missionCreate.Title,
+		Description:    missionCreate.Description,
+		AssignedCatID:  missionCreate.AssignedCatID,
+		ScheduledStart: parseDate(&r, "scheduled_start", missionCreate.ScheduledStart),
+		ScheduledEnd:   parseDate(&r, "scheduled_end", missionCreate.ScheduledEnd),
+		Classification: missionCreate.Classification,
 	}
 
-	for i, target := range input.Targets {
-		if strings.TrimSpace(target.Name) == "" {
-			return &ValidationError{Field: "targets", Message: "target name cannot be empty", Index: &i}
+	targets := make([]models.Target, len(missionCreate.Targets))
+	for i, targetInput := range missionCreate.Targets {
+		targets[i] = models.Target{
+			Name:           targetInput.Name,
+			Country:        targetInput.Country,
+			Latitude:       targetInput.Latitude,
+			Longitude:      targetInput.Longitude,
+			City:           targetInput.City,
+			Address:        targetInput.Address,
+			Notes:          targetInput.Notes,
+			Classification: targetInput.Classification,
+			RequiredSkills: requirementsFromInput(targetInput.RequiredSkills),
 		}
-		if strings.TrimSpace(target.Country) == "" {
-			return &ValidationError{Field: "targets", Message: "target country cannot be empty", Index: &i}
+	}
+
+	r.Merge(validate.Mission(&mission, targets))
+	if err := r.Err(); err != nil {
+		logger(c).Error(err)
+		abortError(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	createdMission, err := h.services.Mission.Create(c.Request.Context(), mission, targets, actor(c))
+	if err != nil {
+		logger(c).Error(err)
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
 		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to create mission")
+		return
 	}
 
-	return nil
+	d := newDisclosure(c)
+	d.mission(createdMission)
+	d.send(c, h.services, http.StatusCreated, createdMission)
 }
 
-func (h *mission) Create(c *gin.Context) {
-	var missionCreate missionInput
+// missionFromTemplateInput overrides what a template suggests; anything left
+// out is taken from the template. Targets[i] applies to the i-th placeholder
+// and entries past the last placeholder add targets.
+type missionFromTemplateInput struct {
+	Title          *string               `json:"title,omitempty"`
+	Description    *string               `json:"description,omitempty"`
+	AssignedCatID  *uuid.UUID            `json:"assigned_cat_id,omitempty"`
+	ScheduledStart string                `json:"scheduled_start,omitempty"`
+	ScheduledEnd   string                `json:"scheduled_end,omitempty"`
+	Classification models.Classification `json:"classification,omitempty"`
+	Targets        []targetOverrideInput `json:"targets" binding:"dive"`
+}
 
-	if err := c.ShouldBindJSON(&missionCreate); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
-		return
+// targetOverrideInput changes the fields that are present. Leaving out
+// required_skills keeps the suggested skills; omit drops the placeholder.
+type targetOverrideInput struct {
+	Name           *string                 `json:"name"`
+	Country        *string                 `json:"country"`
+	Latitude       *float64                `json:"latitude"`
+	Longitude      *float64                `json:"longitude"`
+	City           *string                 `json:"city"`
+	Address        *string                 `json:"address"`
+	Notes          *string                 `json:"notes"`
+	RequiredSkills []skillRequirementInput `json:"required_skills" binding:"dive"`
+	Omit           bool                    `json:"omit"`
+}
+
+// check reports overrides that are wrong whatever the template holds. The
+// mission they produce is validated like any other.
+func (input *missionFromTemplateInput) check(r *validate.Report) {
+	for i, target := range input.Targets {
+		at := validate.Pointer("targets", i)
+		if target.Country != nil {
+			if code, ok := r.Country(at+validate.Pointer("country"), *target.Country); ok {
+				input.Targets[i].Country = &code
+			}
+		}
+		if target.Latitude != nil || target.Longitude != nil {
+			r.Coordinates(at, target.Latitude, target.Longitude)
+		}
 	}
+}
 
-	if err := missionCreate.Validate(); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
-			"error":   "Validation failed",
-			"details": err.Error(),
-		})
+// CreateFromTemplate creates a mission from the template in the path. The
+// body is optional.
+func (h *mission) CreateFromTemplate(c *gin.Context) {
+	templateID, ok := parseTemplateID(c)
+	if !ok {
 		return
 	}
 
-	mission := models.Mission{
-		Title:         strings.TrimSpace(missionCreate.Title),
-		Description:   missionCreate.Description,
-		AssignedCatID: missionCreate.AssignedCatID,
+	// The body is optional: without one the template is used as it is.
+	var input missionFromTemplateInput
+	var r validate.Report
+	if c.Request.ContentLength != 0 && !bind(c, &input, &r) {
+		return
+	}
+	input.check(&r)
+
+	overrides := models.MissionOverrides{
+		Title:          input.Title,
+		Description:    input.Description,
+		AssignedCatID:  input.AssignedCatID,
+		ScheduledStart: parseDate(&r, "scheduled_start", input.ScheduledStart),
+		ScheduledEnd:   parseDate(&r, "scheduled_end", input.ScheduledEnd),
+		Classification: input.Classification,
+		Targets:        make([]models.TargetOverride, len(input.Targets)),
+	}
+	if err := r.Err(); err != nil {
+		logger(c).Error(err)
+		abortError(c, http.StatusUnprocessableEntity, err)
+		return
 	}
 
-	targets := make([]models.Target, len(missionCreate.Targets))
-	for i, targetInput := range missionCreate.Targets {
-		targets[i] = models.Target{
-			Name:    strings.TrimSpace(targetInput.Name),
-			Country: strings.TrimSpace(targetInput.Country),
-			Notes:   targetInput.Notes,
+	for i, target := range input.Targets {
+		overrides.Targets[i] = models.TargetOverride{
+			Name:      target.Name,
+			Country:   target.Country,
+			Notes:     target.Notes,
+			Latitude:  target.Latitude,
+			Longitude: target.Longitude,
+			City:      target.City,
+			Address:   target.Address,
+			Omit:      target.Omit,
+		}
+		if target.RequiredSkills != nil {
+			overrides.Targets[i].RequiredSkills = requirementsFromInput(target.RequiredSkills)
 		}
 	}
 
-	createdMission, err := h.services.Mission.Create(c.Request.Context(), mission, targets)
+	createdMission, err := h.services.Mission.CreateFromTemplate(c.Request.Context(), templateID, overrides, actor(c))
 	if err != nil {
-		logrus.Error(err)
+		logger(c).Error(err)
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		if isNotFoundError(err) {
+			abortError(c, http.StatusNotFound, err)
+			return
+		}
+		if invalid, ok := validate.As(err); ok {
+			abortInvalid(c, invalid)
+			return
+		}
 		if isBusinessLogicError(err) {
-			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
-				"error":   "Business rule violation",
-				"details": err.Error(),
-			})
+			abortError(c, http.StatusConflict, err)
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create mission",
-			"details": "Internal server error",
-		})
+		abortProblem(c, http.StatusInternalServerError, "Failed to create mission")
 		return
 	}
 
-	c.JSON(http.StatusCreated, createdMission)
+	d := newDisclosure(c)
+	d.mission(createdMission)
+	d.send(c, h.services, http.StatusCreated, createdMission)
 }
 
 func (h *mission) GetByID(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Mission ID is required",
-		})
+		abortProblem(c, http.StatusBadRequest, "Mission ID is required")
 		return
 	}
 
 	newID, err := uuid.Parse(id)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid mission ID format",
-			"details": "ID must be a valid UUID",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "mission ID must be a valid UUID")
 		return
 	}
 
 	mission, err := h.services.Mission.GetByID(c.Request.Context(), newID)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve mission",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve mission")
 		return
 	}
 
 	if mission == nil {
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
-			"error": "Mission not found",
-		})
+		abortProblem(c, http.StatusNotFound, "Mission not found")
 		return
 	}
 
-	c.JSON(http.StatusOK, mission)
+	d := newDisclosure(c)
+	d.mission(mission)
+	d.send(c, h.services, http.StatusOK, mission)
 }
 
 func (h *mission) GetAll(c *gin.Context) {
 	missions, err := h.services.Mission.GetAll(c.Request.Context())
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve missions",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve missions")
 		return
 	}
 
-	c.JSON(http.StatusOK, missions)
+	d := newDisclosure(c)
+	d.missions(missions)
+	d.send(c, h.services, http.StatusOK, missions)
 }
 
 type missionUpdate struct {
@@ -175,45 +277,89 @@ type missionUpdate struct {
 func (h *mission) UpdateCompleted(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Mission ID is required",
-		})
+		abortProblem(c, http.StatusBadRequest, "Mission ID is required")
 		return
 	}
 
 	newID, err := uuid.Parse(id)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid mission ID format",
-			"details": "ID must be a valid UUID",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "mission ID must be a valid UUID")
 		return
 	}
 
 	var missionUpdate missionUpdate
 	if err := c.ShouldBindJSON(&missionUpdate); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": "completed field is required and must be a boolean",
-		})
+		logger(c).Error(err)
+		abortBinding(c, err)
 		return
 	}
 
 	err = h.services.Mission.UpdateCompleted(c.Request.Context(), newID, *missionUpdate.Completed)
 	if err != nil {
-		logrus.Error(err)
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Mission not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
 		if isBusinessLogicError(err) {
-			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
-				"error":   "Business rule violation",
-				"details": err.Error(),
-			})
+			abortError(c, http.StatusConflict, err)
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update mission",
-		})
+		abortProblem(c, http.StatusInternalServerError, "Failed to update mission")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+type scheduleInput struct {
+	ScheduledStart string `json:"scheduled_start"`
+	ScheduledEnd   string `json:"scheduled_end"`
+}
+
+// UpdateSchedule sets the days a mission runs; empty values clear a bound.
+func (h *mission) UpdateSchedule(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "mission ID must be a valid UUID")
+		return
+	}
+
+	var input scheduleInput
+	var r validate.Report
+	if !bind(c, &input, &r) {
+		return
+	}
+
+	start := parseDate(&r, "scheduled_start", input.ScheduledStart)
+	end := parseDate(&r, "scheduled_end", input.ScheduledEnd)
+	if err := r.Err(); err != nil {
+		abortError(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	err = h.services.Mission.UpdateSchedule(c.Request.Context(), newID, start, end)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Mission not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to update mission schedule")
 		return
 	}
 
@@ -221,89 +367,306 @@ func (h *mission) UpdateCompleted(c *gin.Context) {
 }
 
 type assignCatInput struct {
-	CatID *uuid.UUID `json:"cat_id"`
+	CatID  *uuid.UUID `json:"cat_id"`
+	Reason string     `json:"reason"`
 }
 
 func (h *mission) AssignCat(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Mission ID is required",
-		})
+		abortProblem(c, http.StatusBadRequest, "Mission ID is required")
 		return
 	}
 
 	newID, err := uuid.Parse(id)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid mission ID format",
-			"details": "ID must be a valid UUID",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "mission ID must be a valid UUID")
 		return
 	}
 
 	var assignInput assignCatInput
 	if err := c.ShouldBindJSON(&assignInput); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
+		logger(c).Error(err)
+		abortBinding(c, err)
+		return
+	}
+
+	coverage, err := h.services.Mission.UpdateAssignedCat(c.Request.Context(), newID, assignInput.CatID, actor(c), assignInput.Reason)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Mission not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to assign cat to mission")
+		return
+	}
+
+	if coverage != nil && !coverage.Complete {
+		c.JSON(http.StatusOK, gin.H{
+			"warnings": []string{"cat lacks some skills required by the mission's targets"},
+			"coverage": coverage,
 		})
 		return
 	}
 
-	err = h.services.Mission.UpdateAssignedCat(c.Request.Context(), newID, assignInput.CatID)
+	c.JSON(http.StatusNoContent, nil)
+}
+
+type assignmentInput struct {
+	CatID uuid.UUID `json:"cat_id" binding:"required"`
+	Role  string    `json:"role" binding:"required"`
+}
+
+// GetAssignments lists the cats currently on the mission, or with
+// ?history=true everyone who has been on it.
+func (h *mission) GetAssignments(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "mission ID must be a valid UUID")
+		return
+	}
+
+	assignments, err := h.services.Mission.GetAssignments(c.Request.Context(), newID, c.Query("history") == "true")
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Mission not found")
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve mission assignments")
+		return
+	}
+
+	c.JSON(http.StatusOK, assignments)
+}
+
+// Assign adds a cat to the mission as lead, support or backup.
+func (h *mission) Assign(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		logrus.Error(err)
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "mission ID must be a valid UUID")
+		return
+	}
+
+	var input assignmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger(c).Error(err)
+		abortBinding(c, err)
+		return
+	}
+
+	assignment, coverage, err := h.services.Mission.Assign(c.Request.Context(), newID, input.CatID, strings.ToLower(strings.TrimSpace(input.Role)), actor(c))
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Mission not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
 		if isBusinessLogicError(err) {
-			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
-				"error":   "Business rule violation",
-				"details": err.Error(),
-			})
+			abortError(c, http.StatusConflict, err)
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to assign cat to mission",
+		abortProblem(c, http.StatusInternalServerError, "Failed to assign cat to mission")
+		return
+	}
+
+	if coverage != nil && !coverage.Complete {
+		c.JSON(http.StatusCreated, gin.H{
+			"assignment": assignment,
+			"warnings":   []string{"cat lacks some skills required by the mission's targets"},
+			"coverage":   coverage,
 		})
 		return
 	}
 
+	c.JSON(http.StatusCreated, assignment)
+}
+
+// Unassign takes a cat off the mission; ?reason= is kept in its history.
+func (h *mission) Unassign(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "mission ID must be a valid UUID")
+		return
+	}
+
+	catID, err := uuid.Parse(c.Param("cat_id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "cat ID must be a valid UUID")
+		return
+	}
+
+	if err := h.services.Mission.Unassign(c.Request.Context(), newID, catID, actor(c), c.Query("reason")); err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Cat is not assigned to this mission")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to unassign cat from mission")
+		return
+	}
+
 	c.JSON(http.StatusNoContent, nil)
 }
 
 func (h *mission) Delete(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Mission ID is required",
-		})
+		abortProblem(c, http.StatusBadRequest, "Mission ID is required")
 		return
 	}
 
 	newID, err := uuid.Parse(id)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid mission ID format",
-			"details": "ID must be a valid UUID",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "mission ID must be a valid UUID")
 		return
 	}
 
 	err = h.services.Mission.Delete(c.Request.Context(), newID)
 	if err != nil {
-		logrus.Error(err)
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Mission not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
 		if isBusinessLogicError(err) {
-			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
-				"error":   "Business rule violation",
-				"details": err.Error(),
-			})
+			abortError(c, http.StatusConflict, err)
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete mission",
-		})
+		abortProblem(c, http.StatusInternalServerError, "Failed to delete mission")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *mission) Restore(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "mission ID must be a valid UUID")
+		return
+	}
+
+	mission, err := h.services.Mission.Restore(c.Request.Context(), newID)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Deleted mission not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to restore mission")
+		return
+	}
+
+	d := newDisclosure(c)
+	d.mission(mission)
+	d.send(c, h.services, http.StatusOK, mission)
+}
+
+// Route orders the mission's targets by travel distance, starting from
+// ?from_lat= and ?from_lon= when given.
+func (h *mission) Route(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "mission ID must be a valid UUID")
+		return
+	}
+
+	var r validate.Report
+	fromLat := queryFloat(c, &r, "from_lat")
+	fromLon := queryFloat(c, &r, "from_lon")
+	if r.Err() == nil {
+		r.CoordinateParams("from_lat", "from_lon", fromLat, fromLon)
+	}
+	if err := r.Err(); err != nil {
+		abortError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	route, err := h.services.Mission.Route(c.Request.Context(), newID, fromLat, fromLon)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Mission not found")
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to compute mission route")
+		return
+	}
+
+	c.JSON(http.StatusOK, route)
+}
+
+// UpdateClassification changes the mission's level. The caller must be cleared for
+// the old and the new level.
+func (h *mission) UpdateClassification(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "mission ID must be a valid UUID")
+		return
+	}
+
+	var input classificationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger(c).Error(err)
+		abortBinding(c, err)
+		return
+	}
+
+	err = h.services.Mission.UpdateClassification(c.Request.Context(), newID, input.Classification)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Mission not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to update mission classification")
 		return
 	}
 
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+const missionID = "3c9d8f7e-52a4-4b1e-8f0c-6d2a1b9e4f37"
+
+func TestDeleteMissionRefusedByTriggerIsConflict(t *testing.T) {
+	const refusal = "Cannot delete mission 1f0e: assigned to cat 9a51"
+	pg, pool := newFakePostgres(t,
+		reply{match: "UPDATE missions SET deleted_at", code: "P0001", message: refusal},
+		missionRow(missionID, models.Unclassified),
+	)
+	h := newMission(config.Default(), testServices(t, pool))
+	r := testRouter(models.Principal{Name: "handler", Clearance: models.TopSecret}, func(r gin.IRoutes) {
+		r.DELETE("/mission/:id", h.Delete)
+	})
+
+	w := serve(r, http.MethodDelete, "/mission/"+missionID, "")
+
+	p := problemOf(t, w, http.StatusConflict)
+	if p.Type != "/problems/business-rule" || p.Detail != refusal {
+		t.Errorf("got %+v, want the trigger's refusal as a business rule problem", p)
+	}
+	if !pg.ran("UPDATE missions SET deleted_at") {
+		t.Error("the mission was never deleted")
+	}
+}
+
+func TestDeleteMissingMissionIsNotFound(t *testing.T) {
+	// Found, then deleted by someone else before this delete ran.
+	_, pool := newFakePostgres(t,
+		reply{match: "UPDATE missions SET deleted_at", tag: "UPDATE 0"},
+		missionRow(missionID, models.Unclassified),
+	)
+	h := newMission(config.Default(), testServices(t, pool))
+	r := testRouter(models.Principal{Name: "handler", Clearance: models.TopSecret}, func(r gin.IRoutes) {
+		r.DELETE("/mission/:id", h.Delete)
+	})
+
+	w := serve(r, http.MethodDelete, "/mission/"+missionID, "")
+
+	problemOf(t, w, http.StatusNotFound)
+}
+
+func TestRemovingLeadOfMissingMissionIsNotFound(t *testing.T) {
+	// No reply: the mission lookup finds no row.
+	pg, pool := newFakePostgres(t)
+	h := newMission(config.Default(), testServices(t, pool))
+	r := testRouter(models.Principal{Name: "handler", Clearance: models.TopSecret}, func(r gin.IRoutes) {
+		r.PUT("/mission/:id/assign", h.AssignCat)
+	})
+
+	w := serve(r, http.MethodPut, "/mission/"+missionID+"/assign", `{"cat_id": null}`)
+
+	problemOf(t, w, http.StatusNotFound)
+	if pg.ran("UPDATE mission_assignments") {
+		t.Error("assignments of a missing mission were closed")
+	}
+}
+
+func TestDeleteMissionWithTargetAboveClearanceIsForbidden(t *testing.T) {
+	pg, pool := newFakePostgres(t,
+		missionRow(missionID, models.Confidential),
+		targetRow("WHERE t.mission_id", "8d2e4b6a-1c3f-4e5d-9a7b-0f1e2d3c4b5a", missionID, models.TopSecret),
+	)
+	h := newMission(config.Default(), testServices(t, pool))
+	r := testRouter(models.Principal{Name: "handler", Clearance: models.Secret}, func(r gin.IRoutes) {
+		r.DELETE("/mission/:id", h.Delete)
+	})
+
+	w := serve(r, http.MethodDelete, "/mission/"+missionID, "")
+
+	problemOf(t, w, http.StatusForbidden)
+	if pg.ran("UPDATE missions SET deleted_at") {
+		t.Error("a mission holding a top secret target was deleted by a secret principal")
+	}
+}
+
+func TestRestoreMissionAboveClearanceIsForbidden(t *testing.T) {
+	pg, pool := newFakePostgres(t,
+		reply{
+			match:   "SELECT classification FROM missions",
+			columns: []column{{"classification", textOID}},
+			rows:    [][]any{{models.Unclassified}, {models.Secret}},
+		},
+	)
+	h := newMission(config.Default(), testServices(t, pool))
+	r := testRouter(models.Principal{Name: "handler", Clearance: models.Confidential}, func(r gin.IRoutes) {
+		r.POST("/mission/:id/restore", h.Restore)
+	})
+
+	w := serve(r, http.MethodPost, "/mission/"+missionID+"/restore", "")
+
+	problemOf(t, w, http.StatusForbidden)
+	if pg.ran("UPDATE missions SET deleted_at = NULL") {
+		t.Error("a mission bringing back a secret target was restored by a confidential principal")
+	}
+}
+
+func TestUpdateScheduleChecksLeave(t *testing.T) {
+	const catID = "5b7e1d3c-9f2a-4c8e-b6d4-1a3f5e7c9b20"
+	assignment := reply{
+		match: "FROM mission_assignments a",
+		columns: []column{
+			{"id", uuidOID}, {"mission_id", uuidOID}, {"cat_id", uuidOID}, {"cat_name", textOID},
+			{"role", textOID}, {"assigned_at", timestamptzOID}, {"assigned_by", textOID},
+			{"unassigned_at", timestamptzOID}, {"unassigned_by", textOID}, {"unassign_reason", textOID},
+		},
+		rows: [][]any{{"0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d", missionID, catID, "Tom", "lead", testTime, "handler", nil, nil, nil}},
+	}
+	onLeave := reply{
+		match: "FROM cat_leaves",
+		columns: []column{
+			{"id", uuidOID}, {"cat_id", uuidOID}, {"kind", textOID}, {"starts_on", dateOID},
+			{"ends_on", dateOID}, {"note", textOID}, {"created_at", timestamptzOID},
+		},
+		rows: [][]any{{"7c9e1a3b-5d7f-4b2c-9e4a-6c8e0a2b4d6f", catID, models.LeaveVacation, "2026-03-10", "2026-03-12", "", testTime}},
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		leave      bool
+		status     int
+		wantDetail string
+		askedLeave bool
+	}{
+		{
+			name: "end before start", body: `{"scheduled_start": "2026-03-09", "scheduled_end": "2026-03-01"}`,
+			status: http.StatusConflict, wantDetail: "scheduled end cannot be before scheduled start",
+		},
+		{
+			name: "cat on leave", body: `{"scheduled_start": "2026-03-01", "scheduled_end": "2026-03-10"}`, leave: true,
+			status: http.StatusConflict, askedLeave: true,
+			wantDetail: "Tom: cat is on vacation leave from 2026-03-10 to 2026-03-12 during the mission window",
+		},
+		{
+			name: "cat free", body: `{"scheduled_start": "2026-03-01", "scheduled_end": "2026-03-09"}`,
+			status: http.StatusNoContent, askedLeave: true,
+		},
+		{
+			name: "unscheduled", body: `{}`, leave: true,
+			status: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replies := []reply{missionRow(missionID, models.Unclassified), assignment}
+			if tt.leave {
+				replies = append(replies, onLeave)
+			}
+			pg, pool := newFakePostgres(t, replies...)
+			h := newMission(config.Default(), testServices(t, pool))
+			r := testRouter(models.Principal{Name: "handler", Clearance: models.Unclassified}, func(r gin.IRoutes) {
+				r.PUT("/mission/:id/schedule", h.UpdateSchedule)
+			})
+
+			w := serve(r, http.MethodPut, "/mission/"+missionID+"/schedule", tt.body)
+
+			if tt.status == http.StatusNoContent {
+				if w.Code != tt.status {
+					t.Fatalf("got status %d, want %d: %s", w.Code, tt.status, w.Body)
+				}
+			} else if p := problemOf(t, w, tt.status); p.Detail != tt.wantDetail {
+				t.Errorf("got detail %q, want %q", p.Detail, tt.wantDetail)
+			}
+			if pg.ran("FROM cat_leaves") != tt.askedLeave {
+				t.Errorf("leave looked up = %v, want %v", !tt.askedLeave, tt.askedLeave)
+			}
+			if moved := pg.ran("SET scheduled_start"); moved != (tt.status == http.StatusNoContent) {
+				t.Errorf("schedule moved = %v with status %d", moved, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// column is a result column: its name and type OID.
+type column struct {
+	name string
+	oid  uint32
+}
+
+// reply answers every query containing match, first match wins. With code
+// set it is an error; with columns it returns rows of text values, nil for
+// NULL; otherwise it completes with tag, such as "UPDATE 0".
+type reply struct {
+	match   string
+	code    string
+	message string
+	columns []column
+	rows    [][]any
+	tag     string
+}
+
+// fakePostgres speaks just enough of the wire protocol for pgx in simple
+// protocol mode, so handlers can run against the real services and
+// repositories and get the answers, and the trigger errors, a test scripts.
+// Queries no reply matches succeed without rows.
+type fakePostgres struct {
+	mu      sync.Mutex
+	replies []reply
+	queries []string
+}
+
+func newFakePostgres(t *testing.T, replies ...reply) (*fakePostgres, *pgxpool.Pool) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	pg := &fakePostgres{replies: replies}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go pg.serve(conn)
+		}
+	}()
+
+	url := fmt.Sprintf("postgres://sca@%s/sca?sslmode=disable&default_query_exec_mode=simple_protocol", l.Addr())
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without the types of a prepared statement, pgx needs telling how to
+	// send the id lists the repositories pass to = ANY.
+	config.AfterConnect = func(_ context.Context, conn *pgx.Conn) error {
+		conn.TypeMap().RegisterDefaultPgType([]uuid.UUID{}, "_uuid")
+		return nil
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pg, pool
+}
+
+// ran reports whether a query containing match was received.
+func (pg *fakePostgres) ran(match string) bool {
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+	for _, q := range pg.queries {
+		if strings.Contains(q, match) {
+			return true
+		}
+	}
+	return false
+}
+
+func (pg *fakePostgres) serve(conn net.Conn) {
+	defer conn.Close()
+	be := pgproto3.NewBackend(conn, conn)
+	if _, err := be.ReceiveStartupMessage(); err != nil {
+		return
+	}
+	be.Send(&pgproto3.AuthenticationOk{})
+	for _, p := range [][2]string{
+		{"server_version", "16.0"},
+		{"client_encoding", "UTF8"},
+		{"standard_conforming_strings", "on"},
+		{"DateStyle", "ISO, MDY"},
+		{"integer_datetimes", "on"},
+		{"TimeZone", "UTC"},
+	} {
+		be.Send(&pgproto3.ParameterStatus{Name: p[0], Value: p[1]})
+	}
+	be.Send(&pgproto3.BackendKeyData{ProcessID: 1, SecretKey: 1})
+	be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	if be.Flush() != nil {
+		return
+	}
+
+	status := byte('I')
+	for {
+		msg, err := be.Receive()
+		if err != nil {
+			return
+		}
+		q, ok := msg.(*pgproto3.Query)
+		if !ok {
+			return
+		}
+		status = pg.answer(be, q.String, status)
+		be.Send(&pgproto3.ReadyForQuery{TxStatus: status})
+		if be.Flush() != nil {
+			return
+		}
+	}
+}
+
+// answer sends the reply to one query and returns the transaction status
+// that follows it.
+func (pg *fakePostgres) answer(be *pgproto3.Backend, sql string, status byte) byte {
+	pg.mu.Lock()
+	pg.queries = append(pg.queries, sql)
+	var r *reply
+	for i := range pg.replies {
+		if strings.Contains(sql, pg.replies[i].match) {
+			r = &pg.replies[i]
+			break
+		}
+	}
+	pg.mu.Unlock()
+
+	verb := strings.ToUpper(strings.Fields(sql + " x")[0])
+	switch verb {
+	case "BEGIN":
+		be.Send(&pgproto3.CommandComplete{CommandTag: []byte("BEGIN")})
+		return 'T'
+	case "COMMIT", "ROLLBACK":
+		be.Send(&pgproto3.CommandComplete{CommandTag: []byte(verb)})
+		return 'I'
+	}
+
+	if r != nil && r.code != "" {
+		be.Send(&pgproto3.ErrorResponse{Severity: "ERROR", Code: r.code, Message: r.message})
+		if status == 'T' {
+			return 'E'
+		}
+		return status
+	}
+
+	if r != nil && r.columns != nil {
+		fields := make([]pgproto3.FieldDescription, len(r.columns))
+		for i, c := range r.columns {
+			fields[i] = pgproto3.FieldDescription{Name: []byte(c.name), DataTypeOID: c.oid, DataTypeSize: -1, TypeModifier: -1}
+		}
+		be.Send(&pgproto3.RowDescription{Fields: fields})
+		for _, row := range r.rows {
+			values := make([][]byte, len(row))
+			for i, v := range row {
+				if v != nil {
+					values[i] = []byte(fmt.Sprint(v))
+				}
+			}
+			be.Send(&pgproto3.DataRow{Values: values})
+		}
+		be.Send(&pgproto3.CommandComplete{CommandTag: []byte(fmt.Sprintf("SELECT %d", len(r.rows)))})
+		return status
+	}
+
+	tag := verb + " 1"
+	switch {
+	case r != nil && r.tag != "":
+		tag = r.tag
+	case verb == "SELECT", verb == "WITH":
+		tag = "SELECT 0"
+	case verb == "INSERT":
+		tag = "INSERT 0 1"
+	}
+	be.Send(&pgproto3.CommandComplete{CommandTag: []byte(tag)})
+	return status
+}
+
+// Type OIDs of the columns the replies use.
+const (
+	uuidOID        = pgtype.UUIDOID
+	textOID        = pgtype.TextOID
+	boolOID        = pgtype.BoolOID
+	float8OID      = pgtype.Float8OID
+	dateOID        = pgtype.DateOID
+	timestamptzOID = pgtype.TimestamptzOID
+)
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/principal"
+	"github.com/mksmstpck/spy_cat_agency/internal/services"
+)
+
+const (
+	// operatorKey holds the name of the operator a request authenticated as.
+	operatorKey = "operator"
+	// gatewayKey holds the slug of the agency whose gateway token a request
+	// carried.
+	gatewayKey = "gateway"
+)
+
+type principals struct {
+	config   config.Config
+	services *services.Services
+	// tokens are the operators' bearer tokens by name.
+	tokens map[string]string
+	// gateways are the gateways' bearer tokens by agency slug.
+	gateways map[string]string
+}
+
+func newPrincipals(
+	config config.Config,
+	services *services.Services,
+) *principals {
+	return &principals{
+		config:   config,
+		services: services,
+		tokens:   config.Operators(),
+		gateways: config.Gateways(),
+	}
+}
+
+// RequireOperator lets only requests with an operator's
+// "Authorization: Bearer" token through. They act as that operator,
+// whatever X-Actor says.
+func (h *principals) RequireOperator(c *gin.Context) {
+	token, ok := bearer(c, "operator credentials are required")
+	if !ok {
+		return
+	}
+	name, ok := holder(h.tokens, token)
+	if !ok {
+		abortUnauthenticated(c, "the bearer token is not valid")
+		return
+	}
+	c.Set(operatorKey, name)
+	c.Next()
+}
+
+// Authenticate lets through requests with a gateway's or an operator's
+// "Authorization: Bearer" token. A gateway token binds the request to its
+// agency and vouches for X-Actor; an operator acts as itself.
+func (h *principals) Authenticate(c *gin.Context) {
+	token, ok := bearer(c, "gateway or operator credentials are required")
+	if !ok {
+		return
+	}
+	if slug, ok := holder(h.gateways, token); ok {
+		c.Set(gatewayKey, slug)
+		c.Next()
+		return
+	}
+	if name, ok := holder(h.tokens, token); ok {
+		c.Set(operatorKey, name)
+		c.Next()
+		return
+	}
+	abortUnauthenticated(c, "the bearer token is not valid")
+}
+
+// bearer returns the request's bearer token, or ends the request with 401
+// and detail when there is none.
+func bearer(c *gin.Context, detail string) (string, bool) {
+	header := c.GetHeader("Authorization")
+	if header == "" {
+		abortUnauthenticated(c, detail)
+		return "", false
+	}
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		abortUnauthenticated(c, "the Authorization header must be a Bearer token")
+		return "", false
+	}
+	return token, true
+}
+
+// holder finds who holds token among tokens by holder, comparing every
+// token in constant time.
+func holder(tokens map[string]string, token string) (string, bool) {
+	got := sha256.Sum256([]byte(token))
+	var found string
+	for name, t := range tokens {
+		want := sha256.Sum256([]byte(t))
+		if subtle.ConstantTimeCompare(got[:], want[:]) == 1 {
+			found = name
+		}
+	}
+	return found, found != ""
+}
+
+func abortUnauthenticated(c *gin.Context, detail string) {
+	c.Header("WWW-Authenticate", `Bearer realm="sca"`)
+	abortProblem(c, http.StatusUnauthorized, detail)
+}
+
+// Identify makes the request act as its actor with the clearance the agency
+// granted it. It runs after the agency is resolved.
+func (h *principals) Identify(c *gin.Context) {
+	p, err := h.services.Principal.Resolve(c.Request.Context(), actor(c))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to resolve principal")
+		return
+	}
+
+	c.Request = c.Request.WithContext(principal.With(c.Request.Context(), p))
+	c.Next()
+}
+
+func (h *principals) GetAll(c *gin.Context) {
+	principals, err := h.services.Principal.GetAll(c.Request.Context())
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve principals")
+		return
+	}
+
+	c.JSON(http.StatusOK, principals)
+}
+
+func (h *principals) Set(c *gin.Context) {
+	var input clearanceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger(c).Error(err)
+		abortBinding(c, err)
+		return
+	}
+
+	p, err := h.services.Principal.Set(c.Request.Context(), c.Param("name"), input.Clearance)
+	if err != nil {
+		logger(c).Error(err)
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to set clearance")
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+func (h *principals) Delete(c *gin.Context) {
+	if err := h.services.Principal.Delete(c.Request.Context(), c.Param("name")); err != nil {
+		logger(c).Error(err)
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Principal not found")
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to delete principal")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// Audit lists the newest classified reads of the agency.
+func (h *principals) Audit(c *gin.Context) {
+	var limit int
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			abortProblem(c, http.StatusBadRequest, "limit must be an integer")
+			return
+		}
+	}
+
+	entries, err := h.services.Audit.GetAll(c.Request.Context(), limit)
+	if err != nil {
+		logger(c).Error(err)
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve audit trail")
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mksmstpck/spy_cat_agency/internal/principal"
+)
+
+func TestIdentifyBelievesXActorOnlyFromAGateway(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		actor string
+		want  string
+	}{
+		{"gateway", gatewayToken, "alice", "alice"},
+		{"gateway without actor", gatewayToken, "", "anonymous"},
+		{"operator", operatorToken, "alice", "root"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, pool := newFakePostgres(t, agencyRow)
+			r := credentialRouter(t, pool, func(c *gin.Context) {
+				c.String(http.StatusOK, principal.From(c.Request.Context()).Name)
+			})
+
+			w := serveAs(r, tt.token, map[string]string{"X-Agency": "mi6", "X-Actor": tt.actor})
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("got status %d, want 200: %s", w.Code, w.Body)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("acting as %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXActorWithoutCredentialsIsUnauthenticated(t *testing.T) {
+	pg, pool := newFakePostgres(t, agencyRow)
+	r := credentialRouter(t, pool, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := serveAs(r, "", map[string]string{"X-Agency": "mi6", "X-Actor": "director"})
+
+	problemOf(t, w, http.StatusUnauthorized)
+	if pg.ran("FROM principals") {
+		t.Error("a principal was resolved for an unauthenticated request")
+	}
+}
+
+func TestActorIgnoresXActorWithoutAGateway(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPut, "/mission/x/assign", nil)
+	c.Request.Header.Set("X-Actor", "director")
+
+	if got := actor(c); got != "anonymous" {
+		t.Errorf("got %q, want anonymous", got)
+	}
+	c.Set(gatewayKey, "mi6")
+	if got := actor(c); got != "director" {
+		t.Errorf("got %q behind a gateway, want director", got)
+	}
+}
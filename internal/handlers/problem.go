@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
+)
+
+// problemContentType is the media type of every error response.
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 error body. Type identifies the kind of problem
+// and Title names it; both are the same for every occurrence. Detail says
+// what went wrong this time, and Errors lists the offending fields.
+type Problem struct {
+	Type      string          `json:"type"`
+	Title     string          `json:"title"`
+	Status    int             `json:"status"`
+	Detail    string          `json:"detail,omitempty"`
+	Instance  string          `json:"instance,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	Errors    validate.Errors `json:"errors,omitempty"`
+}
+
+type problemType struct {
+	slug  string
+	title string
+}
+
+// problemTypes maps the statuses the API answers with to their problem
+// type. The type URI is /problems/<slug>, documented in the README.
+var problemTypes = map[int]problemType{
+	http.StatusBadRequest:            {"invalid-request", "Invalid request"},
+	http.StatusUnauthorized:          {"unauthenticated", "Authentication required"},
+	http.StatusForbidden:             {"not-cleared", "Not cleared"},
+	http.StatusNotFound:              {"not-found", "Not found"},
+	http.StatusMethodNotAllowed:      {"method-not-allowed", "Method not allowed"},
+	http.StatusConflict:              {"business-rule", "Business rule violation"},
+	http.StatusRequestEntityTooLarge: {"too-large", "Payload too large"},
+	http.StatusUnsupportedMediaType:  {"unsupported-media-type", "Unsupported media type"},
+	http.StatusUnprocessableEntity:   {"validation", "Validation failed"},
+	http.StatusInternalServerError:   {"internal", "Internal server error"},
+}
+
+func newProblem(c *gin.Context, status int, detail string, violations ...validate.Violation) Problem {
+	t, ok := problemTypes[status]
+	if !ok {
+		t = problemType{"internal", http.StatusText(status)}
+	}
+	return Problem{
+		Type:      "/problems/" + t.slug,
+		Title:     t.title,
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		RequestID: c.GetString(requestIDKey),
+		Errors:    violations,
+	}
+}
+
+// abortProblem ends the request with a problem+json body.
+func abortProblem(c *gin.Context, status int, detail string, violations ...validate.Violation) {
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(status, newProblem(c, status, detail, violations...))
+}
+
+// abortError ends the request with err as the detail. Violations in err
+// are listed field by field.
+func abortError(c *gin.Context, status int, err error) {
+	invalid, ok := validate.As(err)
+	if !ok {
+		abortProblem(c, status, err.Error())
+		return
+	}
+	detail := invalid[0].String()
+	if len(invalid) > 1 {
+		detail = fmt.Sprintf("%d fields are invalid", len(invalid))
+	}
+	abortProblem(c, status, detail, invalid...)
+}
+
+// abortInvalid ends the request with every violation found in the body.
+func abortInvalid(c *gin.Context, invalid validate.Errors) {
+	abortError(c, http.StatusUnprocessableEntity, invalid)
+}
+
+// bind decodes the JSON body into obj. Fields breaking the binding rules are
+// added to r, so they are reported together with the domain rules run on
+// the decoded input. A body that cannot be decoded at all ends the request.
+func bind(c *gin.Context, obj any, r *validate.Report) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+	logger(c).Error(err)
+	if invalid, ok := bindingViolations(err); ok {
+		r.Merge(invalid)
+		return true
+	}
+	abortBinding(c, err)
+	return false
+}
+
+// abortBinding ends the request for a body that failed to bind. The
+// messages name JSON fields, never the Go types behind them.
+func abortBinding(c *gin.Context, err error) {
+	var syntax *json.SyntaxError
+	if invalid, ok := bindingViolations(err); ok {
+		abortInvalid(c, invalid)
+		return
+	}
+	switch {
+	case errors.As(err, &syntax), errors.Is(err, io.ErrUnexpectedEOF):
+		abortProblem(c, http.StatusBadRequest, "request body is not valid JSON")
+	case errors.Is(err, io.EOF):
+		abortProblem(c, http.StatusBadRequest, "request body is required")
+	default:
+		abortProblem(c, http.StatusBadRequest, "request body could not be read")
+	}
+}
+
+// bindingViolations turns binding rule and JSON type errors into
+// violations. A type error stops decoding, so it is reported alone.
+func bindingViolations(err error) (validate.Errors, bool) {
+	var (
+		invalid   validator.ValidationErrors
+		typeError *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &invalid):
+		violations := make(validate.Errors, len(invalid))
+		for i, fe := range invalid {
+			violations[i] = validate.Violation{Pointer: bindingPointer(fe.Namespace()), Message: bindingMessage(fe)}
+		}
+		return violations, true
+	case errors.As(err, &typeError):
+		return validate.Errors{{
+			Pointer: "/" + strings.ReplaceAll(typeError.Field, ".", "/"),
+			Message: "must be " + jsonKind(typeError.Type),
+		}}, true
+	}
+	return nil, false
+}
+
+// bindingPointer turns a validator namespace such as
+// "missionInput.targets[0].name" into the JSON pointer /targets/0/name.
+func bindingPointer(namespace string) string {
+	_, path, _ := strings.Cut(namespace, ".")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	return "/" + strings.ReplaceAll(path, ".", "/")
+}
+
+func bindingMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return "must have at least " + fe.Param() + " item(s)"
+	case "max":
+		return "must have at most " + fe.Param() + " item(s)"
+	case "oneof":
+		return "must be one of " + fe.Param()
+	default:
+		return "failed the " + fe.Tag() + " check"
+	}
+}
+
+var textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// jsonKind names the JSON value a Go type is decoded from.
+func jsonKind(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshaler) {
+		return "a string"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return "a string"
+	}
+}
+
+// problemNoRoute answers unknown paths and methods like any other error.
+func problemNoRoute(c *gin.Context) {
+	abortProblem(c, http.StatusNotFound, "no route for "+c.Request.Method+" "+c.Request.URL.Path)
+}
+
+func problemNoMethod(c *gin.Context) {
+	abortProblem(c, http.StatusMethodNotAllowed, c.Request.Method+" is not allowed on "+c.Request.URL.Path)
+}
+
+// problemRecovery turns a panic into a 500 problem; gin logs the stack.
+func problemRecovery(c *gin.Context, _ any) {
+	abortProblem(c, http.StatusInternalServerError, "the request could not be completed")
+}
+
+func init() {
+	// Report JSON field names, not Go field names, in binding errors.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return f.Name
+			}
+			return name
+		})
+	}
+}
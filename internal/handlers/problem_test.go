@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
+)
+
+func TestAbortError(t *testing.T) {
+	twoFields := validate.Errors{
+		{Pointer: "/title", Message: "is required"},
+		{Parameter: "limit", Message: "must be at most 100"},
+	}
+	tests := []struct {
+		name       string
+		status     int
+		err        error
+		wantType   string
+		wantTitle  string
+		wantDetail string
+		wantErrors validate.Errors
+	}{
+		{
+			name: "plain error", status: http.StatusInternalServerError, err: errors.New("pool closed"),
+			wantType: "/problems/internal", wantTitle: "Internal server error", wantDetail: "pool closed",
+		},
+		{
+			name: "conflict", status: http.StatusConflict, err: fault.Conflictf("cat %d is on leave", 7),
+			wantType: "/problems/business-rule", wantTitle: "Business rule violation", wantDetail: "cat 7 is on leave",
+		},
+		{
+			name: "forbidden", status: http.StatusForbidden, err: fault.Forbiddenf("target is top secret"),
+			wantType: "/problems/not-cleared", wantTitle: "Not cleared", wantDetail: "target is top secret",
+		},
+		{
+			name: "not found", status: http.StatusNotFound, err: fault.NotFoundf("mission not found"),
+			wantType: "/problems/not-found", wantTitle: "Not found", wantDetail: "mission not found",
+		},
+		{
+			name: "one violation", status: http.StatusUnprocessableEntity, err: twoFields[:1],
+			wantType: "/problems/validation", wantTitle: "Validation failed", wantDetail: "/title: is required",
+			wantErrors: twoFields[:1],
+		},
+		{
+			name: "violations", status: http.StatusUnprocessableEntity, err: twoFields,
+			wantType: "/problems/validation", wantTitle: "Validation failed", wantDetail: "2 fields are invalid",
+			wantErrors: twoFields,
+		},
+		{
+			name: "wrapped violations", status: http.StatusUnprocessableEntity, err: fmt.Errorf("create mission: %w", twoFields),
+			wantType: "/problems/validation", wantTitle: "Validation failed", wantDetail: "2 fields are invalid",
+			wantErrors: twoFields,
+		},
+		{
+			name: "unmapped status", status: http.StatusTeapot, err: errors.New("short and stout"),
+			wantType: "/problems/internal", wantTitle: "I'm a teapot", wantDetail: "short and stout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRouter(models.Principal{Name: "handler"}, func(r gin.IRoutes) {
+				r.GET("/problem", func(c *gin.Context) { abortError(c, tt.status, tt.err) })
+			})
+
+			p := problemOf(t, serve(r, http.MethodGet, "/problem", ""), tt.status)
+
+			if p.Type != tt.wantType || p.Title != tt.wantTitle || p.Detail != tt.wantDetail || p.Instance != "/problem" {
+				t.Errorf("got %+v, want type %q, title %q and detail %q at /problem", p, tt.wantType, tt.wantTitle, tt.wantDetail)
+			}
+			if !reflect.DeepEqual(p.Errors, tt.wantErrors) {
+				t.Errorf("got errors %+v, want %+v", p.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestFaultKinds(t *testing.T) {
+	tests := []struct {
+		name                          string
+		err                           error
+		conflict, forbidden, notFound bool
+	}{
+		{"conflict", fault.Conflictf("busy"), true, false, false},
+		{"forbidden", fault.Forbiddenf("secret"), false, true, false},
+		{"not found", fault.NotFoundf("gone"), false, false, true},
+		{"wrapped", fmt.Errorf("assign cat: %w", fault.NotFoundf("gone")), false, false, true},
+		{"unmarked", errors.New("busy"), false, false, false},
+		{"violations", validate.Errors{{Pointer: "/name", Message: "is required"}}, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := [3]bool{isBusinessLogicError(tt.err), isForbiddenError(tt.err), isNotFoundError(tt.err)}
+			if want := [3]bool{tt.conflict, tt.forbidden, tt.notFound}; got != want {
+				t.Errorf("conflict, forbidden, not found = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestBindProblems(t *testing.T) {
+	type input struct {
+		Name    string   `json:"name" binding:"required"`
+		Age     int      `json:"age"`
+		Targets []string `json:"targets" binding:"max=1"`
+	}
+	tests := []struct {
+		name       string
+		body       string
+		status     int
+		wantDetail string
+		wantErrors validate.Errors
+	}{
+		{
+			name: "no body", body: "", status: http.StatusBadRequest,
+			wantDetail: "request body is required",
+		},
+		{
+			name: "not json", body: `{"name":`, status: http.StatusBadRequest,
+			wantDetail: "request body is not valid JSON",
+		},
+		{
+			name: "wrong type", body: `{"name": "Tom", "age": "three"}`, status: http.StatusUnprocessableEntity,
+			wantDetail: "/age: must be a number",
+			wantErrors: validate.Errors{{Pointer: "/age", Message: "must be a number"}},
+		},
+		{
+			name: "binding rules", body: `{"targets": ["a", "b"]}`, status: http.StatusUnprocessableEntity,
+			wantDetail: "2 fields are invalid",
+			wantErrors: validate.Errors{
+				{Pointer: "/name", Message: "is required"},
+				{Pointer: "/targets", Message: "must have at most 1 item(s)"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRouter(models.Principal{Name: "handler"}, func(r gin.IRoutes) {
+				r.POST("/bind", func(c *gin.Context) {
+					var in input
+					var report validate.Report
+					if !bind(c, &in, &report) {
+						return
+					}
+					if err := report.Err(); err != nil {
+						abortError(c, http.StatusUnprocessableEntity, err)
+						return
+					}
+					c.Status(http.StatusNoContent)
+				})
+			})
+
+			p := problemOf(t, serve(r, http.MethodPost, "/bind", tt.body), tt.status)
+
+			if p.Detail != tt.wantDetail || !reflect.DeepEqual(p.Errors, tt.wantErrors) {
+				t.Errorf("got detail %q and errors %+v, want %q and %+v", p.Detail, p.Errors, tt.wantDetail, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestBindingPointer(t *testing.T) {
+	tests := map[string]string{
+		"missionInput.title":            "/title",
+		"missionInput.targets[0].name":  "/targets/0/name",
+		"missionInput.targets[12]":      "/targets/12",
+		"input.schedule.start":          "/schedule/start",
+		"updateTargetInput.coordinates": "/coordinates",
+	}
+	for namespace, want := range tests {
+		if got := bindingPointer(namespace); got != want {
+			t.Errorf("bindingPointer(%q) = %q, want %q", namespace, got, want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/services"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
+)
+
+type search struct {
+	config   config.Config
+	services *services.Services
+}
+
+func newSearch(
+	config config.Config,
+	services *services.Services,
+) *search {
+	return &search{
+		config:   config,
+		services: services,
+	}
+}
+
+// parseSearchQuery reads ?q=, ?kind=, ?status=active|completed, ?cat_id=
+// and ?limit=.
+func parseSearchQuery(c *gin.Context) (models.SearchQuery, error) {
+	var r validate.Report
+	query := models.SearchQuery{
+		Text: c.Query("q"),
+		Kind: c.Query("kind"),
+	}
+
+	switch status := c.Query("status"); status {
+	case "":
+	case "active", "completed":
+		completed := status == "completed"
+		query.Completed = &completed
+	default:
+		r.AddParam("status", "status must be active or completed")
+	}
+
+	if value := c.Query("cat_id"); value != "" {
+		if catID, err := uuid.Parse(value); err != nil {
+			r.AddParam("cat_id", "cat_id must be a valid UUID")
+		} else {
+			query.CatID = &catID
+		}
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			r.AddParam("limit", "limit must be an integer")
+		}
+		query.Limit = limit
+	}
+
+	return query, r.Err()
+}
+
+func (h *search) Search(c *gin.Context) {
+	query, err := parseSearchQuery(c)
+	if err != nil {
+		abortError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	hits, err := h.services.Search.Search(c.Request.Context(), query)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Cat not found")
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusBadRequest, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to search")
+		return
+	}
+
+	d := newDisclosure(c)
+	for i := range hits {
+		d.hit(&hits[i])
+	}
+	d.send(c, h.services, http.StatusOK, hits)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+func TestSearchMixesFieldAndNotesWords(t *testing.T) {
+	sealed, err := testNotes(t).Seal("Meets the courier at the safehouse on Tuesdays")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pg, pool := newFakePostgres(t, reply{
+		match: "websearch_to_tsquery",
+		columns: []column{
+			{"kind", textOID}, {"id", uuidOID}, {"mission_id", uuidOID}, {"title", textOID},
+			{"snippet", textOID}, {"completed", boolOID}, {"rank", pgtype.Float4OID},
+			{"classification", textOID}, {"notes", textOID},
+		},
+		rows: [][]any{{models.SearchTarget, targetID, missionID, "Mr. Whiskers", "Mr. Whiskers <b>Austria</b>",
+			false, 0.15, models.Unclassified, sealed}},
+	})
+	h := newSearch(config.Default(), testServices(t, pool))
+	r := testRouter(models.Principal{Name: "handler", Clearance: models.Unclassified}, func(r gin.IRoutes) {
+		r.GET("/search", h.Search)
+	})
+
+	w := serve(r, http.MethodGet, "/search?q="+url.QueryEscape("Austria safehouse -decoy"), "")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", w.Code, w.Body)
+	}
+	var hits []models.SearchHit
+	if err := json.Unmarshal(w.Body.Bytes(), &hits); err != nil {
+		t.Fatal(err)
+	}
+	want := "Mr. Whiskers <b>Austria</b> ... Meets the courier at the <b>safehouse</b> on Tuesdays"
+	if len(hits) != 1 || hits[0].Snippet != want {
+		t.Errorf("got %+v, want one hit with snippet %q", hits, want)
+	}
+	// Each word is matched on its own, in the fields or the notes.
+	if !pg.ran("'{austria,safehouse}'") || !pg.ran("'{decoy}'") {
+		t.Error("the query words were not sent one by one")
+	}
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/services"
+)
+
+type skill struct {
+	config   config.Config
+	services *services.Services
+}
+
+func newSkill(
+	config config.Config,
+	services *services.Services,
+) *skill {
+	return &skill{
+		config:   config,
+		services: services,
+	}
+}
+
+type skillInput struct {
+	Code     string `json:"code" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Category string `json:"category" binding:"required"`
+}
+
+type catSkillInput struct {
+	Skill       string `json:"skill" binding:"required"`
+	Proficiency int    `json:"proficiency" binding:"required,min=1,max=5"`
+}
+
+type skillRequirementInput struct {
+	Skill          string `json:"skill" binding:"required"`
+	MinProficiency int    `json:"min_proficiency" binding:"required,min=1,max=5"`
+}
+
+type catSkillsInput struct {
+	Skills []catSkillInput `json:"skills" binding:"dive"`
+}
+
+type requirementsInput struct {
+	RequiredSkills []skillRequirementInput `json:"required_skills" binding:"dive"`
+}
+
+func requirementsFromInput(input []skillRequirementInput) []models.SkillRequirement {
+	reqs := make([]models.SkillRequirement, len(input))
+	for i, req := range input {
+		reqs[i] = models.SkillRequirement{
+			Skill:          models.Skill{Code: strings.TrimSpace(req.Skill)},
+			MinProficiency: req.MinProficiency,
+		}
+	}
+	return reqs
+}
+
+// isNotFoundError reports a record missing from the caller's agency.
+func isNotFoundError(err error) bool {
+	return fault.Is(err, fault.NotFound)
+}
+
+func (h *skill) GetAll(c *gin.Context) {
+	skills, err := h.services.Skill.GetAll(c.Request.Context())
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve skills")
+		return
+	}
+
+	c.JSON(http.StatusOK, skills)
+}
+
+func (h *skill) Create(c *gin.Context) {
+	var input skillInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger(c).Error(err)
+		abortBinding(c, err)
+		return
+	}
+
+	created, err := h.services.Skill.Create(c.Request.Context(), models.Skill{
+		Code:     input.Code,
+		Name:     input.Name,
+		Category: input.Category,
+	})
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to create skill")
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *skill) GetCatSkills(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "cat ID must be a valid UUID")
+		return
+	}
+
+	skills, err := h.services.Skill.GetCatSkills(c.Request.Context(), id)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Cat not found")
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve cat skills")
+		return
+	}
+
+	c.JSON(http.StatusOK, skills)
+}
+
+func (h *skill) SetCatSkills(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "cat ID must be a valid UUID")
+		return
+	}
+
+	var input catSkillsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger(c).Error(err)
+		abortBinding(c, err)
+		return
+	}
+
+	skills := make([]models.CatSkill, len(input.Skills))
+	for i, skill := range input.Skills {
+		skills[i] = models.CatSkill{
+			Skill:       models.Skill{Code: skill.Skill},
+			Proficiency: skill.Proficiency,
+		}
+	}
+
+	updated, err := h.services.Skill.SetCatSkills(c.Request.Context(), id, skills)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Cat not found")
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to update cat skills")
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *skill) SetTargetRequirements(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "target ID must be a valid UUID")
+		return
+	}
+
+	var input requirementsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger(c).Error(err)
+		abortBinding(c, err)
+		return
+	}
+
+	target, err := h.services.Skill.SetTargetRequirements(c.Request.Context(), id, requirementsFromInput(input.RequiredSkills))
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Target not found")
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to update target requirements")
+		return
+	}
+
+	d := newDisclosure(c)
+	d.target(target)
+	d.send(c, h.services, http.StatusOK, target)
+}
+
+// Coverage reports which skill requirements of the mission's open targets a
+// cat meets. It checks the assigned cat unless cat_id is given.
+func (h *skill) Coverage(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "mission ID must be a valid UUID")
+		return
+	}
+
+	var catID *uuid.UUID
+	if raw := c.Query("cat_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			abortProblem(c, http.StatusBadRequest, "cat ID must be a valid UUID")
+			return
+		}
+		catID = &parsed
+	}
+
+	coverage, err := h.services.Skill.Coverage(c.Request.Context(), id, catID)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortError(c, http.StatusNotFound, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to compute skill coverage")
+		return
+	}
+
+	c.JSON(http.StatusOK, coverage)
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/mksmstpck/spy_cat_agency/internal/config"
 	"github.com/mksmstpck/spy_cat_agency/internal/models"
 	"github.com/mksmstpck/spy_cat_agency/internal/services"
-	"github.com/sirupsen/logrus"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
 )
 
 type spyCat struct {
@@ -32,92 +32,57 @@ type spyCatInput struct {
 	Breed    string  `json:"breed" binding:"required"`
 	ExpYears int     `json:"years_experience"`
 	Salary   float32 `json:"salary"`
-}
-
-func (input *spyCatInput) Validate() error {
-	if strings.TrimSpace(input.Name) == "" {
-		return &ValidationError{Field: "name", Message: "cat name cannot be empty"}
-	}
-	if strings.TrimSpace(input.Breed) == "" {
-		return &ValidationError{Field: "breed", Message: "breed cannot be empty"}
-	}
-	if input.ExpYears < 0 {
-		return &ValidationError{Field: "years_experience", Message: "experience cannot be negative"}
-	}
-	if input.Salary < 0 {
-		return &ValidationError{Field: "salary", Message: "salary cannot be negative"}
-	}
-	return nil
+	// Clearance defaults to unclassified.
+	Clearance models.Classification `json:"clearance"`
 }
 
 func (h *spyCat) Create(c *gin.Context) {
 	var catCreate spyCatInput
-
-	if err := c.ShouldBindJSON(&catCreate); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	if err := catCreate.Validate(); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
-			"error":   "Validation failed",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	breed, err := h.services.Breed.GetByName(c.Request.Context(), strings.TrimSpace(catCreate.Breed))
-	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid breed",
-			"details": err.Error(),
-		})
+	var r validate.Report
+	if !bind(c, &catCreate, &r) {
 		return
 	}
 
-	if breed == nil {
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
-			"error": "Breed not found",
-		})
-		return
+	cat := &models.SpyCat{
+		Name:      catCreate.Name,
+		ExpYears:  catCreate.ExpYears,
+		Salary:    catCreate.Salary,
+		Clearance: catCreate.Clearance,
 	}
 
-	cat := &models.SpyCat{
-		Name:     strings.TrimSpace(catCreate.Name),
-		Breed:    *breed,
-		ExpYears: catCreate.ExpYears,
-		Salary:   catCreate.Salary,
+	if name := strings.TrimSpace(catCreate.Breed); name != "" {
+		breed, err := h.services.Breed.GetByName(c.Request.Context(), name)
+		if err != nil {
+			logger(c).Error(err)
+			abortProblem(c, http.StatusInternalServerError, "Failed to look up breed")
+			return
+		}
+		if breed == nil {
+			r.Add(validate.Pointer("breed"), "unknown breed %q", name)
+		} else {
+			cat.Breed = *breed
+		}
 	}
 
-	if err := cat.Validate(); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
-			"error":   "Model validation failed",
-			"details": err.Error(),
-		})
+	r.Merge(validate.Cat(cat))
+	if err := r.Err(); err != nil {
+		logger(c).Error(err)
+		abortError(c, http.StatusUnprocessableEntity, err)
 		return
 	}
 
-	cat, err = h.services.SpyCat.Create(c.Request.Context(), *cat)
+	cat, err := h.services.SpyCat.Create(c.Request.Context(), *cat)
 	if err != nil {
-		logrus.Error(err)
+		logger(c).Error(err)
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
 		if isBusinessLogicError(err) {
-			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
-				"error":   "Business rule violation",
-				"details": err.Error(),
-			})
+			abortError(c, http.StatusConflict, err)
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create cat",
-			"details": "Internal server error",
-		})
+		abortProblem(c, http.StatusInternalServerError, "Failed to create cat")
 		return
 	}
 
@@ -127,48 +92,61 @@ func (h *spyCat) Create(c *gin.Context) {
 func (h *spyCat) GetByID(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Cat ID is required",
-		})
+		abortProblem(c, http.StatusBadRequest, "Cat ID is required")
 		return
 	}
 
 	newID, err := uuid.Parse(id)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid cat ID format",
-			"details": "ID must be a valid UUID",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "cat ID must be a valid UUID")
 		return
 	}
 
 	cat, err := h.services.SpyCat.GetByID(c.Request.Context(), newID)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve cat",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve cat")
 		return
 	}
 
 	if cat == nil {
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
-			"error": "Cat not found",
-		})
+		abortProblem(c, http.StatusNotFound, "Cat not found")
 		return
 	}
 
 	c.JSON(http.StatusOK, cat)
 }
 
+// GetAll lists cats, filtered by repeated ?trait= breed filters and
+// ?status= (repeatable, or "all"). Retired cats are hidden by default.
 func (h *spyCat) GetAll(c *gin.Context) {
-	cats, err := h.services.SpyCat.GetAll(c.Request.Context())
+	var filter models.CatFilter
+	for _, expr := range c.QueryArray("trait") {
+		trait, err := models.ParseBreedTraitFilter(expr)
+		if err != nil {
+			abortError(c, http.StatusBadRequest, err)
+			return
+		}
+		filter.Traits = append(filter.Traits, trait)
+	}
+
+	for _, status := range c.QueryArray("status") {
+		if status == "all" {
+			filter.Statuses = models.CatStatuses
+			break
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	cats, err := h.services.SpyCat.GetAll(c.Request.Context(), filter)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve cats",
-		})
+		logger(c).Error(err)
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusBadRequest, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve cats")
 		return
 	}
 
@@ -179,67 +157,42 @@ type spyCatSalaryUpdate struct {
 	Salary *float32 `json:"salary" binding:"required"`
 }
 
-func (input *spyCatSalaryUpdate) Validate() error {
-	if input.Salary == nil {
-		return &ValidationError{Field: "salary", Message: "salary is required"}
-	}
-	if *input.Salary < 0 {
-		return &ValidationError{Field: "salary", Message: "salary cannot be negative"}
-	}
-	return nil
-}
-
 func (h *spyCat) UpdateSalary(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Cat ID is required",
-		})
+		abortProblem(c, http.StatusBadRequest, "Cat ID is required")
 		return
 	}
 
 	catID, err := uuid.Parse(id)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid cat ID format",
-			"details": "ID must be a valid UUID",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "cat ID must be a valid UUID")
 		return
 	}
 
 	var catUpdate spyCatSalaryUpdate
-	if err := c.ShouldBindJSON(&catUpdate); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": "salary field is required and must be a number",
-		})
+	var r validate.Report
+	if !bind(c, &catUpdate, &r) {
 		return
 	}
-
-	if err := catUpdate.Validate(); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
-			"error":   "Validation failed",
-			"details": err.Error(),
-		})
+	if catUpdate.Salary != nil {
+		r.Merge(validate.Salary(*catUpdate.Salary))
+	}
+	if err := r.Err(); err != nil {
+		logger(c).Error(err)
+		abortError(c, http.StatusUnprocessableEntity, err)
 		return
 	}
 
 	err = h.services.SpyCat.UpdateSalary(c.Request.Context(), catID, *catUpdate.Salary)
 	if err != nil {
-		logrus.Error(err)
+		logger(c).Error(err)
 		if isBusinessLogicError(err) {
-			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
-				"error":   "Business rule violation",
-				"details": err.Error(),
-			})
+			abortError(c, http.StatusConflict, err)
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update cat salary",
-		})
+		abortProblem(c, http.StatusInternalServerError, "Failed to update cat salary")
 		return
 	}
 
@@ -250,67 +203,42 @@ type spyCatExpUpdate struct {
 	ExpYears *int `json:"years_experience" binding:"required"`
 }
 
-func (input *spyCatExpUpdate) Validate() error {
-	if input.ExpYears == nil {
-		return &ValidationError{Field: "years_experience", Message: "years_experience is required"}
-	}
-	if *input.ExpYears < 0 {
-		return &ValidationError{Field: "years_experience", Message: "experience cannot be negative"}
-	}
-	return nil
-}
-
 func (h *spyCat) UpdateExpYears(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Cat ID is required",
-		})
+		abortProblem(c, http.StatusBadRequest, "Cat ID is required")
 		return
 	}
 
 	catID, err := uuid.Parse(id)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid cat ID format",
-			"details": "ID must be a valid UUID",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "cat ID must be a valid UUID")
 		return
 	}
 
 	var catUpdate spyCatExpUpdate
-	if err := c.ShouldBindJSON(&catUpdate); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": "years_experience field is required and must be a number",
-		})
+	var r validate.Report
+	if !bind(c, &catUpdate, &r) {
 		return
 	}
-
-	if err := catUpdate.Validate(); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
-			"error":   "Validation failed",
-			"details": err.Error(),
-		})
+	if catUpdate.ExpYears != nil {
+		r.Merge(validate.Experience(*catUpdate.ExpYears))
+	}
+	if err := r.Err(); err != nil {
+		logger(c).Error(err)
+		abortError(c, http.StatusUnprocessableEntity, err)
 		return
 	}
 
 	err = h.services.SpyCat.UpdateExperience(c.Request.Context(), catID, *catUpdate.ExpYears)
 	if err != nil {
-		logrus.Error(err)
+		logger(c).Error(err)
 		if isBusinessLogicError(err) {
-			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
-				"error":   "Business rule violation",
-				"details": err.Error(),
-			})
+			abortError(c, http.StatusConflict, err)
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update cat experience",
-		})
+		abortProblem(c, http.StatusInternalServerError, "Failed to update cat experience")
 		return
 	}
 
@@ -320,35 +248,172 @@ func (h *spyCat) UpdateExpYears(c *gin.Context) {
 func (h *spyCat) Delete(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Cat ID is required",
-		})
+		abortProblem(c, http.StatusBadRequest, "Cat ID is required")
 		return
 	}
 
 	newID, err := uuid.Parse(id)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid cat ID format",
-			"details": "ID must be a valid UUID",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "cat ID must be a valid UUID")
 		return
 	}
 
 	err = h.services.SpyCat.Delete(c.Request.Context(), newID)
 	if err != nil {
-		logrus.Error(err)
+		logger(c).Error(err)
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to delete cat")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetCareer lists every mission the cat has been on, including completed
+// ones, with how long it served on each.
+func (h *spyCat) GetCareer(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "cat ID must be a valid UUID")
+		return
+	}
+
+	career, err := h.services.Mission.GetCareer(c.Request.Context(), newID)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Cat not found")
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve cat missions")
+		return
+	}
+
+	c.JSON(http.StatusOK, career)
+}
+
+type spyCatStatusUpdate struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// UpdateStatus suspends or reactivates a cat.
+func (h *spyCat) UpdateStatus(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "cat ID must be a valid UUID")
+		return
+	}
+
+	var input spyCatStatusUpdate
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger(c).Error(err)
+		abortBinding(c, err)
+		return
+	}
+
+	err = h.services.SpyCat.UpdateStatus(c.Request.Context(), newID, strings.ToLower(strings.TrimSpace(input.Status)))
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Cat not found")
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to update cat status")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+type spyCatRetirement struct {
+	Reason string `json:"reason" binding:"required"`
+	// ReplacementCatID takes over the cat's active missions.
+	ReplacementCatID *uuid.UUID `json:"replacement_cat_id"`
+}
+
+// Retire retires a cat, handing its active missions to a replacement.
+func (h *spyCat) Retire(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "cat ID must be a valid UUID")
+		return
+	}
+
+	var input spyCatRetirement
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger(c).Error(err)
+		abortBinding(c, err)
+		return
+	}
+
+	err = h.services.SpyCat.Retire(c.Request.Context(), newID, input.Reason, actor(c), input.ReplacementCatID)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Cat not found")
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to retire cat")
+		return
+	}
+
+	cat, err := h.services.SpyCat.GetByID(c.Request.Context(), newID)
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve cat")
+		return
+	}
+
+	c.JSON(http.StatusOK, cat)
+}
+
+// UpdateClearance changes the levels of work the cat may be put on.
+func (h *spyCat) UpdateClearance(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "cat ID must be a valid UUID")
+		return
+	}
+
+	var input clearanceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger(c).Error(err)
+		abortBinding(c, err)
+		return
+	}
+
+	err = h.services.SpyCat.UpdateClearance(c.Request.Context(), newID, input.Clearance)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Cat not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
 		if isBusinessLogicError(err) {
-			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
-				"error":   "Business rule violation",
-				"details": err.Error(),
-			})
+			abortError(c, http.StatusConflict, err)
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete cat",
-		})
+		abortProblem(c, http.StatusInternalServerError, "Failed to update cat clearance")
 		return
 	}
 
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
+)
+
+func TestCreateCatWithUnknownBreedIsInvalid(t *testing.T) {
+	// No reply: the breed lookup finds no row.
+	pg, pool := newFakePostgres(t)
+	h := newSpyCat(config.Default(), testServices(t, pool))
+	r := testRouter(models.Principal{Name: "handler", Clearance: models.TopSecret}, func(r gin.IRoutes) {
+		r.POST("/cat/", h.Create)
+	})
+
+	w := serve(r, http.MethodPost, "/cat/", `{"name": "Tom", "breed": "Dragon", "salary": 100}`)
+
+	p := problemOf(t, w, http.StatusUnprocessableEntity)
+	want := validate.Violation{Pointer: "/breed", Message: `unknown breed "Dragon"`}
+	if len(p.Errors) != 1 || p.Errors[0] != want {
+		t.Errorf("got violations %+v, want %+v", p.Errors, want)
+	}
+	if pg.ran("INSERT INTO cats") {
+		t.Error("a cat with an unknown breed was stored")
+	}
+}
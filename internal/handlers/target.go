@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -9,7 +10,7 @@ import (
 	"github.com/mksmstpck/spy_cat_agency/internal/config"
 	"github.com/mksmstpck/spy_cat_agency/internal/models"
 	"github.com/mksmstpck/spy_cat_agency/internal/services"
-	"github.com/sirupsen/logrus"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
 )
 
 type target struct {
@@ -31,102 +32,110 @@ type targetCreate struct {
 	MissionID uuid.UUID `json:"mission_id" binding:"required"`
 	Name      string    `json:"name" binding:"required"`
 	Country   string    `json:"country" binding:"required"`
+	Latitude  *float64  `json:"latitude"`
+	Longitude *float64  `json:"longitude"`
+	City      string    `json:"city"`
+	Address   string    `json:"address"`
 	Notes     string    `json:"notes"`
+	// Classification defaults to the mission's.
+	Classification models.Classification   `json:"classification"`
+	RequiredSkills []skillRequirementInput `json:"required_skills" binding:"dive"`
 }
 
-func (input *targetCreate) Validate() error {
-	if strings.TrimSpace(input.Name) == "" {
-		return &ValidationError{Field: "name", Message: "target name cannot be empty"}
+// queryFloat reads an optional float query parameter.
+func queryFloat(c *gin.Context, r *validate.Report, name string) *float64 {
+	value := c.Query(name)
+	if value == "" {
+		return nil
 	}
-	if strings.TrimSpace(input.Country) == "" {
-		return &ValidationError{Field: "country", Message: "target country cannot be empty"}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		r.AddParam(name, "%q is not a number", value)
+		return nil
 	}
-	return nil
+	return &f
 }
 
 func (h *target) Create(c *gin.Context) {
 	var targetCreate targetCreate
-
-	if err := c.ShouldBindJSON(&targetCreate); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+	var r validate.Report
+	if !bind(c, &targetCreate, &r) {
 		return
 	}
 
-	if err := targetCreate.Validate(); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
-			"error":   "Validation failed",
-			"details": err.Error(),
-		})
-		return
+	target := models.Target{
+		MissionID:      targetCreate.MissionID,
+		Name:           targetCreate.Name,
+		Country:        targetCreate.Country,
+		Latitude:       targetCreate.Latitude,
+		Longitude:      targetCreate.Longitude,
+		City:           targetCreate.City,
+		Address:        targetCreate.Address,
+		Notes:          targetCreate.Notes,
+		Classification: targetCreate.Classification,
+		RequiredSkills: requirementsFromInput(targetCreate.RequiredSkills),
 	}
 
-	target := models.Target{
-		MissionID: targetCreate.MissionID,
-		Name:      strings.TrimSpace(targetCreate.Name),
-		Country:   strings.TrimSpace(targetCreate.Country),
-		Notes:     targetCreate.Notes,
+	r.Merge(validate.Target(&target))
+	if err := r.Err(); err != nil {
+		logger(c).Error(err)
+		abortError(c, http.StatusUnprocessableEntity, err)
+		return
 	}
 
 	createdTarget, err := h.services.Target.Create(c.Request.Context(), target)
 	if err != nil {
-		logrus.Error(err)
+		logger(c).Error(err)
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Mission not found")
+			return
+		}
 		if isBusinessLogicError(err) {
-			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
-				"error":   "Business rule violation",
-				"details": err.Error(),
-			})
+			abortError(c, http.StatusConflict, err)
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create target",
-		})
+		abortProblem(c, http.StatusInternalServerError, "Failed to create target")
 		return
 	}
 
-	c.JSON(http.StatusCreated, createdTarget)
+	d := newDisclosure(c)
+	d.target(createdTarget)
+	d.send(c, h.services, http.StatusCreated, createdTarget)
 }
 
 func (h *target) GetByID(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Target ID is required",
-		})
+		abortProblem(c, http.StatusBadRequest, "Target ID is required")
 		return
 	}
 
 	newID, err := uuid.Parse(id)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid target ID format",
-			"details": "ID must be a valid UUID",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "target ID must be a valid UUID")
 		return
 	}
 
 	target, err := h.services.Target.GetByID(c.Request.Context(), newID)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve target",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve target")
 		return
 	}
 
 	if target == nil {
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
-			"error": "Target not found",
-		})
+		abortProblem(c, http.StatusNotFound, "Target not found")
 		return
 	}
 
-	c.JSON(http.StatusOK, target)
+	d := newDisclosure(c)
+	d.target(target)
+	d.send(c, h.services, http.StatusOK, target)
 }
 
 type targetUpdateCompleted struct {
@@ -136,45 +145,40 @@ type targetUpdateCompleted struct {
 func (h *target) UpdateCompleted(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Target ID is required",
-		})
+		abortProblem(c, http.StatusBadRequest, "Target ID is required")
 		return
 	}
 
 	newID, err := uuid.Parse(id)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid target ID format",
-			"details": "ID must be a valid UUID",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "target ID must be a valid UUID")
 		return
 	}
 
 	var targetUpdate targetUpdateCompleted
 	if err := c.ShouldBindJSON(&targetUpdate); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": "completed field is required and must be a boolean",
-		})
+		logger(c).Error(err)
+		abortBinding(c, err)
 		return
 	}
 
 	err = h.services.Target.UpdateCompleted(c.Request.Context(), newID, *targetUpdate.Completed)
 	if err != nil {
-		logrus.Error(err)
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Target not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
 		if isBusinessLogicError(err) {
-			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
-				"error":   "Business rule violation",
-				"details": err.Error(),
-			})
+			abortError(c, http.StatusConflict, err)
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update target",
-		})
+		abortProblem(c, http.StatusInternalServerError, "Failed to update target")
 		return
 	}
 
@@ -188,83 +192,288 @@ type targetUpdateNotes struct {
 func (h *target) UpdateNotes(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Target ID is required",
-		})
+		abortProblem(c, http.StatusBadRequest, "Target ID is required")
 		return
 	}
 
 	newID, err := uuid.Parse(id)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid target ID format",
-			"details": "ID must be a valid UUID",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "target ID must be a valid UUID")
 		return
 	}
 
 	var targetUpdate targetUpdateNotes
 	if err := c.ShouldBindJSON(&targetUpdate); err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": "notes field is required",
-		})
+		logger(c).Error(err)
+		abortBinding(c, err)
 		return
 	}
 
 	err = h.services.Target.UpdateNotes(c.Request.Context(), newID, *targetUpdate.Notes)
 	if err != nil {
-		logrus.Error(err)
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Target not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
 		if isBusinessLogicError(err) {
-			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
-				"error":   "Business rule violation",
-				"details": err.Error(),
-			})
+			abortError(c, http.StatusConflict, err)
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update target notes",
-		})
+		abortProblem(c, http.StatusInternalServerError, "Failed to update target notes")
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
 }
 
+type targetUpdateCountry struct {
+	Country string `json:"country" binding:"required"`
+}
+
+func (h *target) UpdateCountry(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "target ID must be a valid UUID")
+		return
+	}
+
+	var input targetUpdateCountry
+	var r validate.Report
+	if !bind(c, &input, &r) {
+		return
+	}
+
+	country, ok := r.Country(validate.Pointer("country"), input.Country)
+	if !ok && r.Err() == nil {
+		r.Add(validate.Pointer("country"), "target country cannot be empty")
+	}
+	if err := r.Err(); err != nil {
+		abortError(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	err = h.services.Target.UpdateCountry(c.Request.Context(), newID, country)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Target not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to update target country")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+type targetUpdateLocation struct {
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	City      string   `json:"city"`
+	Address   string   `json:"address"`
+}
+
+// UpdateLocation sets the target's coordinates, city and address; null
+// coordinates clear them.
+func (h *target) UpdateLocation(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "target ID must be a valid UUID")
+		return
+	}
+
+	var input targetUpdateLocation
+	var r validate.Report
+	if !bind(c, &input, &r) {
+		return
+	}
+
+	r.Coordinates("", input.Latitude, input.Longitude)
+	if err := r.Err(); err != nil {
+		abortError(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	err = h.services.Target.UpdateLocation(c.Request.Context(), newID, models.TargetLocation{
+		Latitude:  input.Latitude,
+		Longitude: input.Longitude,
+		City:      strings.TrimSpace(input.City),
+		Address:   strings.TrimSpace(input.Address),
+	})
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Target not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to update target location")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// Nearby lists targets within ?radius_km= of ?lat= and ?lon=, nearest first.
+func (h *target) Nearby(c *gin.Context) {
+	var r validate.Report
+	lat := queryFloat(c, &r, "lat")
+	lon := queryFloat(c, &r, "lon")
+	radius := queryFloat(c, &r, "radius_km")
+	for _, name := range []string{"lat", "lon", "radius_km"} {
+		if c.Query(name) == "" {
+			r.AddParam(name, "is required")
+		}
+	}
+	if lat != nil && lon != nil {
+		r.CoordinateParams("lat", "lon", lat, lon)
+	}
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			r.AddParam("limit", "limit must be an integer")
+		}
+	}
+	if err := r.Err(); err != nil {
+		abortError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	targets, err := h.services.Target.Nearby(c.Request.Context(), *lat, *lon, *radius, limit)
+	if err != nil {
+		logger(c).Error(err)
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusBadRequest, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to find nearby targets")
+		return
+	}
+
+	d := newDisclosure(c)
+	for i := range targets {
+		d.target(&targets[i].Target)
+	}
+	d.send(c, h.services, http.StatusOK, targets)
+}
+
 func (h *target) Delete(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Target ID is required",
-		})
+		abortProblem(c, http.StatusBadRequest, "Target ID is required")
 		return
 	}
 
 	newID, err := uuid.Parse(id)
 	if err != nil {
-		logrus.Error(err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid target ID format",
-			"details": "ID must be a valid UUID",
-		})
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "target ID must be a valid UUID")
 		return
 	}
 
 	err = h.services.Target.Delete(c.Request.Context(), newID)
 	if err != nil {
-		logrus.Error(err)
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Target not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to delete target")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *target) Restore(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "target ID must be a valid UUID")
+		return
+	}
+
+	target, err := h.services.Target.Restore(c.Request.Context(), newID)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Deleted target not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to restore target")
+		return
+	}
+
+	d := newDisclosure(c)
+	d.target(target)
+	d.send(c, h.services, http.StatusOK, target)
+}
+
+// UpdateClassification changes the target's level. The caller must be cleared for
+// the old and the new level.
+func (h *target) UpdateClassification(c *gin.Context) {
+	newID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "target ID must be a valid UUID")
+		return
+	}
+
+	var input classificationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger(c).Error(err)
+		abortBinding(c, err)
+		return
+	}
+
+	err = h.services.Target.UpdateClassification(c.Request.Context(), newID, input.Classification)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Target not found")
+			return
+		}
+		if isForbiddenError(err) {
+			abortForbidden(c, err)
+			return
+		}
 		if isBusinessLogicError(err) {
-			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
-				"error":   "Business rule violation",
-				"details": err.Error(),
-			})
+			abortError(c, http.StatusConflict, err)
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete target",
-		})
+		abortProblem(c, http.StatusInternalServerError, "Failed to update target classification")
 		return
 	}
 
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+const targetID = "8d2e4b6a-1c3f-4e5d-9a7b-0f1e2d3c4b5a"
+
+func TestTargetWritesAboveClearanceAreForbidden(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		route  func(h *target) gin.HandlerFunc
+	}{
+		{"completed", http.MethodPut, "/target/:id/completed", `{"completed": true}`, func(h *target) gin.HandlerFunc { return h.UpdateCompleted }},
+		{"notes", http.MethodPut, "/target/:id/notes", `{"notes": "moved to the safehouse"}`, func(h *target) gin.HandlerFunc { return h.UpdateNotes }},
+		{"country", http.MethodPut, "/target/:id/country", `{"country": "Austria"}`, func(h *target) gin.HandlerFunc { return h.UpdateCountry }},
+		{"location", http.MethodPut, "/target/:id/location", `{"latitude": 48.2, "longitude": 16.37}`, func(h *target) gin.HandlerFunc { return h.UpdateLocation }},
+		{"delete", http.MethodDelete, "/target/:id", "", func(h *target) gin.HandlerFunc { return h.Delete }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pg, pool := newFakePostgres(t,
+				targetRow("WHERE t.id =", targetID, missionID, models.TopSecret),
+			)
+			h := newTarget(config.Default(), testServices(t, pool))
+			r := testRouter(models.Principal{Name: "handler", Clearance: models.Secret}, func(r gin.IRoutes) {
+				r.Handle(tt.method, tt.path, tt.route(h))
+			})
+
+			w := serve(r, tt.method, "/target/"+targetID+tt.path[len("/target/:id"):], tt.body)
+
+			problemOf(t, w, http.StatusForbidden)
+			if pg.ran("UPDATE targets") {
+				t.Error("a top secret target was changed by a secret principal")
+			}
+		})
+	}
+}
+
+func TestUpdateNotesOfMissingTargetIsNotFound(t *testing.T) {
+	pg, pool := newFakePostgres(t)
+	h := newTarget(config.Default(), testServices(t, pool))
+	r := testRouter(models.Principal{Name: "handler", Clearance: models.TopSecret}, func(r gin.IRoutes) {
+		r.PUT("/target/:id/notes", h.UpdateNotes)
+	})
+
+	w := serve(r, http.MethodPut, "/target/"+targetID+"/notes", `{"notes": "moved"}`)
+
+	problemOf(t, w, http.StatusNotFound)
+	if pg.ran("UPDATE targets") {
+		t.Error("notes of a missing target were written")
+	}
+}
+
+func TestRestoreTargetAboveClearanceIsForbidden(t *testing.T) {
+	pg, pool := newFakePostgres(t,
+		reply{
+			match:   "SELECT classification FROM targets",
+			columns: []column{{"classification", textOID}},
+			rows:    [][]any{{models.TopSecret}},
+		},
+	)
+	h := newTarget(config.Default(), testServices(t, pool))
+	r := testRouter(models.Principal{Name: "handler", Clearance: models.Secret}, func(r gin.IRoutes) {
+		r.POST("/target/:id/restore", h.Restore)
+	})
+
+	w := serve(r, http.MethodPost, "/target/"+targetID+"/restore", "")
+
+	problemOf(t, w, http.StatusForbidden)
+	if pg.ran("UPDATE targets SET deleted_at = NULL") {
+		t.Error("a top secret target was restored by a secret principal")
+	}
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/services"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
+)
+
+type template struct {
+	config   config.Config
+	services *services.Services
+}
+
+func newTemplate(
+	config config.Config,
+	services *services.Services,
+) *template {
+	return &template{
+		config:   config,
+		services: services,
+	}
+}
+
+type templateInput struct {
+	Name        string                `json:"name" binding:"required"`
+	Title       string                `json:"title" binding:"required"`
+	Description *string               `json:"description,omitempty"`
+	Targets     []templateTargetInput `json:"targets" binding:"required,min=1,max=3,dive"`
+}
+
+// templateTargetInput is a placeholder target. Country may be left for the
+// mission to fill in.
+type templateTargetInput struct {
+	Name            string                  `json:"name" binding:"required"`
+	Country         string                  `json:"country"`
+	Notes           string                  `json:"notes"`
+	SuggestedSkills []skillRequirementInput `json:"suggested_skills" binding:"dive"`
+}
+
+func (input *templateInput) model() models.MissionTemplate {
+	t := models.MissionTemplate{
+		Name:        input.Name,
+		Title:       input.Title,
+		Description: input.Description,
+		Targets:     make([]models.TemplateTarget, len(input.Targets)),
+	}
+	for i, target := range input.Targets {
+		t.Targets[i] = models.TemplateTarget{
+			Name:            target.Name,
+			Country:         target.Country,
+			Notes:           target.Notes,
+			SuggestedSkills: make([]models.SuggestedSkill, len(target.SuggestedSkills)),
+		}
+		for j, skill := range target.SuggestedSkills {
+			t.Targets[i].SuggestedSkills[j] = models.SuggestedSkill{
+				Skill:          strings.TrimSpace(skill.Skill),
+				MinProficiency: skill.MinProficiency,
+			}
+		}
+	}
+	return t
+}
+
+func parseTemplateID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusBadRequest, "template ID must be a valid UUID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// bindTemplate reads and validates a template body, answering the request
+// itself when it is not acceptable.
+func bindTemplate(c *gin.Context) (models.MissionTemplate, bool) {
+	var input templateInput
+	var r validate.Report
+	if !bind(c, &input, &r) {
+		return models.MissionTemplate{}, false
+	}
+
+	t := input.model()
+	r.Merge(validate.Template(&t))
+	if err := r.Err(); err != nil {
+		logger(c).Error(err)
+		abortError(c, http.StatusUnprocessableEntity, err)
+		return models.MissionTemplate{}, false
+	}
+
+	return t, true
+}
+
+func (h *template) Create(c *gin.Context) {
+	input, ok := bindTemplate(c)
+	if !ok {
+		return
+	}
+
+	created, err := h.services.Template.Create(c.Request.Context(), input, actor(c))
+	if err != nil {
+		logger(c).Error(err)
+		if invalid, ok := validate.As(err); ok {
+			abortInvalid(c, invalid)
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to create template")
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *template) GetAll(c *gin.Context) {
+	templates, err := h.services.Template.GetAll(c.Request.Context())
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve templates")
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+func (h *template) GetByID(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+
+	t, err := h.services.Template.GetByID(c.Request.Context(), id)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Template not found")
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to retrieve template")
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+func (h *template) Update(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+
+	input, ok := bindTemplate(c)
+	if !ok {
+		return
+	}
+	input.ID = id
+
+	updated, err := h.services.Template.Update(c.Request.Context(), input)
+	if err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Template not found")
+			return
+		}
+		if invalid, ok := validate.As(err); ok {
+			abortInvalid(c, invalid)
+			return
+		}
+		if isBusinessLogicError(err) {
+			abortError(c, http.StatusConflict, err)
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to update template")
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *template) Delete(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Template.Delete(c.Request.Context(), id); err != nil {
+		logger(c).Error(err)
+		if isNotFoundError(err) {
+			abortProblem(c, http.StatusNotFound, "Template not found")
+			return
+		}
+		abortProblem(c, http.StatusInternalServerError, "Failed to delete template")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/services"
+)
+
+type trash struct {
+	config   config.Config
+	services *services.Services
+}
+
+func newTrash(
+	config config.Config,
+	services *services.Services,
+) *trash {
+	return &trash{
+		config:   config,
+		services: services,
+	}
+}
+
+func (h *trash) GetAll(c *gin.Context) {
+	items, err := h.services.Trash.GetAll(c.Request.Context())
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to list trash")
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// Purge runs the purge job now instead of waiting for the next interval.
+func (h *trash) Purge(c *gin.Context) {
+	result, err := h.services.Trash.Purge(c.Request.Context())
+	if err != nil {
+		logger(c).Error(err)
+		abortProblem(c, http.StatusInternalServerError, "Failed to purge trash")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
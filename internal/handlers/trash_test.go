@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+// trashLevels answers the restore check of a trashed object with levels:
+// the object's, then those of the targets a mission brings back.
+func trashLevels(table string, levels ...models.Classification) reply {
+	rows := make([][]any, len(levels))
+	for i, level := range levels {
+		rows[i] = []any{level}
+	}
+	return reply{
+		match:   "SELECT classification FROM " + table,
+		columns: []column{{"classification", textOID}},
+		rows:    rows,
+	}
+}
+
+func TestRestoreRules(t *testing.T) {
+	const missionDeleted = "Cannot restore target 8d2e: mission 3c9d is deleted"
+	tests := []struct {
+		name       string
+		kind       string
+		clearance  models.Classification
+		replies    []reply
+		status     int
+		wantDetail string
+		restored   bool
+	}{
+		{
+			name: "target not in the trash", kind: "target", clearance: models.TopSecret,
+			status: http.StatusNotFound, wantDetail: "Deleted target not found",
+		},
+		{
+			name: "target above clearance", kind: "target", clearance: models.Confidential,
+			replies: []reply{trashLevels("targets", models.Secret)},
+			status:  http.StatusForbidden, wantDetail: "handler is not cleared for secret",
+		},
+		{
+			name: "target of a deleted mission", kind: "target", clearance: models.Secret,
+			replies: []reply{
+				trashLevels("targets", models.Secret),
+				{match: "UPDATE targets SET deleted_at = NULL", code: "P0001", message: missionDeleted},
+			},
+			status: http.StatusConflict, wantDetail: missionDeleted, restored: true,
+		},
+		{
+			name: "target restored meanwhile", kind: "target", clearance: models.Secret,
+			replies: []reply{
+				trashLevels("targets", models.Secret),
+				{match: "UPDATE targets SET deleted_at = NULL", tag: "UPDATE 0"},
+			},
+			status: http.StatusNotFound, wantDetail: "Deleted target not found", restored: true,
+		},
+		{
+			name: "target", kind: "target", clearance: models.Secret,
+			replies: []reply{
+				trashLevels("targets", models.Secret),
+				targetRow("WHERE t.id =", targetID, missionID, models.Secret),
+			},
+			status: http.StatusOK, restored: true,
+		},
+		{
+			name: "mission not in the trash", kind: "mission", clearance: models.TopSecret,
+			status: http.StatusNotFound, wantDetail: "Deleted mission not found",
+		},
+		{
+			name: "mission bringing back a target above clearance", kind: "mission", clearance: models.Secret,
+			replies: []reply{trashLevels("missions", models.Confidential, models.Unclassified, models.TopSecret)},
+			status:  http.StatusForbidden, wantDetail: "handler is not cleared for top_secret",
+		},
+		{
+			name: "mission", kind: "mission", clearance: models.Secret,
+			replies: []reply{
+				trashLevels("missions", models.Confidential, models.Secret),
+				missionRow(missionID, models.Confidential),
+			},
+			status: http.StatusOK, restored: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pg, pool := newFakePostgres(t, tt.replies...)
+			s := testServices(t, pool)
+			id, restore := targetID, newTarget(config.Default(), s).Restore
+			if tt.kind == "mission" {
+				id, restore = missionID, newMission(config.Default(), s).Restore
+			}
+			r := testRouter(models.Principal{Name: "handler", Clearance: tt.clearance}, func(r gin.IRoutes) {
+				r.POST("/"+tt.kind+"/:id/restore", restore)
+			})
+
+			w := serve(r, http.MethodPost, "/"+tt.kind+"/"+id+"/restore", "")
+
+			if tt.status == http.StatusOK {
+				if w.Code != tt.status {
+					t.Fatalf("got status %d, want %d: %s", w.Code, tt.status, w.Body)
+				}
+			} else if p := problemOf(t, w, tt.status); p.Detail != tt.wantDetail {
+				t.Errorf("got detail %q, want %q", p.Detail, tt.wantDetail)
+			}
+			if restored := pg.ran("SET deleted_at = NULL"); restored != tt.restored {
+				t.Errorf("restore ran = %v, want %v", restored, tt.restored)
+			}
+		})
+	}
+}
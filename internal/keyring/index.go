@@ -0,0 +1,99 @@
+package keyring
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// termSize is how many bytes of the HMAC a term keeps: plenty to tell words
+// apart, short enough for a GIN index.
+const termSize = 16
+
+// Index is a blind index over sealed text: every word becomes a keyed hash,
+// so the database can find notes containing a word without learning the
+// word. Words must match exactly, after lower-casing; there is no stemming
+// and no prefix match.
+type Index struct {
+	key []byte
+}
+
+// NewIndex builds an index from a base64 key of 32 random bytes. Changing
+// the key makes every stored term useless until the notes are reindexed.
+func NewIndex(encoded string) (*Index, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("index key is not valid base64")
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("index key must be %d bytes, got %d", keySize, len(key))
+	}
+	return &Index{key: key}, nil
+}
+
+// Terms hashes the distinct words of text, sorted. Empty text has no terms,
+// but the slice is never nil, so it is stored as an empty array.
+func (ix *Index) Terms(text string) []string {
+	terms := []string{}
+	for _, word := range Words(text) {
+		terms = append(terms, ix.term(word))
+	}
+	slices.Sort(terms)
+	return slices.Compact(terms)
+}
+
+// QueryWords splits a web search query into the words it asks for and the
+// words it excludes (-word), lower-cased and each given once. The "or"
+// operator is left out.
+func QueryWords(query string) (want, exclude []string) {
+	for _, field := range strings.Fields(query) {
+		field = strings.TrimLeft(field, `"`)
+		if strings.EqualFold(field, "or") {
+			continue
+		}
+		excluded := strings.HasPrefix(field, "-")
+		for _, word := range Words(field) {
+			if excluded {
+				exclude = appendNew(exclude, word)
+			} else {
+				want = appendNew(want, word)
+			}
+		}
+	}
+	return want, exclude
+}
+
+func appendNew(words []string, word string) []string {
+	if slices.Contains(words, word) {
+		return words
+	}
+	return append(words, word)
+}
+
+// Query hashes words one by one, keeping their order, so the terms line up
+// with the words QueryWords returns.
+func (ix *Index) Query(words []string) []string {
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = ix.term(word)
+	}
+	return terms
+}
+
+func (ix *Index) term(word string) string {
+	mac := hmac.New(sha256.New, ix.key)
+	mac.Write([]byte(word))
+	return encoding.EncodeToString(mac.Sum(nil)[:termSize])
+}
+
+// Words splits text into lower-case runs of letters and digits, the words
+// the index knows.
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,29 @@
+package keyring
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryWords(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    []string
+		exclude []string
+	}{
+		{"Austria safehouse", []string{"austria", "safehouse"}, nil},
+		{`"safe house" -decoy`, []string{"safe", "house"}, []string{"decoy"}},
+		{"vienna or Salzburg", []string{"vienna", "salzburg"}, nil},
+		{"Vienna vienna -x -X", []string{"vienna"}, []string{"x"}},
+		{"-only", nil, []string{"only"}},
+		{"!!", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			want, exclude := QueryWords(tt.query)
+			if !reflect.DeepEqual(want, tt.want) || !reflect.DeepEqual(exclude, tt.exclude) {
+				t.Errorf("got %q and %q, want %q and %q", want, exclude, tt.want, tt.exclude)
+			}
+		})
+	}
+}
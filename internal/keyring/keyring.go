@@ -0,0 +1,184 @@
+// Package keyring encrypts short secrets, such as target notes, with
+// envelope encryption. Every value gets a fresh data key; the data key is
+// wrapped by a key-encryption key that is named in the sealed value, so keys
+// can be rotated without losing access to values sealed under older ones.
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// prefix marks a sealed value and its format version. A sealed value reads
+// prefix + key id + ":" + wrapped data key + ":" + ciphertext, both base64.
+const prefix = "sca1:"
+
+const keySize = 32
+
+var keyID = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,32}$`)
+
+var encoding = base64.RawURLEncoding
+
+// Keyring seals with the active key and opens with any key it holds.
+type Keyring struct {
+	keys   map[string]cipher.AEAD
+	active string
+}
+
+// Parse builds a keyring from "id:base64-key" entries, each key being 32
+// random bytes, and the id of the key new values are sealed with.
+func Parse(entries []string, active string) (*Keyring, error) {
+	if len(entries) == 0 {
+		return nil, errors.New("no keys given")
+	}
+
+	k := &Keyring{keys: make(map[string]cipher.AEAD, len(entries)), active: active}
+	for _, entry := range entries {
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || !keyID.MatchString(id) {
+			return nil, fmt.Errorf("key %q is not id:base64-key with an id of letters, digits, _, . or -", redact(entry))
+		}
+		if _, dup := k.keys[id]; dup {
+			return nil, fmt.Errorf("key %q is given twice", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q is not valid base64", id)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("key %q must be %d bytes, got %d", id, keySize, len(key))
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+	}
+
+	if _, ok := k.keys[active]; !ok {
+		return nil, fmt.Errorf("active key %q is not among the keys", active)
+	}
+	return k, nil
+}
+
+// Active is the id of the key new values are sealed with.
+func (k *Keyring) Active() string {
+	return k.active
+}
+
+// ActivePrefix starts every value sealed with the active key, which lets a
+// query find the values that still need rotating.
+func (k *Keyring) ActivePrefix() string {
+	return prefix + k.active + ":"
+}
+
+// Seal encrypts plaintext under a fresh data key wrapped by the active key.
+// The empty string stays empty.
+func (k *Keyring) Seal(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	dataKey := make([]byte, keySize)
+	rand.Read(dataKey)
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	header := prefix + k.active + ":"
+	wrapped := encoding.EncodeToString(seal(k.keys[k.active], dataKey, []byte(header)))
+	header += wrapped + ":"
+	ciphertext := encoding.EncodeToString(seal(data, []byte(plaintext), []byte(header)))
+	return header + ciphertext, nil
+}
+
+// Open decrypts a value sealed under any key of the keyring. Values that are
+// not sealed, written before encryption was enabled, are returned as they are.
+func (k *Keyring) Open(value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", errors.New("sealed value is malformed")
+	}
+	id, wrapped, ciphertext := parts[0], parts[1], parts[2]
+
+	kek, ok := k.keys[id]
+	if !ok {
+		return "", fmt.Errorf("sealed with unknown key %q", id)
+	}
+
+	header := prefix + id + ":"
+	dataKey, err := open(kek, wrapped, []byte(header))
+	if err != nil {
+		return "", fmt.Errorf("unwrap data key of key %q: %w", id, err)
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(data, ciphertext, []byte(header+wrapped+":"))
+	if err != nil {
+		return "", fmt.Errorf("decrypt value sealed with key %q: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+// IsSealed reports whether value was produced by Seal.
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// KeyID names the key value was sealed with, or "" if it is not sealed.
+func KeyID(value string) string {
+	if !IsSealed(value) {
+		return ""
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return id
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns the nonce followed by the ciphertext. crypto/rand.Read never
+// fails; it crashes the program instead.
+func seal(aead cipher.AEAD, plaintext, additional []byte) []byte {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	rand.Read(nonce)
+	return aead.Seal(nonce, nonce, plaintext, additional)
+}
+
+func open(aead cipher.AEAD, encoded string, additional []byte) ([]byte, error) {
+	raw, err := encoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("not valid base64")
+	}
+	if len(raw) < aead.NonceSize() {
+		return nil, errors.New("too short")
+	}
+	return aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], additional)
+}
+
+// redact keeps a malformed entry recognisable in errors without printing the
+// key material.
+func redact(entry string) string {
+	if id, _, ok := strings.Cut(entry, ":"); ok {
+		return id + ":***"
+	}
+	return "***"
+}
@@ -0,0 +1,219 @@
+package keyring
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	oldKey = "old:OpILpyRQhXwVnQXx/hf3S3LspYzzZDvnaPMujshesNQ="
+	newKey = "new:wxRysNCcRuz0VRh734lFEHNjdNbtI9JrkTUrL4yGf5U="
+)
+
+func mustParse(t *testing.T, entries []string, active string) *Keyring {
+	t.Helper()
+	k, err := Parse(entries, active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		active  string
+		wantErr string
+	}{
+		{"one key", []string{oldKey}, "old", ""},
+		{"two keys", []string{oldKey, newKey}, "new", ""},
+		{"no keys", nil, "old", "no keys given"},
+		{"no id", []string{"OpILpyRQhXwVnQXx"}, "old", `key "***" is not id:base64-key`},
+		{"bad id", []string{"a b:OpILpyRQhXwVnQXx/hf3S3LspYzzZDvnaPMujshesNQ="}, "a b", `key "a b:***" is not id:base64-key`},
+		{"twice", []string{oldKey, oldKey}, "old", `key "old" is given twice`},
+		{"not base64", []string{"old:!!"}, "old", `key "old" is not valid base64`},
+		{"short", []string{"old:c2hvcnQ="}, "old", `key "old" must be 32 bytes, got 5`},
+		{"inactive", []string{oldKey}, "new", `active key "new" is not among the keys`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.entries, tt.active)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("got %v, want no error", err)
+			case tt.wantErr != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.wantErr)):
+				t.Fatalf("got %v, want %q", err, tt.wantErr)
+			}
+			if err != nil && strings.Contains(err.Error(), "OpILpy") {
+				t.Errorf("error %q prints the key", err)
+			}
+		})
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	k := mustParse(t, []string{oldKey}, "old")
+	for _, plaintext := range []string{"", "Meets handler at the Prater, Tuesdays", "Ünïcödé 🐈"} {
+		sealed, err := k.Seal(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plaintext != "" && (!IsSealed(sealed) || strings.Contains(sealed, plaintext)) {
+			t.Errorf("Seal(%q) = %q, want it sealed", plaintext, sealed)
+		}
+		opened, err := k.Open(sealed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opened != plaintext {
+			t.Errorf("Open(Seal(%q)) = %q", plaintext, opened)
+		}
+	}
+
+	again, _ := k.Seal("same")
+	if once, _ := k.Seal("same"); once == again {
+		t.Error("sealing the same text twice gave the same value")
+	}
+}
+
+func TestOpenRefuses(t *testing.T) {
+	k := mustParse(t, []string{oldKey}, "old")
+	sealed, err := k.Seal("notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(sealed, ":")
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"malformed", prefix + "old:abc", "sealed value is malformed"},
+		{"unknown key", strings.Replace(sealed, ":old:", ":gone:", 1), `sealed with unknown key "gone"`},
+		{"bad wrapped key", strings.Join([]string{parts[0], parts[1], "AAAA", parts[3]}, ":"), `unwrap data key of key "old"`},
+		{"tampered", sealed[:len(sealed)-2] + "AA", `decrypt value sealed with key "old"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := k.Open(tt.value); err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("got %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenPassesPlainText(t *testing.T) {
+	k := mustParse(t, []string{oldKey}, "old")
+	got, err := k.Open("written before encryption")
+	if err != nil || got != "written before encryption" {
+		t.Errorf("got %q, %v, want the value unchanged", got, err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	before := mustParse(t, []string{oldKey}, "old")
+	sealed, err := before.Seal("safehouse on Praterstrasse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after := mustParse(t, []string{oldKey, newKey}, "new")
+	if after.Active() != "new" || after.ActivePrefix() != "sca1:new:" {
+		t.Errorf("got active %q and prefix %q", after.Active(), after.ActivePrefix())
+	}
+	if KeyID(sealed) != "old" || strings.HasPrefix(sealed, after.ActivePrefix()) {
+		t.Errorf("value %q should still need rotating", sealed)
+	}
+
+	opened, err := after.Open(sealed)
+	if err != nil || opened != "safehouse on Praterstrasse" {
+		t.Fatalf("got %q, %v, want the old value opened", opened, err)
+	}
+	resealed, err := after.Seal(opened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if KeyID(resealed) != "new" {
+		t.Errorf("resealed under %q, want new", KeyID(resealed))
+	}
+
+	retired := mustParse(t, []string{newKey}, "new")
+	if _, err := retired.Open(sealed); err == nil {
+		t.Error("a value sealed with a retired key was opened")
+	}
+	if _, err := retired.Open(resealed); err != nil {
+		t.Errorf("rotated value: %v", err)
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	tests := map[string]string{
+		"":                     "",
+		"plain notes":          "",
+		"sca1:old:wrapped:ct":  "old",
+		"sca1:k.2-b:wrapped:c": "k.2-b",
+	}
+	for value, want := range tests {
+		if got := KeyID(value); got != want {
+			t.Errorf("KeyID(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	ix, err := NewIndex("wxRysNCcRuz0VRh734lFEHNjdNbtI9JrkTUrL4yGf5U=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewIndex("OpILpyRQhXwVnQXx/hf3S3LspYzzZDvnaPMujshesNQ=")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	terms := ix.Terms("Safehouse, SAFEHOUSE; vienna-2")
+	if len(terms) != 3 {
+		t.Fatalf("got %d terms, want 3 distinct words: %q", len(terms), terms)
+	}
+	for _, tt := range []struct {
+		word  string
+		found bool
+	}{
+		{"safehouse", true},
+		{"vienna", true},
+		{"2", true},
+		{"safe", false},
+		{"salzburg", false},
+	} {
+		got := false
+		for _, term := range terms {
+			got = got || term == ix.Query([]string{tt.word})[0]
+		}
+		if got != tt.found {
+			t.Errorf("%q found = %v, want %v", tt.word, got, tt.found)
+		}
+	}
+
+	if got := ix.Query([]string{"vienna", "safehouse"}); got[0] != ix.term("vienna") || got[1] != ix.term("safehouse") {
+		t.Error("Query did not keep the order of the words")
+	}
+	if ix.term("vienna") == other.term("vienna") {
+		t.Error("two index keys hash a word the same")
+	}
+	if terms := ix.Terms(""); terms == nil || len(terms) != 0 {
+		t.Errorf("got %#v for empty text, want an empty slice", terms)
+	}
+}
+
+func TestNewIndexRefuses(t *testing.T) {
+	tests := map[string]string{
+		"!!":       "index key is not valid base64",
+		"c2hvcnQ=": "index key must be 32 bytes, got 5",
+	}
+	for encoded, want := range tests {
+		if _, err := NewIndex(encoded); err == nil || err.Error() != want {
+			t.Errorf("NewIndex(%q) = %v, want %q", encoded, err, want)
+		}
+	}
+}
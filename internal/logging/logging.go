@@ -0,0 +1,25 @@
+// Package logging carries a request-scoped logger in the request context,
+// so every layer a request passes through logs with its request ID.
+package logging
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type contextKey struct{}
+
+// With returns a copy of ctx that logs through entry.
+func With(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, entry)
+}
+
+// From returns the logger of ctx. Without one, as in background jobs, it
+// is the standard logger.
+func From(ctx context.Context) *logrus.Entry {
+	if entry, ok := ctx.Value(contextKey{}).(*logrus.Entry); ok {
+		return entry
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StatsSource returns the numbers behind the business gauges, one entry per
+// agency.
+type StatsSource interface {
+	Get(ctx context.Context) ([]models.AgencyStats, error)
+}
+
+type businessCollector struct {
+	source StatsSource
+
+	activeMissions   *prometheus.Desc
+	idleCats         *prometheus.Desc
+	targetsCompleted *prometheus.Desc
+}
+
+// RegisterBusiness exposes domain gauges. They are queried from the database on
+// every scrape, so they stay correct across restarts and replicas.
+func RegisterBusiness(source StatsSource) error {
+	return prometheus.Register(&businessCollector{
+		source:           source,
+		activeMissions:   prometheus.NewDesc(namespace+"_active_missions", "Missions that are not completed.", []string{"agency"}, nil),
+		idleCats:         prometheus.NewDesc(namespace+"_idle_cats", "Cats without an active mission.", []string{"agency"}, nil),
+		targetsCompleted: prometheus.NewDesc(namespace+"_targets_completed_today", "Targets completed since midnight (database time).", []string{"agency"}, nil),
+	})
+}
+
+func (c *businessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.activeMissions
+	ch <- c.idleCats
+	ch <- c.targetsCompleted
+}
+
+func (c *businessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stats, err := c.source.Get(ctx)
+	if err != nil {
+		logging.From(ctx).Error(err)
+		return
+	}
+
+	for _, s := range stats {
+		ch <- prometheus.MustNewConstMetric(c.activeMissions, prometheus.GaugeValue, float64(s.ActiveMissions), s.Agency)
+		ch <- prometheus.MustNewConstMetric(c.idleCats, prometheus.GaugeValue, float64(s.IdleCats), s.Agency)
+		ch <- prometheus.MustNewConstMetric(c.targetsCompleted, prometheus.GaugeValue, float64(s.TargetsCompletedToday), s.Agency)
+	}
+}
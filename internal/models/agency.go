@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Agency is a tenant. Cats, missions and everything hanging off them belong
+// to exactly one agency and are never visible to another.
+type Agency struct {
+	ID        uuid.UUID
+	Slug      string
+	Name      string
+	Settings  AgencySettings
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// AgencySettings override the service configuration for one agency; unset
+// fields fall back to the global value. Stored as JSONB with these keys.
+type AgencySettings struct {
+	SkillCheck         *string  `json:"skill_check,omitempty"`
+	RequireEvidence    *bool    `json:"require_evidence,omitempty"`
+	AttachmentMaxBytes *int64   `json:"attachment_max_bytes,omitempty"`
+	AttachmentTypes    []string `json:"attachment_types,omitempty"`
+}
+
+// AgencySummary is an agency with the size of its records, for
+// administration across agencies.
+type AgencySummary struct {
+	Agency
+	Cats           int
+	Missions       int
+	ActiveMissions int
+	Templates      int
+	BreedOverrides int
+}
+
+// BreedOverride renames a breed, or hides it from new cats, in one agency.
+type BreedOverride struct {
+	AgencyID uuid.UUID
+	BreedID  uuid.UUID
+	Name     *string
+	Hidden   bool
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RoleLead    = "lead"
+	RoleSupport = "support"
+	RoleBackup  = "backup"
+)
+
+var AssignmentRoles = []string{RoleLead, RoleSupport, RoleBackup}
+
+// Assignment puts a cat on a mission in a role. It is active until
+// UnassignedAt is set. CatID is nil once the cat has been deleted; CatName
+// keeps who it was.
+type Assignment struct {
+	ID             uuid.UUID
+	MissionID      uuid.UUID
+	CatID          *uuid.UUID
+	CatName        string
+	Role           string
+	AssignedAt     time.Time
+	AssignedBy     string
+	UnassignedAt   *time.Time
+	UnassignedBy   *string
+	UnassignReason *string
+}
+
+// CareerEntry is one mission in a cat's career.
+type CareerEntry struct {
+	Assignment
+	MissionTitle       string
+	MissionCompleted   bool
+	MissionCompletedAt *time.Time
+	// DurationSeconds runs from assignment until the cat was unassigned,
+	// the mission completed, or now, whichever came first.
+	DurationSeconds int64
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment is a file kept as evidence on a target. The file itself lives
+// in blob storage under StorageKey; SHA256 is the hex digest of its bytes.
+type Attachment struct {
+	ID          uuid.UUID
+	TargetID    uuid.UUID
+	Filename    string
+	ContentType string
+	SizeBytes   int64
+	SHA256      string
+	StorageKey  string `json:"-"`
+	UploadedBy  string
+	CreatedAt   time.Time
+}
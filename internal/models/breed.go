@@ -1,6 +1,11 @@
 package models
 
 import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,5 +15,130 @@ type Breed struct {
 	ID        uuid.UUID
 	Name      string
 	ApiID     string
+	Profile   *BreedProfile
 	CreatedAt time.Time
+	RemovedAt *time.Time
+}
+
+// BreedProfile is the TheCatAPI breed description. It is stored as JSONB with
+// the API's own keys, which are also the trait names used for filtering.
+type BreedProfile struct {
+	Origin           string      `json:"origin,omitempty"`
+	CountryCode      string      `json:"country_code,omitempty"`
+	Description      string      `json:"description,omitempty"`
+	Temperament      string      `json:"temperament,omitempty"`
+	LifeSpan         string      `json:"life_span,omitempty"`
+	AltNames         string      `json:"alt_names,omitempty"`
+	WikipediaURL     string      `json:"wikipedia_url,omitempty"`
+	Weight           BreedWeight `json:"weight"`
+	Adaptability     int         `json:"adaptability,omitempty"`
+	AffectionLevel   int         `json:"affection_level,omitempty"`
+	ChildFriendly    int         `json:"child_friendly,omitempty"`
+	DogFriendly      int         `json:"dog_friendly,omitempty"`
+	EnergyLevel      int         `json:"energy_level,omitempty"`
+	Grooming         int         `json:"grooming,omitempty"`
+	HealthIssues     int         `json:"health_issues,omitempty"`
+	Intelligence     int         `json:"intelligence,omitempty"`
+	SheddingLevel    int         `json:"shedding_level,omitempty"`
+	SocialNeeds      int         `json:"social_needs,omitempty"`
+	StrangerFriendly int         `json:"stranger_friendly,omitempty"`
+	Vocalisation     int         `json:"vocalisation,omitempty"`
+	Experimental     int         `json:"experimental"`
+	Hairless         int         `json:"hairless"`
+	Natural          int         `json:"natural"`
+	Rare             int         `json:"rare"`
+	Rex              int         `json:"rex"`
+	SuppressedTail   int         `json:"suppressed_tail"`
+	ShortLegs        int         `json:"short_legs"`
+	Hypoallergenic   int         `json:"hypoallergenic"`
+	Indoor           int         `json:"indoor"`
+	Lap              int         `json:"lap"`
+}
+
+type BreedWeight struct {
+	Imperial string `json:"imperial,omitempty"`
+	Metric   string `json:"metric,omitempty"`
+}
+
+// BreedChange says what a sync did to a single breed row.
+type BreedChange string
+
+const (
+	BreedCreated   BreedChange = "created"
+	BreedRenamed   BreedChange = "renamed"
+	BreedUpdated   BreedChange = "updated"
+	BreedRestored  BreedChange = "restored"
+	BreedUnchanged BreedChange = "unchanged"
+)
+
+type BreedSyncResult struct {
+	Source    string
+	Created   int
+	Renamed   int
+	Updated   int
+	Restored  int
+	Removed   int
+	Unchanged int
+	Failed    int
+}
+
+// BreedSync is the last breed sync that succeeded and where its breeds came
+// from: "api", "not_modified" or "snapshot".
+type BreedSync struct {
+	Source   string
+	SyncedAt time.Time
+}
+
+// TextTraits and NumericTraits are the profile keys cats can be filtered by.
+var (
+	TextTraits = []string{"origin", "country_code", "temperament", "life_span"}
+
+	NumericTraits = []string{
+		"adaptability", "affection_level", "child_friendly", "dog_friendly",
+		"energy_level", "grooming", "health_issues", "intelligence",
+		"shedding_level", "social_needs", "stranger_friendly", "vocalisation",
+		"experimental", "hairless", "natural", "rare", "rex",
+		"suppressed_tail", "short_legs", "hypoallergenic", "indoor", "lap",
+	}
+)
+
+// BreedTraitFilter is one condition such as "origin = Egypt" or
+// "intelligence >= 4". Text traits support =, != and ~ (contains, case
+// insensitive); numeric traits support =, !=, <, <=, > and >=.
+type BreedTraitFilter struct {
+	Trait   string
+	Op      string
+	Value   string
+	Numeric bool
+}
+
+var traitFilterPattern = regexp.MustCompile(`^\s*([a-z_]+)\s*(>=|<=|!=|=|>|<|~)\s*(.+?)\s*$`)
+
+func ParseBreedTraitFilter(expr string) (BreedTraitFilter, error) {
+	m := traitFilterPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return BreedTraitFilter{}, fmt.Errorf("trait filter %q must look like \"origin=Egypt\" or \"intelligence>=4\"", expr)
+	}
+
+	f := BreedTraitFilter{Trait: m[1], Op: m[2], Value: m[3]}
+
+	switch {
+	case slices.Contains(TextTraits, f.Trait):
+		if f.Op != "=" && f.Op != "!=" && f.Op != "~" {
+			return f, fmt.Errorf("trait %s only supports =, != and ~", f.Trait)
+		}
+	case slices.Contains(NumericTraits, f.Trait):
+		if f.Op == "~" {
+			return f, fmt.Errorf("trait %s does not support ~", f.Trait)
+		}
+		if _, err := strconv.Atoi(f.Value); err != nil {
+			return f, fmt.Errorf("trait %s needs a whole number, got %q", f.Trait, f.Value)
+		}
+		f.Numeric = true
+	default:
+		return f, fmt.Errorf("unknown trait %q, expected one of: %s, %s",
+			f.Trait, strings.Join(TextTraits, ", "), strings.Join(NumericTraits, ", "))
+	}
+
+	return f, nil
 }
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Classification is how sensitive a mission or target is, and, as a
+// clearance, the most sensitive level a principal or cat may see.
+type Classification string
+
+const (
+	Unclassified Classification = "unclassified"
+	Confidential Classification = "confidential"
+	Secret       Classification = "secret"
+	TopSecret    Classification = "top_secret"
+)
+
+// Classifications lists the levels from least to most sensitive.
+var Classifications = []Classification{Unclassified, Confidential, Secret, TopSecret}
+
+func (c Classification) Valid() bool {
+	return slices.Contains(Classifications, c)
+}
+
+// Covers reports whether a clearance of c may see data classified at level.
+// An unknown level is covered by nothing.
+func (c Classification) Covers(level Classification) bool {
+	have, need := slices.Index(Classifications, c), slices.Index(Classifications, level)
+	return have >= 0 && need >= 0 && have >= need
+}
+
+// Cleared lists the levels a clearance of c may see, from least to most
+// sensitive. An unknown clearance sees nothing.
+func (c Classification) Cleared() []Classification {
+	i := slices.Index(Classifications, c)
+	return Classifications[:i+1]
+}
+
+// Principal is an API caller: an operator, or the X-Actor a gateway vouches
+// for. Callers without a record are unclassified.
+type Principal struct {
+	Name      string
+	Clearance Classification
+	UpdatedAt time.Time
+}
+
+const (
+	AuditRead     = "read"
+	AuditWithheld = "withheld"
+)
+
+// AuditEntry records that a response carried classified data, or left it
+// out because the principal was not cleared for it.
+type AuditEntry struct {
+	ID             uuid.UUID
+	Principal      string
+	Clearance      Classification
+	Kind           string
+	ObjectID       uuid.UUID
+	Classification Classification
+	Outcome        string
+	Path           string
+	At             time.Time
+}
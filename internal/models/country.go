@@ -0,0 +1,91 @@
+package models
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// countriesJSON is ISO 3166-1 with common aliases. Migration 0012 seeds the
+// countries table and resolves existing targets from the same list.
+//
+//go:embed countries.json
+var countriesJSON []byte
+
+type Country struct {
+	Code    string   `json:"code"`
+	Alpha3  string   `json:"alpha3"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+var (
+	countries      []Country
+	countriesByKey = map[string]*Country{}
+
+	accentFolder = strings.NewReplacer(
+		"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a",
+		"ç", "c",
+		"è", "e", "é", "e", "ê", "e", "ë", "e",
+		"ì", "i", "í", "i", "î", "i", "ï", "i",
+		"ñ", "n",
+		"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o",
+		"ù", "u", "ú", "u", "û", "u", "ü", "u",
+		"ý", "y", "ÿ", "y",
+		"&", " and ",
+	)
+	nonAlnum = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+func init() {
+	if err := json.Unmarshal(countriesJSON, &countries); err != nil {
+		panic(fmt.Sprintf("bundled countries: %s", err))
+	}
+	for i := range countries {
+		c := &countries[i]
+		for _, name := range append([]string{c.Code, c.Alpha3, c.Name}, c.Aliases...) {
+			key := countryKey(name)
+			if other, ok := countriesByKey[key]; ok && other != c {
+				panic(fmt.Sprintf("bundled countries: %q names both %s and %s", name, other.Code, c.Code))
+			}
+			countriesByKey[key] = c
+		}
+	}
+}
+
+// countryKey folds case, accents and punctuation so "Côte d'Ivoire" and
+// "COTE D'IVOIRE" compare equal. The migration's country_key must match.
+func countryKey(value string) string {
+	key := accentFolder.Replace(strings.ToLower(value))
+	key = strings.TrimSpace(nonAlnum.ReplaceAllString(key, " "))
+	return strings.TrimPrefix(key, "the ")
+}
+
+// ResolveCountry maps a name, alpha-2 or alpha-3 code or common alias to
+// its ISO 3166-1 entry.
+func ResolveCountry(value string) (Country, bool) {
+	c, ok := countriesByKey[countryKey(value)]
+	if !ok {
+		return Country{}, false
+	}
+	return *c, true
+}
+
+// Countries lists every ISO 3166-1 country ordered by code.
+func Countries() []Country {
+	return slices.Clone(countries)
+}
+
+// UnresolvedCountry is a target whose country predates normalisation and
+// matched no ISO 3166-1 entry.
+type UnresolvedCountry struct {
+	TargetID  uuid.UUID
+	MissionID uuid.UUID
+	Name      string
+	Country   string
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	LeaveVacation = "vacation"
+	LeaveMedical  = "medical"
+	LeaveTraining = "training"
+)
+
+var LeaveKinds = []string{LeaveVacation, LeaveMedical, LeaveTraining}
+
+// Leave is a period, in whole days with both ends included, when a cat
+// cannot take missions.
+type Leave struct {
+	ID        uuid.UUID
+	CatID     uuid.UUID
+	Kind      string
+	StartsOn  time.Time
+	EndsOn    time.Time
+	Note      string
+	CreatedAt time.Time
+}
+
+// Overlaps reports whether the leave shares a day with the window. A nil
+// bound leaves the window open on that side.
+func (l Leave) Overlaps(start, end *time.Time) bool {
+	if end != nil && l.StartsOn.After(*end) {
+		return false
+	}
+	if start != nil && l.EndsOn.Before(*start) {
+		return false
+	}
+	return true
+}
+
+// BusyPeriod is one entry of a cat's availability calendar: either a leave
+// or a scheduled mission.
+type BusyPeriod struct {
+	Kind      string
+	StartsOn  *time.Time
+	EndsOn    *time.Time
+	LeaveID   *uuid.UUID `json:",omitempty"`
+	MissionID *uuid.UUID `json:",omitempty"`
+	Note      string     `json:",omitempty"`
+}
+
+type AvailabilityCalendar struct {
+	CatID uuid.UUID
+	From  time.Time
+	To    time.Time
+	Busy  []BusyPeriod
+}
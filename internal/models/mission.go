@@ -7,12 +7,24 @@ import (
 )
 
 type Mission struct {
-	ID            uuid.UUID
-	Title         string
-	Description   *string
+	ID          uuid.UUID
+	Title       string
+	Description *string
+	// AssignedCatID is the lead cat, kept for clients that predate
+	// Assignments.
 	AssignedCatID *uuid.UUID
-	Targets       []Target
-	Completed     bool
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	// Assignments are the cats currently on the mission.
+	Assignments []Assignment
+	Targets     []Target
+	Completed   bool
+	// Classification guards the description; see Withheld.
+	Classification Classification
+	// Withheld names the fields left empty because the caller is not
+	// cleared for the mission's classification.
+	Withheld []string
+	// ScheduledStart and ScheduledEnd bound, by day, when the mission runs.
+	ScheduledStart *time.Time
+	ScheduledEnd   *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
@@ -0,0 +1,39 @@
+package models
+
+import "github.com/google/uuid"
+
+const (
+	SearchMission = "mission"
+	SearchTarget  = "target"
+)
+
+// SearchQuery is a full-text search over missions and targets. Text uses
+// web search syntax: quoted phrases, OR and -word. An empty Kind and nil
+// filters match everything.
+type SearchQuery struct {
+	Text      string
+	Kind      string
+	Completed *bool
+	CatID     *uuid.UUID
+	Limit     int
+	// Clearance leaves out rows classified above it; the service sets it
+	// from the caller.
+	Clearance Classification
+}
+
+// SearchHit is one matching mission or target. Snippet is the matched text
+// with hits wrapped in <b></b>; MissionID is the mission itself for
+// missions and the owning mission for targets.
+type SearchHit struct {
+	Kind      string
+	ID        uuid.UUID
+	MissionID uuid.UUID
+	Title     string
+	Snippet   string
+	Completed bool
+	Rank      float32
+	// Classification is the hit's own; the snippet is withheld from callers
+	// not cleared for it.
+	Classification Classification
+	Withheld       []string
+}
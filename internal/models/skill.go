@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Skill struct {
+	ID        uuid.UUID
+	Code      string
+	Name      string
+	Category  string
+	CreatedAt time.Time
+}
+
+// CatSkill is a skill a cat has, rated 1 (basic) to 5 (expert).
+type CatSkill struct {
+	Skill       Skill
+	Proficiency int
+}
+
+// SkillRequirement is a skill a target needs, at MinProficiency or better.
+type SkillRequirement struct {
+	Skill          Skill
+	MinProficiency int
+}
+
+type RequirementCoverage struct {
+	TargetID       uuid.UUID
+	TargetName     string
+	Skill          string
+	MinProficiency int
+	CatProficiency int
+	Satisfied      bool
+}
+
+// SkillCoverage compares the requirements of a mission's open targets with
+// what a cat can do.
+type SkillCoverage struct {
+	MissionID    uuid.UUID
+	CatID        *uuid.UUID
+	Requirements []RequirementCoverage
+	Total        int
+	Covered      int
+	Complete     bool
+}
+
+const (
+	SkillCheckOff   = "off"
+	SkillCheckWarn  = "warn"
+	SkillCheckBlock = "block"
+)
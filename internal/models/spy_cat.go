@@ -1,29 +1,40 @@
 package models
 
 import (
-	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	CatActive    = "active"
+	CatSuspended = "suspended"
+	CatRetired   = "retired"
+)
+
+var CatStatuses = []string{CatActive, CatSuspended, CatRetired}
+
 type SpyCat struct {
-	ID        uuid.UUID
-	Name      string
-	ExpYears  int
-	Breed     Breed
-	Salary    float32
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID       uuid.UUID
+	Name     string
+	ExpYears int
+	Breed    Breed
+	Salary   float32
+	Skills   []CatSkill
+	// Status is active, suspended or retired; only active cats can be
+	// assigned to missions.
+	Status string
+	// Clearance is the most sensitive classification of mission the cat can
+	// be assigned to.
+	Clearance        Classification
+	RetiredAt        *time.Time
+	RetirementReason *string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
 }
 
-func (s SpyCat) Validate() error {
-	if s.Name == "" {
-		return errors.New("cat name cannot be empty")
-	}
-	if s.Salary < 0 {
-		return errors.New("salary cannot be negative")
-	}
-
-	return nil
+// CatFilter narrows cat listings. An empty Statuses hides retired cats.
+type CatFilter struct {
+	Traits   []BreedTraitFilter
+	Statuses []string
 }
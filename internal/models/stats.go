@@ -0,0 +1,9 @@
+package models
+
+type AgencyStats struct {
+	// Agency is the slug of the agency the numbers are for.
+	Agency                string
+	ActiveMissions        int
+	IdleCats              int
+	TargetsCompletedToday int
+}
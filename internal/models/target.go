@@ -11,8 +11,73 @@ type Target struct {
 	MissionID uuid.UUID
 	Name      string
 	Country   string
+	// Latitude and Longitude are WGS 84 degrees, both set or both nil.
+	Latitude  *float64
+	Longitude *float64
+	City      string
+	Address   string
 	Notes     string
 	Completed bool
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	// Classification guards the notes and attachments; see Withheld.
+	Classification Classification
+	// Withheld names the fields left empty because the caller is not
+	// cleared for the target's classification.
+	Withheld       []string
+	RequiredSkills []SkillRequirement
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// TargetLocation is where a target is; nil coordinates clear it.
+type TargetLocation struct {
+	Latitude  *float64
+	Longitude *float64
+	City      string
+	Address   string
+}
+
+// BoundingBox limits a proximity scan. Nil longitudes leave the longitude
+// unbounded.
+type BoundingBox struct {
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude *float64
+	MaxLongitude *float64
+}
+
+// NearbyTarget is a target found by a proximity query.
+type NearbyTarget struct {
+	Target
+	DistanceKm float64
+}
+
+// RouteStop is one target on a mission route. LegKm is the distance from
+// the previous stop, or from the start point for the first stop.
+type RouteStop struct {
+	TargetID  uuid.UUID
+	Name      string
+	Latitude  float64
+	Longitude float64
+	LegKm     float64
+}
+
+// MissionRoute visits a mission's located targets in the order with the
+// shortest total great-circle distance. Unlocated targets have no
+// coordinates and are left off the route.
+type MissionRoute struct {
+	MissionID      uuid.UUID
+	StartLatitude  *float64
+	StartLongitude *float64
+	Stops          []RouteStop
+	TotalKm        float64
+	Unlocated      []uuid.UUID
+}
+
+// NotesKeyUsage counts the target notes and template placeholder notes
+// sealed with one key. An empty KeyID counts notes still stored in plain
+// text.
+type NotesKeyUsage struct {
+	KeyID     string
+	Targets   int
+	Templates int
 }
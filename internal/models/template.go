@@ -0,0 +1,139 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MissionTemplate is the starting point of a mission that is run again and
+// again. Its targets are placeholders that are filled in, or overridden,
+// when a mission is created from it.
+type MissionTemplate struct {
+	ID          uuid.UUID
+	Name        string
+	Title       string
+	Description *string
+	Targets     []TemplateTarget
+	CreatedBy   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// TemplateTarget is a placeholder target. It is stored as JSONB with these
+// keys. Country may be left empty for the mission to fill in.
+type TemplateTarget struct {
+	Name            string           `json:"name"`
+	Country         string           `json:"country,omitempty"`
+	Notes           string           `json:"notes,omitempty"`
+	SuggestedSkills []SuggestedSkill `json:"suggested_skills,omitempty"`
+}
+
+// SuggestedSkill becomes a skill requirement of the target created from the
+// placeholder.
+type SuggestedSkill struct {
+	Skill          string `json:"skill"`
+	MinProficiency int    `json:"min_proficiency"`
+}
+
+// MissionOverrides replace what a template suggests. Targets[i] applies to
+// the template's i-th placeholder; entries past the last placeholder add
+// targets of their own.
+type MissionOverrides struct {
+	Title          *string
+	Description    *string
+	AssignedCatID  *uuid.UUID
+	ScheduledStart *time.Time
+	ScheduledEnd   *time.Time
+	// Classification applies to the mission and, by default, its targets.
+	Classification Classification
+	Targets        []TargetOverride
+}
+
+// TargetOverride changes the fields that are set. A nil RequiredSkills keeps
+// the suggested skills; Omit leaves the placeholder out of the mission.
+type TargetOverride struct {
+	Name           *string
+	Country        *string
+	Notes          *string
+	Latitude       *float64
+	Longitude      *float64
+	City           *string
+	Address        *string
+	RequiredSkills []SkillRequirement
+	Omit           bool
+}
+
+// Instantiate builds the mission and targets the template describes, with
+// the overrides applied. The result still has to pass mission validation.
+func (t *MissionTemplate) Instantiate(overrides MissionOverrides) (Mission, []Target) {
+	mission := Mission{
+		Title:          t.Title,
+		Description:    t.Description,
+		AssignedCatID:  overrides.AssignedCatID,
+		ScheduledStart: overrides.ScheduledStart,
+		ScheduledEnd:   overrides.ScheduledEnd,
+		Classification: overrides.Classification,
+	}
+	if overrides.Title != nil {
+		mission.Title = *overrides.Title
+	}
+	if overrides.Description != nil {
+		mission.Description = overrides.Description
+	}
+
+	var targets []Target
+	for i := 0; i < max(len(t.Targets), len(overrides.Targets)); i++ {
+		var target Target
+		if i < len(t.Targets) {
+			placeholder := t.Targets[i]
+			target = Target{
+				Name:           placeholder.Name,
+				Country:        placeholder.Country,
+				Notes:          placeholder.Notes,
+				RequiredSkills: make([]SkillRequirement, len(placeholder.SuggestedSkills)),
+			}
+			for j, skill := range placeholder.SuggestedSkills {
+				target.RequiredSkills[j] = SkillRequirement{
+					Skill:          Skill{Code: skill.Skill},
+					MinProficiency: skill.MinProficiency,
+				}
+			}
+		}
+		if i < len(overrides.Targets) {
+			override := overrides.Targets[i]
+			if override.Omit {
+				continue
+			}
+			override.apply(&target)
+		}
+		targets = append(targets, target)
+	}
+
+	return mission, targets
+}
+
+func (o TargetOverride) apply(target *Target) {
+	if o.Name != nil {
+		target.Name = *o.Name
+	}
+	if o.Country != nil {
+		target.Country = *o.Country
+	}
+	if o.Notes != nil {
+		target.Notes = *o.Notes
+	}
+	if o.Latitude != nil || o.Longitude != nil {
+		target.Latitude = o.Latitude
+		target.Longitude = o.Longitude
+	}
+	if o.City != nil {
+		target.City = *o.City
+	}
+	if o.Address != nil {
+		target.Address = *o.Address
+	}
+	if o.RequiredSkills != nil {
+		target.RequiredSkills = o.RequiredSkills
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TrashMission = "mission"
+	TrashTarget  = "target"
+)
+
+// TrashItem is a soft-deleted mission or target waiting to be restored or
+// purged. MissionID is set for targets only.
+type TrashItem struct {
+	Kind      string
+	ID        uuid.UUID
+	MissionID *uuid.UUID
+	Name      string
+	DeletedAt time.Time
+}
+
+// PurgeResult counts the rows a purge removed for good. Targets of a purged
+// mission go with it and are not counted separately; attachments are.
+type PurgeResult struct {
+	Missions    int64
+	Targets     int64
+	Attachments int64
+}
@@ -0,0 +1,25 @@
+// Package principal carries the caller a request acts as, with its
+// clearance, in the request context.
+package principal
+
+import (
+	"context"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+type contextKey struct{}
+
+// With returns a copy of ctx that acts as p.
+func With(ctx context.Context, p models.Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// From returns the principal ctx acts as. Without one it is an anonymous
+// caller with no clearance beyond unclassified.
+func From(ctx context.Context) models.Principal {
+	if p, ok := ctx.Value(contextKey{}).(models.Principal); ok {
+		return p
+	}
+	return models.Principal{Name: "anonymous", Clearance: models.Unclassified}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+)
+
+var (
+	errAgencyNotFound        = fault.NotFoundf("agency not found")
+	errBreedOverrideNotFound = fault.NotFoundf("breed override not found")
+
+	agencySlug = regexp.MustCompile(`^[a-z][a-z0-9-]{0,62}$`)
+)
+
+// agency administers the agencies. Its methods work across agencies and
+// take the agency they act on explicitly, never from the context.
+type agency struct {
+	db db.DB
+}
+
+func newAgency(db db.DB) *agency {
+	return &agency{
+		db: db,
+	}
+}
+
+func (s *agency) Create(ctx context.Context, a models.Agency) (*models.Agency, error) {
+	ctx, span := tracing.Start(ctx, "Agency.Create")
+	defer span.End()
+
+	a.Slug = strings.ToLower(strings.TrimSpace(a.Slug))
+	a.Name = strings.TrimSpace(a.Name)
+	if !agencySlug.MatchString(a.Slug) {
+		return nil, fault.Conflictf("agency slug %q must start with a letter and hold only a-z, 0-9 and -", a.Slug)
+	}
+	if a.Name == "" {
+		return nil, fault.Conflictf("agency name cannot be empty")
+	}
+	if err := checkAgencySettings(a.Settings); err != nil {
+		return nil, err
+	}
+	return s.db.Agency.Create(ctx, a)
+}
+
+func (s *agency) GetAll(ctx context.Context) ([]models.AgencySummary, error) {
+	ctx, span := tracing.Start(ctx, "Agency.GetAll")
+	defer span.End()
+
+	return s.db.Agency.GetAll(ctx)
+}
+
+func (s *agency) GetBySlug(ctx context.Context, slug string) (*models.Agency, error) {
+	ctx, span := tracing.Start(ctx, "Agency.GetBySlug")
+	defer span.End()
+
+	a, err := s.db.Agency.GetBySlug(ctx, strings.ToLower(strings.TrimSpace(slug)))
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, errAgencyNotFound
+	}
+	return a, nil
+}
+
+// UpdateSettings replaces the agency's settings. They apply to requests
+// that start after the change.
+func (s *agency) UpdateSettings(ctx context.Context, slug string, settings models.AgencySettings) (*models.Agency, error) {
+	ctx, span := tracing.Start(ctx, "Agency.UpdateSettings")
+	defer span.End()
+
+	if err := checkAgencySettings(settings); err != nil {
+		return nil, err
+	}
+	a, err := s.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.db.Agency.UpdateSettings(ctx, a.ID, settings)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, errAgencyNotFound
+	}
+	return updated, nil
+}
+
+func (s *agency) GetBreedOverrides(ctx context.Context, slug string) ([]models.BreedOverride, error) {
+	ctx, span := tracing.Start(ctx, "Agency.GetBreedOverrides")
+	defer span.End()
+
+	a, err := s.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return s.db.Agency.GetBreedOverrides(ctx, a.ID)
+}
+
+// SetBreedOverride renames the breed for the agency, or hides it from the
+// agency's breed list and new cats. Cats that already have it keep it.
+func (s *agency) SetBreedOverride(ctx context.Context, slug string, override models.BreedOverride) (*models.BreedOverride, error) {
+	ctx, span := tracing.Start(ctx, "Agency.SetBreedOverride")
+	defer span.End()
+
+	a, err := s.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if override.Name != nil {
+		name := strings.TrimSpace(*override.Name)
+		if name == "" {
+			return nil, fault.Conflictf("breed override name cannot be empty")
+		}
+		override.Name = &name
+	}
+	override.AgencyID = a.ID
+	if err := s.db.Agency.SetBreedOverride(ctx, override); err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+func (s *agency) DeleteBreedOverride(ctx context.Context, slug string, breedID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "Agency.DeleteBreedOverride")
+	defer span.End()
+
+	a, err := s.GetBySlug(ctx, slug)
+	if err != nil {
+		return err
+	}
+	found, err := s.db.Agency.DeleteBreedOverride(ctx, a.ID, breedID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errBreedOverrideNotFound
+	}
+	return nil
+}
+
+// checkAgencySettings applies the rules of the matching configuration keys.
+func checkAgencySettings(settings models.AgencySettings) error {
+	if mode := settings.SkillCheck; mode != nil {
+		switch *mode {
+		case models.SkillCheckOff, models.SkillCheckWarn, models.SkillCheckBlock:
+		default:
+			return fault.Conflictf("skill check must be off, warn or block, got %q", *mode)
+		}
+	}
+	if limit := settings.AttachmentMaxBytes; limit != nil && *limit < 1 {
+		return fault.Conflictf("attachment max bytes must be positive, got %d", *limit)
+	}
+	for _, t := range settings.AttachmentTypes {
+		if major, minor, ok := strings.Cut(t, "/"); !ok || major == "" || major == "*" || minor == "" {
+			return fault.Conflictf("attachment type %q is not a content type like image/png or image/*", t)
+		}
+	}
+	return nil
+}
+
+// settingsOf returns the settings of the agency ctx acts for; unset fields
+// fall back to the service configuration.
+func settingsOf(ctx context.Context) models.AgencySettings {
+	a, _ := tenant.Agency(ctx)
+	return a.Settings
+}
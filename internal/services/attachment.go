@@ -0,0 +1,255 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/storage"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+)
+
+// sniffLen is how much of a file http.DetectContentType looks at.
+const sniffLen = 512
+
+const maxFilenameBytes = 255
+
+var errAttachmentNotFound = fault.NotFoundf("attachment not found")
+
+// Uploads fail with these when the content itself is refused.
+var (
+	ErrAttachmentEmpty    = errors.New("attachment is empty")
+	ErrAttachmentType     = errors.New("attachment type is not accepted")
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+)
+
+type attachment struct {
+	db       db.DB
+	blobs    storage.Blob
+	maxBytes int64
+	types    []string
+}
+
+func newAttachment(db db.DB, blobs storage.Blob, maxBytes int64, types []string) *attachment {
+	return &attachment{
+		db:       db,
+		blobs:    blobs,
+		maxBytes: maxBytes,
+		types:    types,
+	}
+}
+
+// Create stores the file read from r on the target. The content type is
+// sniffed from the bytes, not taken from the client, and must be accepted;
+// the file may not exceed the size limit.
+func (s *attachment) Create(ctx context.Context, targetID uuid.UUID, filename string, r io.Reader, by string) (*models.Attachment, error) {
+	ctx, span := tracing.Start(ctx, "Attachment.Create")
+	defer span.End()
+
+	if _, err := targetWritable(ctx, s.db, targetID); err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrAttachmentEmpty
+	}
+	head = head[:n]
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !s.accepts(ctx, contentType) {
+		return nil, fmt.Errorf("%w: %s", ErrAttachmentType, contentType)
+	}
+
+	attachment := models.Attachment{
+		ID:          uuid.New(),
+		TargetID:    targetID,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		UploadedBy:  by,
+	}
+	attachment.StorageKey = targetID.String() + "/" + attachment.ID.String()
+
+	body := &checkedReader{
+		r:     io.MultiReader(bytes.NewReader(head), r),
+		hash:  sha256.New(),
+		limit: s.MaxBytes(ctx),
+	}
+	if err := s.blobs.Put(ctx, attachment.StorageKey, body); err != nil {
+		return nil, err
+	}
+	attachment.SizeBytes = body.n
+	attachment.SHA256 = hex.EncodeToString(body.hash.Sum(nil))
+
+	created, err := s.db.Attachment.Create(ctx, attachment)
+	if err != nil {
+		s.deleteBlob(ctx, attachment.StorageKey)
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *attachment) GetByTarget(ctx context.Context, targetID uuid.UUID) ([]models.Attachment, error) {
+	ctx, span := tracing.Start(ctx, "Attachment.GetByTarget")
+	defer span.End()
+
+	if err := s.targetExists(ctx, targetID); err != nil {
+		return nil, err
+	}
+	return s.db.Attachment.GetByTarget(ctx, targetID)
+}
+
+// Open returns the attachment with its content; the caller closes it.
+func (s *attachment) Open(ctx context.Context, targetID, id uuid.UUID) (*models.Attachment, io.ReadCloser, error) {
+	ctx, span := tracing.Start(ctx, "Attachment.Open")
+	defer span.End()
+
+	if err := s.targetExists(ctx, targetID); err != nil {
+		return nil, nil, err
+	}
+	attachment, err := s.db.Attachment.GetByID(ctx, targetID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if attachment == nil {
+		return nil, nil, errAttachmentNotFound
+	}
+
+	content, err := s.blobs.Get(ctx, attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, fault.NotFoundf("attachment %s: %w", id, err)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("attachment %s: %w", id, err)
+	}
+	return attachment, content, nil
+}
+
+// Delete removes an attachment from a target that is not completed.
+func (s *attachment) Delete(ctx context.Context, targetID, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "Attachment.Delete")
+	defer span.End()
+
+	if _, err := targetWritable(ctx, s.db, targetID); err != nil {
+		return err
+	}
+	attachment, err := s.db.Attachment.GetByID(ctx, targetID, id)
+	if err != nil {
+		return err
+	}
+	if attachment == nil {
+		return errAttachmentNotFound
+	}
+
+	deleted, err := s.db.Attachment.Delete(ctx, targetID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errAttachmentNotFound
+	}
+	s.deleteBlob(ctx, attachment.StorageKey)
+	return nil
+}
+
+func (s *attachment) targetExists(ctx context.Context, targetID uuid.UUID) error {
+	target, err := s.db.Target.GetByID(ctx, targetID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return errTargetNotFound
+	}
+	return nil
+}
+
+// MaxBytes is the size limit of one attachment for the agency ctx acts for.
+func (s *attachment) MaxBytes(ctx context.Context) int64 {
+	ctx, span := tracing.Start(ctx, "Attachment.MaxBytes")
+	defer span.End()
+
+	if limit := settingsOf(ctx).AttachmentMaxBytes; limit != nil {
+		return *limit
+	}
+	return s.maxBytes
+}
+
+func (s *attachment) accepts(ctx context.Context, contentType string) bool {
+	types := s.types
+	if agencyTypes := settingsOf(ctx).AttachmentTypes; len(agencyTypes) > 0 {
+		types = agencyTypes
+	}
+	major, _, _ := strings.Cut(contentType, "/")
+	for _, t := range types {
+		if t == contentType || t == major+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteBlob removes a blob whose record is gone; failing only wastes space.
+func (s *attachment) deleteBlob(ctx context.Context, key string) {
+	if err := s.blobs.Delete(ctx, key); err != nil {
+		logging.From(ctx).Errorf("delete attachment blob %s: %s", key, err)
+	}
+}
+
+// checkedReader hashes and counts what passes through and fails once more
+// than limit bytes have been read, so an oversized upload is never stored.
+type checkedReader struct {
+	r     io.Reader
+	hash  hash.Hash
+	n     int64
+	limit int64
+}
+
+func (c *checkedReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	c.hash.Write(p[:n])
+	if c.n > c.limit {
+		return n, fmt.Errorf("%w: it exceeds the %d byte limit", ErrAttachmentTooLarge, c.limit)
+	}
+	return n, err
+}
+
+// cleanFilename keeps the base name of a client-supplied file name without
+// control characters, capped at 255 bytes.
+func cleanFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+	if name == "" || name == "." || name == "/" || name == ".." {
+		return "attachment"
+	}
+	for len(name) > maxFilenameBytes {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type audit struct {
+	db db.DB
+}
+
+func newAudit(db db.DB) *audit {
+	return &audit{
+		db: db,
+	}
+}
+
+// Record stores the entries of one response. The caller must not release
+// classified data when it fails.
+func (s *audit) Record(ctx context.Context, entries []models.AuditEntry) error {
+	ctx, span := tracing.Start(ctx, "Audit.Record")
+	defer span.End()
+
+	if len(entries) == 0 {
+		return nil
+	}
+	return s.db.Audit.Record(ctx, entries)
+}
+
+func (s *audit) GetAll(ctx context.Context, limit int) ([]models.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "Audit.GetAll")
+	defer span.End()
+
+	if limit == 0 {
+		limit = defaultAuditLimit
+	}
+	if limit < 1 || limit > maxAuditLimit {
+		return nil, fault.Conflictf("audit limit must be between 1 and %d", maxAuditLimit)
+	}
+	return s.db.Audit.GetAll(ctx, limit)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/mksmstpck/spy_cat_agency/internal/db"
 	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
 )
 
 type breed struct {
@@ -18,13 +19,43 @@ func newBreed(db db.DB) *breed {
 }
 
 func (s *breed) Create(ctx context.Context, breed models.Breed) (*models.Breed, error) {
+	ctx, span := tracing.Start(ctx, "Breed.Create")
+	defer span.End()
+
 	return s.db.Breed.Create(ctx, breed)
 }
 
 func (s *breed) GetAll(ctx context.Context) ([]models.Breed, error) {
+	ctx, span := tracing.Start(ctx, "Breed.GetAll")
+	defer span.End()
+
 	return s.db.Breed.GetAll(ctx)
 }
 
 func (s *breed) GetByName(ctx context.Context, name string) (*models.Breed, error) {
+	ctx, span := tracing.Start(ctx, "Breed.GetByName")
+	defer span.End()
+
 	return s.db.Breed.GetByName(ctx, name)
 }
+
+func (s *breed) Upsert(ctx context.Context, breed models.Breed) (*models.Breed, models.BreedChange, error) {
+	ctx, span := tracing.Start(ctx, "Breed.Upsert")
+	defer span.End()
+
+	return s.db.Breed.Upsert(ctx, breed)
+}
+
+func (s *breed) MarkRemoved(ctx context.Context, keep []string) (int64, error) {
+	ctx, span := tracing.Start(ctx, "Breed.MarkRemoved")
+	defer span.End()
+
+	return s.db.Breed.MarkRemoved(ctx, keep)
+}
+
+func (s *breed) MarkSynced(ctx context.Context, source string) error {
+	ctx, span := tracing.Start(ctx, "Breed.MarkSynced")
+	defer span.End()
+
+	return s.db.Breed.MarkSynced(ctx, source)
+}
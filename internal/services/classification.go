@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/principal"
+)
+
+// levelNames lists the classifications for error messages.
+var levelNames = func() string {
+	names := make([]string, len(models.Classifications))
+	for i, c := range models.Classifications {
+		names[i] = string(c)
+	}
+	return strings.Join(names, ", ")
+}()
+
+// checkLevel validates a classification or clearance the caller is setting.
+// Empty means fallback. Nobody can set a level above their own clearance.
+func checkLevel(ctx context.Context, level, fallback models.Classification) (models.Classification, error) {
+	if level == "" {
+		level = fallback
+	}
+	if !level.Valid() {
+		return "", fault.Conflictf("classification must be one of %s, got %q", levelNames, level)
+	}
+	if err := checkCleared(ctx, level); err != nil {
+		return "", err
+	}
+	return level, nil
+}
+
+// checkCleared refuses callers whose clearance does not cover level.
+func checkCleared(ctx context.Context, level models.Classification) error {
+	if p := principal.From(ctx); !p.Clearance.Covers(level) {
+		return fault.Forbiddenf("%s is not cleared for %s", p.Name, level)
+	}
+	return nil
+}
+
+// targetWritable loads a live target the caller is cleared to change.
+func targetWritable(ctx context.Context, db db.DB, id uuid.UUID) (*models.Target, error) {
+	target, err := db.Target.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, errTargetNotFound
+	}
+	if err := checkCleared(ctx, target.Classification); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// missionWritable loads a live mission the caller is cleared to change.
+func missionWritable(ctx context.Context, db db.DB, id uuid.UUID) (*models.Mission, error) {
+	mission, err := db.Mission.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if mission == nil {
+		return nil, errMissionNotFound
+	}
+	if err := checkCleared(ctx, mission.Classification); err != nil {
+		return nil, err
+	}
+	return mission, nil
+}
+
+// checkRestorable refuses to take something out of the trash the caller is
+// not cleared for: a target, or a mission with the targets it brings back.
+func checkRestorable(ctx context.Context, db db.DB, kind string, id uuid.UUID, notFound error) error {
+	levels, err := db.Trash.Levels(ctx, kind, id)
+	if err != nil {
+		return err
+	}
+	if len(levels) == 0 {
+		return notFound
+	}
+	for _, level := range levels {
+		if err := checkCleared(ctx, level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// missionLevel is the highest classification of the mission and its
+// targets, the clearance a cat needs to work it.
+func missionLevel(mission *models.Mission, targets []models.Target) models.Classification {
+	level := mission.Classification
+	for _, t := range targets {
+		if !level.Covers(t.Classification) {
+			level = t.Classification
+		}
+	}
+	return level
+}
+
+// checkCatClearance refuses to put a cat on work classified above its
+// clearance.
+func checkCatClearance(ctx context.Context, db db.DB, catID uuid.UUID, level models.Classification) error {
+	cat, err := db.SpyCat.GetByID(ctx, catID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errCatNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !cat.Clearance.Covers(level) {
+		return fault.Conflictf("cat %s has clearance %s, below the %s mission", cat.Name, cat.Clearance, level)
+	}
+	return nil
+}
+
+// checkTeamClearance refuses to raise a mission's level above the clearance
+// of a cat already on it.
+func checkTeamClearance(ctx context.Context, db db.DB, mission *models.Mission, level models.Classification) error {
+	for _, a := range mission.Assignments {
+		if a.CatID == nil {
+			continue
+		}
+		if err := checkCatClearance(ctx, db, *a.CatID, level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activeMissionLevel is the clearance the cat's current missions need.
+func activeMissionLevel(ctx context.Context, db db.DB, catID uuid.UUID) (models.Classification, error) {
+	career, err := db.Mission.GetCareer(ctx, catID)
+	if err != nil {
+		return "", err
+	}
+
+	level := models.Unclassified
+	for _, entry := range career {
+		if entry.UnassignedAt != nil || entry.MissionCompleted {
+			continue
+		}
+		mission, err := db.Mission.GetByID(ctx, entry.MissionID)
+		if err != nil {
+			return "", err
+		}
+		if mission == nil {
+			continue
+		}
+		if m := missionLevel(mission, mission.Targets); !level.Covers(m) {
+			level = m
+		}
+	}
+	return level, nil
+}
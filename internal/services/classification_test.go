@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/principal"
+)
+
+func as(clearance models.Classification) context.Context {
+	return principal.With(context.Background(), models.Principal{Name: "handler", Clearance: clearance})
+}
+
+func TestCheckLevel(t *testing.T) {
+	tests := []struct {
+		name      string
+		clearance models.Classification
+		level     models.Classification
+		fallback  models.Classification
+		want      models.Classification
+		kind      fault.Kind
+	}{
+		{"fallback", models.Unclassified, "", models.Unclassified, models.Unclassified, 0},
+		{"below clearance", models.Secret, models.Confidential, models.Unclassified, models.Confidential, 0},
+		{"at clearance", models.Secret, models.Secret, models.Unclassified, models.Secret, 0},
+		{"above clearance", models.Confidential, models.Secret, models.Unclassified, "", fault.Forbidden},
+		{"fallback above clearance", models.Unclassified, "", models.Secret, "", fault.Forbidden},
+		{"unknown level", models.TopSecret, "cosmic", models.Unclassified, "", fault.Conflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkLevel(as(tt.clearance), tt.level, tt.fallback)
+			if tt.kind == 0 {
+				if err != nil || got != tt.want {
+					t.Errorf("got %q, %v, want %q", got, err, tt.want)
+				}
+				return
+			}
+			if !fault.Is(err, tt.kind) {
+				t.Errorf("got %q, %v, want an error of kind %d", got, err, tt.kind)
+			}
+		})
+	}
+}
+
+func TestCheckCleared(t *testing.T) {
+	levels := models.Classifications
+	for i, clearance := range levels {
+		for j, level := range levels {
+			err := checkCleared(as(clearance), level)
+			if cleared := j <= i; cleared != (err == nil) {
+				t.Errorf("clearance %s, level %s: got %v, want cleared %v", clearance, level, err, cleared)
+			}
+			if err != nil && !fault.Is(err, fault.Forbidden) {
+				t.Errorf("clearance %s, level %s: %v is not forbidden", clearance, level, err)
+			}
+		}
+	}
+
+	// A request without a principal is anonymous and unclassified.
+	if err := checkCleared(context.Background(), models.Confidential); !fault.Is(err, fault.Forbidden) {
+		t.Errorf("anonymous caller: got %v, want forbidden", err)
+	}
+}
+
+func TestMissionLevel(t *testing.T) {
+	targets := func(levels ...models.Classification) []models.Target {
+		out := make([]models.Target, len(levels))
+		for i, level := range levels {
+			out[i].Classification = level
+		}
+		return out
+	}
+	tests := []struct {
+		name    string
+		mission models.Classification
+		targets []models.Target
+		want    models.Classification
+	}{
+		{"no targets", models.Confidential, nil, models.Confidential},
+		{"targets below", models.Secret, targets(models.Unclassified, models.Confidential), models.Secret},
+		{"target above", models.Unclassified, targets(models.Unclassified, models.TopSecret, models.Secret), models.TopSecret},
+		{"highest wins", models.Confidential, targets(models.Secret, models.Confidential), models.Secret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mission := &models.Mission{Classification: tt.mission}
+			if got := missionLevel(mission, tt.targets); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+)
+
+type country struct {
+	db db.DB
+}
+
+func newCountry(db db.DB) *country {
+	return &country{
+		db: db,
+	}
+}
+
+func (s *country) GetAll(ctx context.Context) ([]models.Country, error) {
+	ctx, span := tracing.Start(ctx, "Country.GetAll")
+	defer span.End()
+
+	return s.db.Country.GetAll(ctx)
+}
+
+// GetUnresolved lists targets whose free-text country the country migration
+// could not map to a code.
+func (s *country) GetUnresolved(ctx context.Context) ([]models.UnresolvedCountry, error) {
+	ctx, span := tracing.Start(ctx, "Country.GetUnresolved")
+	defer span.End()
+
+	return s.db.Country.GetUnresolved(ctx)
+}
+
+// resolveCountry returns the ISO 3166-1 alpha-2 code for free-form input.
+func resolveCountry(value string) (string, error) {
+	c, ok := models.ResolveCountry(value)
+	if !ok {
+		return "", fault.Conflictf("unknown country %q", value)
+	}
+	return c.Code, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"math"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
+)
+
+// earthRadiusKm is the mean Earth radius; great-circle distances on it are
+// within 0.5% of the true ellipsoidal distance.
+const earthRadiusKm = 6371.0088
+
+// maxRadiusKm is half the Earth's circumference: every point is closer.
+const maxRadiusKm = math.Pi * earthRadiusKm
+
+// checkCoordinates accepts no location or a valid WGS 84 point.
+func checkCoordinates(lat, lon *float64) error {
+	var r validate.Report
+	r.Coordinates("", lat, lon)
+	return r.Err()
+}
+
+// distanceKm is the haversine great-circle distance between two points.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	rad1, rad2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(rad1)*math.Cos(rad2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// boundingBox returns a box that contains every point within radiusKm of
+// the centre, dropping the longitude bounds when the box would cross the
+// antimeridian or reach a pole.
+func boundingBox(lat, lon, radiusKm float64) models.BoundingBox {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+	box := models.BoundingBox{
+		MinLatitude: math.Max(-90, lat-dLat),
+		MaxLatitude: math.Min(90, lat+dLat),
+	}
+	if box.MinLatitude == -90 || box.MaxLatitude == 90 {
+		return box
+	}
+
+	dLon := math.Asin(math.Min(1, math.Sin(radiusKm/earthRadiusKm)/math.Cos(lat*math.Pi/180))) * 180 / math.Pi
+	if lon-dLon < -180 || lon+dLon > 180 {
+		return box
+	}
+	minLon, maxLon := lon-dLon, lon+dLon
+	box.MinLongitude, box.MaxLongitude = &minLon, &maxLon
+	return box
+}
+
+// shortestRoute orders the stops for the shortest open path, starting at
+// start when given. Missions have at most three targets, so trying every
+// order is cheap.
+func shortestRoute(stops []models.RouteStop, startLat, startLon *float64) ([]models.RouteStop, float64) {
+	var best []models.RouteStop
+	bestKm := math.Inf(1)
+
+	order := make([]models.RouteStop, len(stops))
+	copy(order, stops)
+	permute(order, 0, func(candidate []models.RouteStop) {
+		km := 0.0
+		for i := range candidate {
+			if i > 0 {
+				km += distanceKm(candidate[i-1].Latitude, candidate[i-1].Longitude, candidate[i].Latitude, candidate[i].Longitude)
+			} else if startLat != nil {
+				km += distanceKm(*startLat, *startLon, candidate[i].Latitude, candidate[i].Longitude)
+			}
+		}
+		if km < bestKm {
+			bestKm = km
+			best = append(best[:0], candidate...)
+		}
+	})
+
+	for i := range best {
+		switch {
+		case i > 0:
+			best[i].LegKm = distanceKm(best[i-1].Latitude, best[i-1].Longitude, best[i].Latitude, best[i].Longitude)
+		case startLat != nil:
+			best[i].LegKm = distanceKm(*startLat, *startLon, best[i].Latitude, best[i].Longitude)
+		default:
+			best[i].LegKm = 0
+		}
+	}
+	if best == nil {
+		return []models.RouteStop{}, 0
+	}
+	return best, bestKm
+}
+
+func permute(stops []models.RouteStop, k int, visit func([]models.RouteStop)) {
+	if k == len(stops) {
+		visit(stops)
+		return
+	}
+	for i := k; i < len(stops); i++ {
+		stops[k], stops[i] = stops[i], stops[k]
+		permute(stops, k+1, visit)
+		stops[k], stops[i] = stops[i], stops[k]
+	}
+}
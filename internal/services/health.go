@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+const checkTimeout = 2 * time.Second
+
+type health struct {
+	db       db.DB
+	started  *atomic.Bool
+	stopping *atomic.Bool
+}
+
+func newHealth(db db.DB) *health {
+	return &health{
+		db:       db,
+		started:  &atomic.Bool{},
+		stopping: &atomic.Bool{},
+	}
+}
+
+// MarkStarted flips the instance out of its startup state once the boot work
+// (breed import and friends) is done.
+func (s *health) MarkStarted() {
+	s.started.Store(true)
+}
+
+// MarkStopping makes readiness fail so traffic is routed away while the
+// instance drains.
+func (s *health) MarkStopping() {
+	s.stopping.Store(true)
+}
+
+func (s *health) Started() bool {
+	return s.started.Load()
+}
+
+func (s *health) Startup() models.HealthReport {
+	return report(s.startupCheck())
+}
+
+// Readiness reports whether the instance can serve traffic: it has started,
+// the database answers, the schema is migrated and breeds have been synced
+// at least once, from TheCatAPI or the bundled snapshot.
+func (s *health) Readiness(ctx context.Context) models.HealthReport {
+	return report(
+		s.startupCheck(),
+		runCheck(ctx, "database", func(ctx context.Context) error {
+			return s.db.Health.Ping(ctx)
+		}),
+		runCheck(ctx, "schema", func(ctx context.Context) error {
+			version, dirty, err := s.db.Health.SchemaVersion(ctx)
+			if err != nil {
+				return err
+			}
+			if dirty {
+				return fmt.Errorf("schema version %d is dirty", version)
+			}
+			if version < db.SchemaVersion {
+				return fmt.Errorf("schema version %d, want %d", version, db.SchemaVersion)
+			}
+			return nil
+		}),
+		runCheck(ctx, "breeds", func(ctx context.Context) error {
+			sync, err := s.db.Health.LastBreedSync(ctx)
+			if err != nil {
+				return err
+			}
+			if sync == nil {
+				return errors.New("breeds never synced")
+			}
+			return nil
+		}),
+	)
+}
+
+func (s *health) startupCheck() models.HealthCheck {
+	check := models.HealthCheck{Name: "startup", Status: models.HealthOK}
+	switch {
+	case s.stopping.Load():
+		check.Status = models.HealthFail
+		check.Error = "shutting down"
+	case !s.Started():
+		check.Status = models.HealthFail
+		check.Error = "startup in progress"
+	}
+	return check
+}
+
+func runCheck(ctx context.Context, name string, fn func(ctx context.Context) error) models.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := fn(ctx)
+
+	check := models.HealthCheck{
+		Name:      name,
+		Status:    models.HealthOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		check.Status = models.HealthFail
+		check.Error = err.Error()
+	}
+	return check
+}
+
+func report(checks ...models.HealthCheck) models.HealthReport {
+	r := models.HealthReport{Status: models.HealthOK, Checks: checks}
+	for _, check := range checks {
+		if check.Status != models.HealthOK {
+			r.Status = models.HealthFail
+		}
+	}
+	return r
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+)
+
+// maxCalendarDays bounds the window of one availability request.
+const maxCalendarDays = 366
+
+var errLeaveNotFound = fault.NotFoundf("leave not found")
+
+type leave struct {
+	db db.DB
+}
+
+func newLeave(db db.DB) *leave {
+	return &leave{
+		db: db,
+	}
+}
+
+func (s *leave) Create(ctx context.Context, leave models.Leave) (*models.Leave, error) {
+	ctx, span := tracing.Start(ctx, "Leave.Create")
+	defer span.End()
+
+	if !slices.Contains(models.LeaveKinds, leave.Kind) {
+		return nil, fault.Conflictf("leave kind must be one of %s", strings.Join(models.LeaveKinds, ", "))
+	}
+	if leave.EndsOn.Before(leave.StartsOn) {
+		return nil, fault.Conflictf("leave cannot end before it starts")
+	}
+	if err := catExists(ctx, s.db, leave.CatID); err != nil {
+		return nil, err
+	}
+
+	return s.db.Leave.Create(ctx, leave)
+}
+
+func (s *leave) GetByCat(ctx context.Context, catID uuid.UUID) ([]models.Leave, error) {
+	ctx, span := tracing.Start(ctx, "Leave.GetByCat")
+	defer span.End()
+
+	if err := catExists(ctx, s.db, catID); err != nil {
+		return nil, err
+	}
+	return s.db.Leave.GetByCat(ctx, catID, nil, nil)
+}
+
+func (s *leave) Delete(ctx context.Context, catID, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "Leave.Delete")
+	defer span.End()
+
+	found, err := s.db.Leave.Delete(ctx, catID, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errLeaveNotFound
+	}
+	return nil
+}
+
+// Calendar lists, in start order, everything that keeps the cat busy between
+// from and to: its leave and its scheduled missions.
+func (s *leave) Calendar(ctx context.Context, catID uuid.UUID, from, to time.Time) (*models.AvailabilityCalendar, error) {
+	ctx, span := tracing.Start(ctx, "Leave.Calendar")
+	defer span.End()
+
+	if to.Before(from) {
+		return nil, fault.Conflictf("calendar cannot end before it starts")
+	}
+	if to.Sub(from) > maxCalendarDays*24*time.Hour {
+		return nil, fault.Conflictf("calendar cannot span more than %d days", maxCalendarDays)
+	}
+	if err := catExists(ctx, s.db, catID); err != nil {
+		return nil, err
+	}
+
+	leaves, err := s.db.Leave.GetByCat(ctx, catID, &from, &to)
+	if err != nil {
+		return nil, err
+	}
+	missions, err := s.db.Mission.GetScheduledByCat(ctx, catID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &models.AvailabilityCalendar{
+		CatID: catID,
+		From:  from,
+		To:    to,
+		Busy:  make([]models.BusyPeriod, 0, len(leaves)+len(missions)),
+	}
+	for _, l := range leaves {
+		calendar.Busy = append(calendar.Busy, models.BusyPeriod{
+			Kind:     l.Kind,
+			StartsOn: &l.StartsOn,
+			EndsOn:   &l.EndsOn,
+			LeaveID:  &l.ID,
+			Note:     l.Note,
+		})
+	}
+	for _, m := range missions {
+		calendar.Busy = append(calendar.Busy, models.BusyPeriod{
+			Kind:      "mission",
+			StartsOn:  m.ScheduledStart,
+			EndsOn:    m.ScheduledEnd,
+			MissionID: &m.ID,
+			Note:      m.Title,
+		})
+	}
+
+	slices.SortStableFunc(calendar.Busy, func(a, b models.BusyPeriod) int {
+		switch {
+		case a.StartsOn == nil && b.StartsOn == nil:
+			return 0
+		case a.StartsOn == nil:
+			return -1
+		case b.StartsOn == nil:
+			return 1
+		}
+		return a.StartsOn.Compare(*b.StartsOn)
+	})
+
+	return calendar, nil
+}
+
+// checkLeave refuses a cat for a mission window it is on leave during. An
+// unscheduled mission has no window and never conflicts.
+func checkLeave(ctx context.Context, db db.DB, catID uuid.UUID, start, end *time.Time) error {
+	if start == nil && end == nil {
+		return nil
+	}
+
+	leaves, err := db.Leave.GetByCat(ctx, catID, start, end)
+	if err != nil {
+		return err
+	}
+	if len(leaves) == 0 {
+		return nil
+	}
+
+	l := leaves[0]
+	return fault.Conflictf("cat is on %s leave from %s to %s during the mission window",
+		l.Kind, l.StartsOn.Format(time.DateOnly), l.EndsOn.Format(time.DateOnly))
+}
+
+func checkSchedule(start, end *time.Time) error {
+	if start != nil && end != nil && end.Before(*start) {
+		return fault.Conflictf("scheduled end cannot be before scheduled start")
+	}
+	return nil
+}
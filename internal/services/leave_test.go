@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+func day(value string) *time.Time {
+	d, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		panic(err)
+	}
+	return &d
+}
+
+func TestCheckSchedule(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end *time.Time
+		conflict   bool
+	}{
+		{"unscheduled", nil, nil, false},
+		{"start only", day("2026-03-01"), nil, false},
+		{"end only", nil, day("2026-03-01"), false},
+		{"one day", day("2026-03-01"), day("2026-03-01"), false},
+		{"in order", day("2026-03-01"), day("2026-03-09"), false},
+		{"end before start", day("2026-03-09"), day("2026-03-01"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSchedule(tt.start, tt.end)
+			if tt.conflict != fault.Is(err, fault.Conflict) || (!tt.conflict && err != nil) {
+				t.Errorf("got %v, want conflict %v", err, tt.conflict)
+			}
+		})
+	}
+}
+
+// The rules below are checked before anything is read, so they run with
+// no database behind the services.
+
+func TestCheckLeaveUnscheduled(t *testing.T) {
+	if err := checkLeave(context.Background(), db.DB{}, uuid.New(), nil, nil); err != nil {
+		t.Errorf("got %v, want an unscheduled mission to never conflict", err)
+	}
+}
+
+func TestLeaveCreateRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     string
+		starts   *time.Time
+		ends     *time.Time
+		wantText string
+	}{
+		{"unknown kind", "sabbatical", day("2026-03-01"), day("2026-03-02"), "leave kind must be one of vacation, medical, training"},
+		{"ends before it starts", models.LeaveVacation, day("2026-03-02"), day("2026-03-01"), "leave cannot end before it starts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newLeave(db.DB{})
+			_, err := s.Create(context.Background(), models.Leave{CatID: uuid.New(), Kind: tt.kind, StartsOn: *tt.starts, EndsOn: *tt.ends})
+			if !fault.Is(err, fault.Conflict) || err.Error() != tt.wantText {
+				t.Errorf("got %v, want the conflict %q", err, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestCalendarWindowRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to *time.Time
+		wantText string
+	}{
+		{"ends before it starts", day("2026-03-02"), day("2026-03-01"), "calendar cannot end before it starts"},
+		{"longer than a year", day("2026-01-01"), day("2027-01-03"), "calendar cannot span more than 366 days"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newLeave(db.DB{})
+			_, err := s.Calendar(context.Background(), uuid.New(), *tt.from, *tt.to)
+			if !fault.Is(err, fault.Conflict) || err.Error() != tt.wantText {
+				t.Errorf("got %v, want the conflict %q", err, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestLeaveOverlaps(t *testing.T) {
+	leave := models.Leave{StartsOn: *day("2026-03-10"), EndsOn: *day("2026-03-12")}
+	tests := []struct {
+		name       string
+		start, end *time.Time
+		want       bool
+	}{
+		{"open window", nil, nil, true},
+		{"before", day("2026-03-01"), day("2026-03-09"), false},
+		{"ends on its first day", day("2026-03-01"), day("2026-03-10"), true},
+		{"inside", day("2026-03-11"), day("2026-03-11"), true},
+		{"starts on its last day", day("2026-03-12"), day("2026-03-20"), true},
+		{"after", day("2026-03-13"), day("2026-03-20"), false},
+		{"open end", day("2026-03-13"), nil, false},
+		{"open start", nil, day("2026-03-10"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := leave.Overlaps(tt.start, tt.end); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
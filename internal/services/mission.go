@@ -3,55 +3,457 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
 	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
 )
 
+var errAssignmentNotFound = fault.NotFoundf("assignment not found")
+
 type mission struct {
-	db db.DB
+	db         db.DB
+	skillCheck string
 }
 
-func newMission(db db.DB) *mission {
+func newMission(db db.DB, skillCheck string) *mission {
 	return &mission{
-		db: db,
+		db:         db,
+		skillCheck: skillCheck,
 	}
 }
 
-func (s *mission) Create(ctx context.Context, mission models.Mission, targets []models.Target) (*models.Mission, error) {
-	if len(targets) < 1 || len(targets) > 3 {
-		return nil, errors.New("mission must have between 1 and 3 targets")
+// Create validates and stores a mission. by names who is creating it and is
+// recorded on the lead's assignment.
+func (s *mission) Create(ctx context.Context, mission models.Mission, targets []models.Target, by string) (*models.Mission, error) {
+	ctx, span := tracing.Start(ctx, "Mission.Create")
+	defer span.End()
+
+	if err := validate.Mission(&mission, targets); err != nil {
+		return nil, err
 	}
 
-	for _, target := range targets {
-		if target.Name == "" {
-			return nil, errors.New("target name cannot be empty")
+	for i := range targets {
+		if err := resolveRequirements(ctx, s.db, targets[i].RequiredSkills); err != nil {
+			return nil, err
 		}
-		if target.Country == "" {
-			return nil, errors.New("target country cannot be empty")
+	}
+
+	if err := checkSchedule(mission.ScheduledStart, mission.ScheduledEnd); err != nil {
+		return nil, err
+	}
+
+	// Targets default to the mission's classification.
+	level, err := checkLevel(ctx, mission.Classification, models.Unclassified)
+	if err != nil {
+		return nil, err
+	}
+	mission.Classification = level
+	for i := range targets {
+		if targets[i].Classification, err = checkLevel(ctx, targets[i].Classification, level); err != nil {
+			return nil, err
 		}
 	}
 
-	return s.db.Mission.Create(ctx, mission, targets)
+	if mission.AssignedCatID != nil {
+		if err := checkLeave(ctx, s.db, *mission.AssignedCatID, mission.ScheduledStart, mission.ScheduledEnd); err != nil {
+			return nil, err
+		}
+		if err := checkCatClearance(ctx, s.db, *mission.AssignedCatID, missionLevel(&mission, targets)); err != nil {
+			return nil, err
+		}
+		if _, err := s.checkSkills(ctx, uuid.Nil, *mission.AssignedCatID, targets); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.db.Mission.Create(ctx, mission, targets, by)
+}
+
+// CreateFromTemplate creates a mission from a template's placeholders with
+// the overrides applied. The result is validated like any other mission.
+func (s *mission) CreateFromTemplate(ctx context.Context, templateID uuid.UUID, overrides models.MissionOverrides, by string) (*models.Mission, error) {
+	ctx, span := tracing.Start(ctx, "Mission.CreateFromTemplate")
+	defer span.End()
+
+	t, err := s.db.Template.GetByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, errTemplateNotFound
+	}
+
+	mission, targets := t.Instantiate(overrides)
+	return s.Create(ctx, mission, targets, by)
 }
 
 func (s *mission) GetAll(ctx context.Context) ([]models.Mission, error) {
+	ctx, span := tracing.Start(ctx, "Mission.GetAll")
+	defer span.End()
+
 	return s.db.Mission.GetAll(ctx)
 }
 
 func (s *mission) GetByID(ctx context.Context, id uuid.UUID) (*models.Mission, error) {
+	ctx, span := tracing.Start(ctx, "Mission.GetByID")
+	defer span.End()
+
 	return s.db.Mission.GetByID(ctx, id)
 }
 
+// Route orders the mission's located targets by travel distance, from the
+// start point when one is given.
+func (s *mission) Route(ctx context.Context, id uuid.UUID, startLat, startLon *float64) (*models.MissionRoute, error) {
+	ctx, span := tracing.Start(ctx, "Mission.Route")
+	defer span.End()
+
+	if err := checkCoordinates(startLat, startLon); err != nil {
+		return nil, err
+	}
+
+	mission, err := s.db.Mission.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if mission == nil {
+		return nil, errMissionNotFound
+	}
+
+	route := models.MissionRoute{
+		MissionID:      id,
+		StartLatitude:  startLat,
+		StartLongitude: startLon,
+		Unlocated:      []uuid.UUID{},
+	}
+	var stops []models.RouteStop
+	for _, target := range mission.Targets {
+		if target.Latitude == nil {
+			route.Unlocated = append(route.Unlocated, target.ID)
+			continue
+		}
+		stops = append(stops, models.RouteStop{
+			TargetID:  target.ID,
+			Name:      target.Name,
+			Latitude:  *target.Latitude,
+			Longitude: *target.Longitude,
+		})
+	}
+	route.Stops, route.TotalKm = shortestRoute(stops, startLat, startLon)
+
+	return &route, nil
+}
+
 func (s *mission) UpdateCompleted(ctx context.Context, id uuid.UUID, completed bool) error {
+	ctx, span := tracing.Start(ctx, "Mission.UpdateCompleted")
+	defer span.End()
+
+	if _, err := missionWritable(ctx, s.db, id); err != nil {
+		return err
+	}
 	return s.db.Mission.UpdateCompleted(ctx, id, completed)
 }
 
-func (s *mission) UpdateAssignedCat(ctx context.Context, id uuid.UUID, catID *uuid.UUID) error {
-	return s.db.Mission.UpdateAssignedCat(ctx, id, catID)
+// UpdateSchedule moves the mission window. No cat on the mission, lead or
+// not, may be on leave during the new window.
+func (s *mission) UpdateSchedule(ctx context.Context, id uuid.UUID, start, end *time.Time) error {
+	ctx, span := tracing.Start(ctx, "Mission.UpdateSchedule")
+	defer span.End()
+
+	if err := checkSchedule(start, end); err != nil {
+		return err
+	}
+
+	mission, err := missionWritable(ctx, s.db, id)
+	if err != nil {
+		return err
+	}
+	for _, a := range mission.Assignments {
+		if a.CatID == nil {
+			continue
+		}
+		if err := checkLeave(ctx, s.db, *a.CatID, start, end); err != nil {
+			return fmt.Errorf("%s: %w", a.CatName, err)
+		}
+	}
+
+	return s.db.Mission.UpdateSchedule(ctx, id, start, end)
+}
+
+// UpdateAssignedCat makes a cat the mission's lead, or removes the lead
+// when catID is nil; by and reason go into the assignment history. A cat on
+// leave during the mission window is refused. Depending on the skill check
+// mode an assignment that leaves requirements uncovered is refused, or
+// allowed with the returned coverage describing what is missing.
+func (s *mission) UpdateAssignedCat(ctx context.Context, id uuid.UUID, catID *uuid.UUID, by, reason string) (*models.SkillCoverage, error) {
+	ctx, span := tracing.Start(ctx, "Mission.UpdateAssignedCat")
+	defer span.End()
+
+	mission, err := missionWritable(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	var coverage *models.SkillCoverage
+	if catID != nil {
+		if coverage, err = s.checkAssignee(ctx, mission, *catID, models.RoleLead); err != nil {
+			return nil, err
+		}
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		reason = "unassigned"
+		if catID != nil {
+			reason = "replaced as lead"
+		}
+	}
+
+	found, err := s.db.Mission.UpdateAssignedCat(ctx, id, catID, by, reason)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errMissionNotFound
+	}
+	return coverage, nil
+}
+
+// checkAssignee is what a cat must pass to take a role on the mission: no
+// leave during the mission window, clearance for its highest level and, to
+// lead, the skills its targets require.
+func (s *mission) checkAssignee(ctx context.Context, mission *models.Mission, catID uuid.UUID, role string) (*models.SkillCoverage, error) {
+	if err := checkLeave(ctx, s.db, catID, mission.ScheduledStart, mission.ScheduledEnd); err != nil {
+		return nil, err
+	}
+	if err := checkCatClearance(ctx, s.db, catID, missionLevel(mission, mission.Targets)); err != nil {
+		return nil, err
+	}
+	if role != models.RoleLead {
+		return nil, nil
+	}
+	return s.checkSkills(ctx, mission.ID, catID, mission.Targets)
+}
+
+// checkSkills compares the cat's skills with the targets' requirements. In
+// block mode missing skills are an error; in warn mode they are logged.
+func (s *mission) checkSkills(ctx context.Context, missionID, catID uuid.UUID, targets []models.Target) (*models.SkillCoverage, error) {
+	mode := s.skillCheck
+	if agencyMode := settingsOf(ctx).SkillCheck; agencyMode != nil {
+		mode = *agencyMode
+	}
+	if mode == models.SkillCheckOff {
+		return nil, nil
+	}
+
+	skills, err := s.db.Skill.GetCatSkills(ctx, catID)
+	if err != nil {
+		return nil, err
+	}
+
+	coverage := computeCoverage(missionID, &catID, targets, skills)
+	if coverage.Complete {
+		return coverage, nil
+	}
+
+	if mode == models.SkillCheckBlock {
+		return nil, fault.Conflictf("cat lacks required skills: %s", missing(coverage))
+	}
+
+	logging.From(ctx).Warnf("cat %s lacks required skills: %s", catID, missing(coverage))
+	return coverage, nil
+}
+
+// Assign puts a cat on the mission in the given role. Assigning a lead is
+// the same as UpdateAssignedCat; other roles skip the skill check.
+func (s *mission) Assign(ctx context.Context, id, catID uuid.UUID, role, by string) (*models.Assignment, *models.SkillCoverage, error) {
+	ctx, span := tracing.Start(ctx, "Mission.Assign")
+	defer span.End()
+
+	if !slices.Contains(models.AssignmentRoles, role) {
+		return nil, nil, fault.Conflictf("role must be one of %s", strings.Join(models.AssignmentRoles, ", "))
+	}
+
+	if role == models.RoleLead {
+		coverage, err := s.UpdateAssignedCat(ctx, id, &catID, by, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		assignments, err := s.db.Mission.GetAssignments(ctx, id, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, a := range assignments {
+			if a.Role == models.RoleLead {
+				return &a, coverage, nil
+			}
+		}
+		return nil, nil, errors.New("lead assignment disappeared")
+	}
+
+	mission, err := missionWritable(ctx, s.db, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mission.Completed {
+		return nil, nil, fault.Conflictf("cannot assign cat: mission completed")
+	}
+	if _, err := s.checkAssignee(ctx, mission, catID, role); err != nil {
+		return nil, nil, err
+	}
+
+	assignment, err := s.db.Mission.Assign(ctx, id, catID, role, by)
+	if err != nil {
+		return nil, nil, err
+	}
+	return assignment, nil, nil
+}
+
+// UpdateClassification changes the mission's classification. The caller
+// must be cleared for both the old and the new level, and every cat on the
+// mission for the new one.
+func (s *mission) UpdateClassification(ctx context.Context, id uuid.UUID, level models.Classification) error {
+	ctx, span := tracing.Start(ctx, "Mission.UpdateClassification")
+	defer span.End()
+
+	mission, err := missionWritable(ctx, s.db, id)
+	if err != nil {
+		return err
+	}
+	if level, err = checkLevel(ctx, level, ""); err != nil {
+		return err
+	}
+	mission.Classification = level
+	if err := checkTeamClearance(ctx, s.db, mission, missionLevel(mission, mission.Targets)); err != nil {
+		return err
+	}
+	return s.db.Mission.UpdateClassification(ctx, id, level)
 }
 
+// Unassign takes a cat off the mission, whatever its role.
+func (s *mission) Unassign(ctx context.Context, id, catID uuid.UUID, by, reason string) error {
+	ctx, span := tracing.Start(ctx, "Mission.Unassign")
+	defer span.End()
+
+	if _, err := missionWritable(ctx, s.db, id); err != nil {
+		return err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		reason = "unassigned"
+	}
+
+	found, err := s.db.Mission.Unassign(ctx, id, catID, by, reason)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errAssignmentNotFound
+	}
+	return nil
+}
+
+// GetAssignments lists the cats on the mission, or with history every cat
+// that has ever been on it.
+func (s *mission) GetAssignments(ctx context.Context, id uuid.UUID, history bool) ([]models.Assignment, error) {
+	ctx, span := tracing.Start(ctx, "Mission.GetAssignments")
+	defer span.End()
+
+	mission, err := s.db.Mission.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if mission == nil {
+		return nil, errMissionNotFound
+	}
+	if !history {
+		return mission.Assignments, nil
+	}
+	return s.db.Mission.GetAssignments(ctx, id, true)
+}
+
+// GetCareer lists every mission a cat has been on, with how long it served.
+func (s *mission) GetCareer(ctx context.Context, catID uuid.UUID) ([]models.CareerEntry, error) {
+	ctx, span := tracing.Start(ctx, "Mission.GetCareer")
+	defer span.End()
+
+	if err := catExists(ctx, s.db, catID); err != nil {
+		return nil, err
+	}
+
+	career, err := s.db.Mission.GetCareer(ctx, catID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range career {
+		career[i].DurationSeconds = int64(serviceEnd(career[i], now).Sub(career[i].AssignedAt).Seconds())
+	}
+	return career, nil
+}
+
+// serviceEnd is when a cat stopped working a mission: when it was
+// unassigned, when the mission completed, or now if it still is on it.
+func serviceEnd(entry models.CareerEntry, now time.Time) time.Time {
+	end := now
+	if entry.UnassignedAt != nil {
+		end = *entry.UnassignedAt
+	}
+	if entry.MissionCompletedAt != nil && entry.MissionCompletedAt.Before(end) {
+		end = *entry.MissionCompletedAt
+	}
+	if end.Before(entry.AssignedAt) {
+		return entry.AssignedAt
+	}
+	return end
+}
+
+// Delete moves the mission to the trash with its targets, so the caller
+// must be cleared for the highest of them.
 func (s *mission) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.db.Mission.Delete(ctx, id)
+	ctx, span := tracing.Start(ctx, "Mission.Delete")
+	defer span.End()
+
+	mission, err := missionWritable(ctx, s.db, id)
+	if err != nil {
+		return err
+	}
+	if err := checkCleared(ctx, missionLevel(mission, mission.Targets)); err != nil {
+		return err
+	}
+	deleted, err := s.db.Mission.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errMissionNotFound
+	}
+	return nil
+}
+
+// Restore takes a mission out of the trash and returns it with its live
+// targets.
+func (s *mission) Restore(ctx context.Context, id uuid.UUID) (*models.Mission, error) {
+	ctx, span := tracing.Start(ctx, "Mission.Restore")
+	defer span.End()
+
+	if err := checkRestorable(ctx, s.db, models.TrashMission, id, errMissionNotFound); err != nil {
+		return nil, err
+	}
+	restored, err := s.db.Mission.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !restored {
+		return nil, errMissionNotFound
+	}
+	return s.db.Mission.GetByID(ctx, id)
 }
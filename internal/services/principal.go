@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+)
+
+var errPrincipalNotFound = fault.NotFoundf("principal not found")
+
+type principals struct {
+	db db.DB
+}
+
+func newPrincipals(db db.DB) *principals {
+	return &principals{
+		db: db,
+	}
+}
+
+func (s *principals) GetAll(ctx context.Context) ([]models.Principal, error) {
+	ctx, span := tracing.Start(ctx, "Principal.GetAll")
+	defer span.End()
+
+	return s.db.Principal.GetAll(ctx)
+}
+
+// Resolve returns the principal with the given name; callers the agency has
+// no record of are unclassified.
+func (s *principals) Resolve(ctx context.Context, name string) (models.Principal, error) {
+	ctx, span := tracing.Start(ctx, "Principal.Resolve")
+	defer span.End()
+
+	p, err := s.db.Principal.GetByName(ctx, name)
+	if err != nil {
+		return models.Principal{}, err
+	}
+	if p == nil {
+		return models.Principal{Name: name, Clearance: models.Unclassified}, nil
+	}
+	return *p, nil
+}
+
+// Set grants a principal a clearance. Nobody can grant more than their own
+// clearance, or change a principal cleared above them.
+func (s *principals) Set(ctx context.Context, name string, clearance models.Classification) (*models.Principal, error) {
+	ctx, span := tracing.Start(ctx, "Principal.Set")
+	defer span.End()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fault.Conflictf("principal name cannot be empty")
+	}
+	if !clearance.Valid() {
+		return nil, fault.Conflictf("clearance must be one of %s, got %q", levelNames, clearance)
+	}
+	if err := checkCleared(ctx, clearance); err != nil {
+		return nil, err
+	}
+	if err := s.checkHeld(ctx, name); err != nil {
+		return nil, err
+	}
+	return s.db.Principal.Set(ctx, models.Principal{Name: name, Clearance: clearance})
+}
+
+// Delete revokes a principal's clearance. Like Set, it cannot touch a
+// principal cleared above the caller.
+func (s *principals) Delete(ctx context.Context, name string) error {
+	ctx, span := tracing.Start(ctx, "Principal.Delete")
+	defer span.End()
+
+	if err := s.checkHeld(ctx, name); err != nil {
+		return err
+	}
+	found, err := s.db.Principal.Delete(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errPrincipalNotFound
+	}
+	return nil
+}
+
+// checkHeld refuses to change a principal whose current clearance is above
+// the caller's.
+func (s *principals) checkHeld(ctx context.Context, name string) error {
+	p, err := s.db.Principal.GetByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+	return checkCleared(ctx, p.Clearance)
+}
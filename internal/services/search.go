@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/principal"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type search struct {
+	db db.DB
+}
+
+func newSearch(db db.DB) *search {
+	return &search{
+		db: db,
+	}
+}
+
+func (s *search) Search(ctx context.Context, query models.SearchQuery) ([]models.SearchHit, error) {
+	ctx, span := tracing.Start(ctx, "Search.Search")
+	defer span.End()
+
+	query.Text = strings.TrimSpace(query.Text)
+	if query.Text == "" {
+		return nil, fault.Conflictf("search query cannot be empty")
+	}
+
+	switch query.Kind {
+	case "", models.SearchMission, models.SearchTarget:
+	default:
+		return nil, fault.Conflictf("search kind must be %s or %s", models.SearchMission, models.SearchTarget)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultSearchLimit
+	}
+	if query.Limit < 1 || query.Limit > maxSearchLimit {
+		return nil, fault.Conflictf("search limit must be between 1 and %d", maxSearchLimit)
+	}
+
+	if query.CatID != nil {
+		if err := catExists(ctx, s.db, *query.CatID); err != nil {
+			return nil, err
+		}
+	}
+
+	query.Clearance = principal.From(ctx).Clearance
+	return s.db.Search.Search(ctx, query)
+}
@@ -1,19 +1,48 @@
 package services
 
-import "github.com/mksmstpck/spy_cat_agency/internal/db"
+import (
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/storage"
+)
 
 type Services struct {
-	Breed   breed
-	SpyCat  spyCat
-	Mission mission
-	Target  target
+	Breed      breed
+	SpyCat     spyCat
+	Mission    mission
+	Target     target
+	Stats      stats
+	Health     health
+	Skill      skill
+	Leave      leave
+	Trash      trash
+	Search     search
+	Country    country
+	Attachment attachment
+	Template   template
+	Agency     agency
+	Principal  principals
+	Audit      audit
 }
 
-func NewServices(db db.DB) *Services {
+func NewServices(db db.DB, config config.Config, blobs storage.Blob) *Services {
+	missions := newMission(db, config.SkillCheck)
 	return &Services{
-		Breed:   *newBreed(db),
-		SpyCat:  *newSpyCat(db),
-		Mission: *newMission(db),
-		Target:  *newTarget(db),
+		Breed:      *newBreed(db),
+		SpyCat:     *newSpyCat(db, missions),
+		Mission:    *missions,
+		Target:     *newTarget(db, config.RequireEvidence),
+		Stats:      *newStats(db),
+		Health:     *newHealth(db),
+		Skill:      *newSkill(db),
+		Leave:      *newLeave(db),
+		Trash:      *newTrash(db, blobs, config.TrashRetention),
+		Search:     *newSearch(db),
+		Country:    *newCountry(db),
+		Attachment: *newAttachment(db, blobs, config.AttachmentMaxBytes, config.AttachmentTypes),
+		Template:   *newTemplate(db),
+		Agency:     *newAgency(db),
+		Principal:  *newPrincipals(db),
+		Audit:      *newAudit(db),
 	}
 }
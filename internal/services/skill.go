@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+)
+
+const (
+	minProficiency = 1
+	maxProficiency = 5
+)
+
+var (
+	errCatNotFound     = fault.NotFoundf("cat not found")
+	errMissionNotFound = fault.NotFoundf("mission not found")
+	errTargetNotFound  = fault.NotFoundf("target not found")
+)
+
+type skill struct {
+	db db.DB
+}
+
+func newSkill(db db.DB) *skill {
+	return &skill{
+		db: db,
+	}
+}
+
+func (s *skill) GetAll(ctx context.Context) ([]models.Skill, error) {
+	ctx, span := tracing.Start(ctx, "Skill.GetAll")
+	defer span.End()
+
+	return s.db.Skill.GetAll(ctx)
+}
+
+func (s *skill) Create(ctx context.Context, skill models.Skill) (*models.Skill, error) {
+	ctx, span := tracing.Start(ctx, "Skill.Create")
+	defer span.End()
+
+	skill.Code = strings.ToLower(strings.TrimSpace(skill.Code))
+	skill.Name = strings.TrimSpace(skill.Name)
+	skill.Category = strings.ToLower(strings.TrimSpace(skill.Category))
+
+	if skill.Code == "" {
+		return nil, fault.Conflictf("skill code cannot be empty")
+	}
+	if skill.Name == "" {
+		return nil, fault.Conflictf("skill name cannot be empty")
+	}
+	if skill.Category == "" {
+		return nil, fault.Conflictf("skill category cannot be empty")
+	}
+
+	return s.db.Skill.Create(ctx, skill)
+}
+
+func (s *skill) GetCatSkills(ctx context.Context, catID uuid.UUID) ([]models.CatSkill, error) {
+	ctx, span := tracing.Start(ctx, "Skill.GetCatSkills")
+	defer span.End()
+
+	if err := catExists(ctx, s.db, catID); err != nil {
+		return nil, err
+	}
+	return s.db.Skill.GetCatSkills(ctx, catID)
+}
+
+// SetCatSkills replaces the skills of a cat. Skills are looked up by code.
+func (s *skill) SetCatSkills(ctx context.Context, catID uuid.UUID, skills []models.CatSkill) ([]models.CatSkill, error) {
+	ctx, span := tracing.Start(ctx, "Skill.SetCatSkills")
+	defer span.End()
+
+	if err := catExists(ctx, s.db, catID); err != nil {
+		return nil, err
+	}
+
+	taxonomy, err := loadTaxonomy(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(skills))
+	for i := range skills {
+		code := strings.ToLower(strings.TrimSpace(skills[i].Skill.Code))
+		known, ok := taxonomy[code]
+		if !ok {
+			return nil, fault.Conflictf("unknown skill %q", skills[i].Skill.Code)
+		}
+		if seen[code] {
+			return nil, fault.Conflictf("skill %q is listed twice", code)
+		}
+		seen[code] = true
+		if err := checkProficiency(skills[i].Proficiency); err != nil {
+			return nil, err
+		}
+		skills[i].Skill = known
+	}
+
+	if err := s.db.Skill.SetCatSkills(ctx, catID, skills); err != nil {
+		return nil, err
+	}
+	return s.db.Skill.GetCatSkills(ctx, catID)
+}
+
+// SetTargetRequirements replaces the skill requirements of a target.
+func (s *skill) SetTargetRequirements(ctx context.Context, targetID uuid.UUID, reqs []models.SkillRequirement) (*models.Target, error) {
+	ctx, span := tracing.Start(ctx, "Skill.SetTargetRequirements")
+	defer span.End()
+
+	target, err := s.db.Target.GetByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, errTargetNotFound
+	}
+	if target.Completed {
+		return nil, fault.Conflictf("cannot change requirements of a completed target")
+	}
+
+	if err := resolveRequirements(ctx, s.db, reqs); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Skill.SetTargetRequirements(ctx, targetID, reqs); err != nil {
+		return nil, err
+	}
+	return s.db.Target.GetByID(ctx, targetID)
+}
+
+// Coverage reports how well a cat covers the open targets of a mission. When
+// catID is nil the mission's assigned cat is used.
+func (s *skill) Coverage(ctx context.Context, missionID uuid.UUID, catID *uuid.UUID) (*models.SkillCoverage, error) {
+	ctx, span := tracing.Start(ctx, "Skill.Coverage")
+	defer span.End()
+
+	mission, err := s.db.Mission.GetByID(ctx, missionID)
+	if err != nil {
+		return nil, err
+	}
+	if mission == nil {
+		return nil, errMissionNotFound
+	}
+
+	if catID == nil {
+		catID = mission.AssignedCatID
+	}
+
+	var skills []models.CatSkill
+	if catID != nil {
+		if err := catExists(ctx, s.db, *catID); err != nil {
+			return nil, err
+		}
+		if skills, err = s.db.Skill.GetCatSkills(ctx, *catID); err != nil {
+			return nil, err
+		}
+	}
+
+	return computeCoverage(missionID, catID, mission.Targets, skills), nil
+}
+
+func catExists(ctx context.Context, db db.DB, id uuid.UUID) error {
+	_, err := db.SpyCat.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errCatNotFound
+	}
+	return err
+}
+
+func loadTaxonomy(ctx context.Context, db db.DB) (map[string]models.Skill, error) {
+	skills, err := db.Skill.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	taxonomy := make(map[string]models.Skill, len(skills))
+	for _, skill := range skills {
+		taxonomy[skill.Code] = skill
+	}
+	return taxonomy, nil
+}
+
+// resolveRequirements fills in the skills of requirements given by code and
+// checks their proficiency levels.
+func resolveRequirements(ctx context.Context, db db.DB, reqs []models.SkillRequirement) error {
+	if len(reqs) == 0 {
+		return nil
+	}
+
+	taxonomy, err := loadTaxonomy(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(reqs))
+	for i := range reqs {
+		code := strings.ToLower(strings.TrimSpace(reqs[i].Skill.Code))
+		known, ok := taxonomy[code]
+		if !ok {
+			return fault.Conflictf("unknown skill %q", reqs[i].Skill.Code)
+		}
+		if seen[code] {
+			return fault.Conflictf("skill %q is required twice", code)
+		}
+		seen[code] = true
+		if err := checkProficiency(reqs[i].MinProficiency); err != nil {
+			return err
+		}
+		reqs[i].Skill = known
+	}
+	return nil
+}
+
+func checkProficiency(level int) error {
+	if level < minProficiency || level > maxProficiency {
+		return fault.Conflictf("proficiency must be between %d and %d", minProficiency, maxProficiency)
+	}
+	return nil
+}
+
+// computeCoverage matches the requirements of every open target against the
+// cat's skills. Completed targets no longer need covering.
+func computeCoverage(missionID uuid.UUID, catID *uuid.UUID, targets []models.Target, skills []models.CatSkill) *models.SkillCoverage {
+	levels := make(map[string]int, len(skills))
+	for _, skill := range skills {
+		levels[skill.Skill.Code] = skill.Proficiency
+	}
+
+	coverage := &models.SkillCoverage{
+		MissionID:    missionID,
+		CatID:        catID,
+		Requirements: []models.RequirementCoverage{},
+	}
+
+	for _, target := range targets {
+		if target.Completed {
+			continue
+		}
+		for _, req := range target.RequiredSkills {
+			level := levels[req.Skill.Code]
+			satisfied := level >= req.MinProficiency
+			coverage.Requirements = append(coverage.Requirements, models.RequirementCoverage{
+				TargetID:       target.ID,
+				TargetName:     target.Name,
+				Skill:          req.Skill.Code,
+				MinProficiency: req.MinProficiency,
+				CatProficiency: level,
+				Satisfied:      satisfied,
+			})
+			coverage.Total++
+			if satisfied {
+				coverage.Covered++
+			}
+		}
+	}
+
+	coverage.Complete = coverage.Covered == coverage.Total
+	return coverage
+}
+
+// missing lists the unmet requirements of a coverage report.
+func missing(coverage *models.SkillCoverage) string {
+	var parts []string
+	for _, req := range coverage.Requirements {
+		if !req.Satisfied {
+			parts = append(parts, fmt.Sprintf("%s needs %s %d (has %d)", req.TargetName, req.Skill, req.MinProficiency, req.CatProficiency))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
@@ -3,48 +3,217 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
 	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
 )
 
 type spyCat struct {
 	db db.DB
+	// missions checks a replacement like any other assignment.
+	missions *mission
 }
 
-func newSpyCat(db db.DB) *spyCat {
+func newSpyCat(db db.DB, missions *mission) *spyCat {
 	return &spyCat{
-		db: db,
+		db:       db,
+		missions: missions,
 	}
 }
 
+// Create stores a cat. Nobody can grant a cat clearance above their own.
 func (s *spyCat) Create(ctx context.Context, cat models.SpyCat) (*models.SpyCat, error) {
+	ctx, span := tracing.Start(ctx, "SpyCat.Create")
+	defer span.End()
+
+	if err := validate.Cat(&cat); err != nil {
+		return nil, err
+	}
+	clearance, err := checkLevel(ctx, cat.Clearance, models.Unclassified)
+	if err != nil {
+		return nil, err
+	}
+	cat.Clearance = clearance
 	return s.db.SpyCat.Create(ctx, cat)
 }
 
-func (s *spyCat) GetAll(ctx context.Context) ([]models.SpyCat, error) {
-	return s.db.SpyCat.GetAll(ctx)
+func (s *spyCat) GetAll(ctx context.Context, filter models.CatFilter) ([]models.SpyCat, error) {
+	ctx, span := tracing.Start(ctx, "SpyCat.GetAll")
+	defer span.End()
+
+	for _, status := range filter.Statuses {
+		if !slices.Contains(models.CatStatuses, status) {
+			return nil, fault.Conflictf("status must be one of %s", strings.Join(models.CatStatuses, ", "))
+		}
+	}
+	return s.db.SpyCat.GetAll(ctx, filter)
 }
 
 func (s *spyCat) GetByID(ctx context.Context, id uuid.UUID) (*models.SpyCat, error) {
+	ctx, span := tracing.Start(ctx, "SpyCat.GetByID")
+	defer span.End()
+
 	return s.db.SpyCat.GetByID(ctx, id)
 }
 
 func (s *spyCat) UpdateSalary(ctx context.Context, id uuid.UUID, salary float32) error {
-	if salary < 0 {
-		return errors.New("salary must be >= 0")
+	ctx, span := tracing.Start(ctx, "SpyCat.UpdateSalary")
+	defer span.End()
+
+	if err := validate.Salary(salary); err != nil {
+		return err
 	}
 	return s.db.SpyCat.UpdateSalary(ctx, id, salary)
 }
 
 func (s *spyCat) UpdateExperience(ctx context.Context, id uuid.UUID, exp int) error {
-	if exp < 0 {
-		return errors.New("experience cannot be negative")
+	ctx, span := tracing.Start(ctx, "SpyCat.UpdateExperience")
+	defer span.End()
+
+	if err := validate.Experience(exp); err != nil {
+		return err
 	}
 	return s.db.SpyCat.UpdateExperience(ctx, id, exp)
 }
 
+// UpdateStatus suspends or reactivates a cat. Retirement is final and goes
+// through Retire.
+func (s *spyCat) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
+	ctx, span := tracing.Start(ctx, "SpyCat.UpdateStatus")
+	defer span.End()
+
+	if status != models.CatActive && status != models.CatSuspended {
+		return fault.Conflictf("status must be %s or %s; use retire to retire a cat", models.CatActive, models.CatSuspended)
+	}
+
+	cat, err := s.getCat(ctx, id)
+	if err != nil {
+		return err
+	}
+	if cat.Status == models.CatRetired {
+		return fault.Conflictf("cannot change status of a retired cat")
+	}
+
+	return s.db.SpyCat.UpdateStatus(ctx, id, status)
+}
+
+// UpdateClearance changes a cat's clearance. The caller must be cleared for
+// the new level, and the cat keeps enough clearance for its current
+// missions.
+func (s *spyCat) UpdateClearance(ctx context.Context, id uuid.UUID, clearance models.Classification) error {
+	ctx, span := tracing.Start(ctx, "SpyCat.UpdateClearance")
+	defer span.End()
+
+	clearance, err := checkLevel(ctx, clearance, "")
+	if err != nil {
+		return err
+	}
+	if _, err := s.getCat(ctx, id); err != nil {
+		return err
+	}
+
+	needed, err := activeMissionLevel(ctx, s.db, id)
+	if err != nil {
+		return err
+	}
+	if !clearance.Covers(needed) {
+		return fault.Conflictf("cannot lower clearance to %s: cat is on a %s mission", clearance, needed)
+	}
+	return s.db.SpyCat.UpdateClearance(ctx, id, clearance)
+}
+
+// Retire retires a cat for good. A cat on an active mission can only be
+// retired together with a replacement, who takes over its roles and must
+// pass the checks of assigning it to each of them.
+func (s *spyCat) Retire(ctx context.Context, id uuid.UUID, reason, by string, replacementID *uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "SpyCat.Retire")
+	defer span.End()
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return fault.Conflictf("retirement reason is required")
+	}
+
+	cat, err := s.getCat(ctx, id)
+	if err != nil {
+		return err
+	}
+	if cat.Status == models.CatRetired {
+		return fault.Conflictf("cat is already retired")
+	}
+
+	if replacementID != nil {
+		if *replacementID == id {
+			return fault.Conflictf("replacement cat cannot be the retiring cat")
+		}
+		replacement, err := s.getCat(ctx, *replacementID)
+		if err != nil {
+			return err
+		}
+		if replacement.Status != models.CatActive {
+			return fault.Conflictf("replacement cat must be active, not %s", replacement.Status)
+		}
+		if err := s.checkReplacement(ctx, id, *replacementID); err != nil {
+			return err
+		}
+	}
+
+	return s.db.SpyCat.Retire(ctx, id, reason, by, replacementID)
+}
+
+// checkReplacement runs the assignment checks for every role the retiring
+// cat holds on an active mission, as if the replacement were assigned to it.
+func (s *spyCat) checkReplacement(ctx context.Context, id, replacementID uuid.UUID) error {
+	career, err := s.db.Mission.GetCareer(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, entry := range career {
+		if entry.UnassignedAt != nil || entry.MissionCompleted {
+			continue
+		}
+		mission, err := s.db.Mission.GetByID(ctx, entry.MissionID)
+		if err != nil {
+			return err
+		}
+		if mission == nil {
+			continue
+		}
+		if _, err := s.missions.checkAssignee(ctx, mission, replacementID, entry.Role); err != nil {
+			return fmt.Errorf("replacement on %q: %w", mission.Title, err)
+		}
+	}
+	return nil
+}
+
+// Delete removes a cat that has never been on a mission. Cats with history
+// are retired instead so their record survives.
 func (s *spyCat) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "SpyCat.Delete")
+	defer span.End()
+
+	history, err := s.db.SpyCat.HasHistory(ctx, id)
+	if err != nil {
+		return err
+	}
+	if history {
+		return fault.Conflictf("cannot delete cat with mission history, retire it instead")
+	}
 	return s.db.SpyCat.Delete(ctx, id)
 }
+
+func (s *spyCat) getCat(ctx context.Context, id uuid.UUID) (*models.SpyCat, error) {
+	cat, err := s.db.SpyCat.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errCatNotFound
+	}
+	return cat, err
+}
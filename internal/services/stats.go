@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+)
+
+type stats struct {
+	db db.DB
+}
+
+func newStats(db db.DB) *stats {
+	return &stats{
+		db: db,
+	}
+}
+
+func (s *stats) Get(ctx context.Context) ([]models.AgencyStats, error) {
+	ctx, span := tracing.Start(ctx, "Stats.Get")
+	defer span.End()
+
+	return s.db.Stats.Get(ctx)
+}
@@ -2,46 +2,285 @@ package services
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"math"
 
 	"github.com/google/uuid"
 	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
 	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
 )
 
 type target struct {
 	db db.DB
+	// requireEvidence refuses to complete a target without an attachment.
+	requireEvidence bool
 }
 
-func newTarget(db db.DB) *target {
+func newTarget(db db.DB, requireEvidence bool) *target {
 	return &target{
-		db: db,
+		db:              db,
+		requireEvidence: requireEvidence,
 	}
 }
 
 func (s *target) Create(ctx context.Context, target models.Target) (*models.Target, error) {
-	if target.Name == "" {
-		return nil, errors.New("target name cannot be empty")
+	ctx, span := tracing.Start(ctx, "Target.Create")
+	defer span.End()
+
+	if err := validate.Target(&target); err != nil {
+		return nil, err
+	}
+	mission, err := s.db.Mission.GetByID(ctx, target.MissionID)
+	if err != nil {
+		return nil, err
+	}
+	if mission == nil {
+		return nil, errMissionNotFound
+	}
+	if err := resolveRequirements(ctx, s.db, target.RequiredSkills); err != nil {
+		return nil, err
+	}
+	if target.Classification, err = checkLevel(ctx, target.Classification, mission.Classification); err != nil {
+		return nil, err
 	}
-	if target.Country == "" {
-		return nil, errors.New("target country cannot be empty")
+	if err := checkTeamClearance(ctx, s.db, mission, target.Classification); err != nil {
+		return nil, err
 	}
 
 	return s.db.Target.Create(ctx, target)
 }
 
 func (s *target) GetByID(ctx context.Context, id uuid.UUID) (*models.Target, error) {
+	ctx, span := tracing.Start(ctx, "Target.GetByID")
+	defer span.End()
+
 	return s.db.Target.GetByID(ctx, id)
 }
 
 func (s *target) UpdateCompleted(ctx context.Context, id uuid.UUID, completed bool) error {
+	ctx, span := tracing.Start(ctx, "Target.UpdateCompleted")
+	defer span.End()
+
+	if _, err := targetWritable(ctx, s.db, id); err != nil {
+		return err
+	}
+	requireEvidence := s.requireEvidence
+	if agencyRequires := settingsOf(ctx).RequireEvidence; agencyRequires != nil {
+		requireEvidence = *agencyRequires
+	}
+	if completed && requireEvidence {
+		n, err := s.db.Attachment.Count(ctx, id)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fault.Conflictf("cannot complete target %s: no evidence attached", id)
+		}
+	}
 	return s.db.Target.UpdateCompleted(ctx, id, completed)
 }
 
 func (s *target) UpdateNotes(ctx context.Context, id uuid.UUID, notes string) error {
+	ctx, span := tracing.Start(ctx, "Target.UpdateNotes")
+	defer span.End()
+
+	if _, err := targetWritable(ctx, s.db, id); err != nil {
+		return err
+	}
 	return s.db.Target.UpdateNotes(ctx, id, notes)
 }
 
+// UpdateClassification changes the target's classification. The caller
+// must be cleared for both the old and the new level, and every cat on the
+// mission for the new one.
+func (s *target) UpdateClassification(ctx context.Context, id uuid.UUID, level models.Classification) error {
+	ctx, span := tracing.Start(ctx, "Target.UpdateClassification")
+	defer span.End()
+
+	target, err := targetWritable(ctx, s.db, id)
+	if err != nil {
+		return err
+	}
+	if level, err = checkLevel(ctx, level, ""); err != nil {
+		return err
+	}
+	mission, err := s.db.Mission.GetByID(ctx, target.MissionID)
+	if err != nil {
+		return err
+	}
+	if mission == nil {
+		return errMissionNotFound
+	}
+	if err := checkTeamClearance(ctx, s.db, mission, level); err != nil {
+		return err
+	}
+	return s.db.Target.UpdateClassification(ctx, id, level)
+}
+
+// UpdateCountry corrects a target's country, typically one the country
+// migration could not resolve.
+func (s *target) UpdateCountry(ctx context.Context, id uuid.UUID, country string) error {
+	ctx, span := tracing.Start(ctx, "Target.UpdateCountry")
+	defer span.End()
+
+	code, err := resolveCountry(country)
+	if err != nil {
+		return err
+	}
+	if _, err := targetWritable(ctx, s.db, id); err != nil {
+		return err
+	}
+	updated, err := s.db.Target.UpdateCountry(ctx, id, code)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errTargetNotFound
+	}
+	return nil
+}
+
+func (s *target) UpdateLocation(ctx context.Context, id uuid.UUID, location models.TargetLocation) error {
+	ctx, span := tracing.Start(ctx, "Target.UpdateLocation")
+	defer span.End()
+
+	if err := checkCoordinates(location.Latitude, location.Longitude); err != nil {
+		return err
+	}
+	if _, err := targetWritable(ctx, s.db, id); err != nil {
+		return err
+	}
+	updated, err := s.db.Target.UpdateLocation(ctx, id, location)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errTargetNotFound
+	}
+	return nil
+}
+
+// Nearby finds targets within radiusKm of the point, nearest first.
+func (s *target) Nearby(ctx context.Context, lat, lon, radiusKm float64, limit int) ([]models.NearbyTarget, error) {
+	ctx, span := tracing.Start(ctx, "Target.Nearby")
+	defer span.End()
+
+	if err := checkCoordinates(&lat, &lon); err != nil {
+		return nil, err
+	}
+	if math.IsNaN(radiusKm) || radiusKm <= 0 || radiusKm > maxRadiusKm {
+		return nil, fault.Conflictf("radius must be greater than 0 and at most %.0f km", maxRadiusKm)
+	}
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	if limit < 1 || limit > maxSearchLimit {
+		return nil, fault.Conflictf("nearby limit must be between 1 and %d", maxSearchLimit)
+	}
+
+	return s.db.Target.Nearby(ctx, lat, lon, radiusKm, boundingBox(lat, lon, radiusKm), limit)
+}
+
 func (s *target) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "Target.Delete")
+	defer span.End()
+
+	if _, err := targetWritable(ctx, s.db, id); err != nil {
+		return err
+	}
 	return s.db.Target.Delete(ctx, id)
 }
+
+// Restore takes a target out of the trash. Targets of a deleted mission are
+// restored with the mission instead.
+func (s *target) Restore(ctx context.Context, id uuid.UUID) (*models.Target, error) {
+	ctx, span := tracing.Start(ctx, "Target.Restore")
+	defer span.End()
+
+	if err := checkRestorable(ctx, s.db, models.TrashTarget, id, errTargetNotFound); err != nil {
+		return nil, err
+	}
+	restored, err := s.db.Target.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !restored {
+		return nil, errTargetNotFound
+	}
+	return s.db.Target.GetByID(ctx, id)
+}
+
+// NotesKeyUsage shows which keys target notes are sealed with, across all
+// agencies.
+func (s *target) NotesKeyUsage(ctx context.Context) ([]models.NotesKeyUsage, error) {
+	ctx, span := tracing.Start(ctx, "Target.NotesKeyUsage")
+	defer span.End()
+
+	return s.db.Target.NotesKeyUsage(ctx)
+}
+
+// RotateNotes re-seals every target note, then every template's placeholder
+// notes, not yet under the active key, batch rows per transaction, and
+// returns how many rows it rewrote. It is safe to interrupt and run again.
+func (s *target) RotateNotes(ctx context.Context, batch int) (int, error) {
+	ctx, span := tracing.Start(ctx, "Target.RotateNotes")
+	defer span.End()
+
+	if batch < 1 {
+		return 0, fmt.Errorf("batch must be positive, got %d", batch)
+	}
+
+	total := 0
+	for _, rotate := range []func(context.Context, int) (int, error){
+		s.db.Target.RotateNotes,
+		s.db.Template.RotateNotes,
+	} {
+		for {
+			n, err := rotate(ctx, batch)
+			total += n
+			if err != nil {
+				return total, err
+			}
+			if n == 0 {
+				break
+			}
+			if err := ctx.Err(); err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, nil
+}
+
+// ReindexNotes rebuilds the blind index of every note, batch rows per
+// transaction, and returns how many targets it went through. Run it once
+// after upgrading and whenever the index key changes; an interrupted run
+// starts over.
+func (s *target) ReindexNotes(ctx context.Context, batch int) (int, error) {
+	ctx, span := tracing.Start(ctx, "Target.ReindexNotes")
+	defer span.End()
+
+	if batch < 1 {
+		return 0, fmt.Errorf("batch must be positive, got %d", batch)
+	}
+
+	var after uuid.UUID
+	total := 0
+	for {
+		last, n, err := s.db.Target.ReindexNotes(ctx, after, batch)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n == 0 {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		after = last
+	}
+}
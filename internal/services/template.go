@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/fault"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+	"github.com/mksmstpck/spy_cat_agency/internal/validate"
+)
+
+var errTemplateNotFound = fault.NotFoundf("template not found")
+
+type template struct {
+	db db.DB
+}
+
+func newTemplate(db db.DB) *template {
+	return &template{
+		db: db,
+	}
+}
+
+func (s *template) Create(ctx context.Context, t models.MissionTemplate, by string) (*models.MissionTemplate, error) {
+	ctx, span := tracing.Start(ctx, "Template.Create")
+	defer span.End()
+
+	if err := s.check(ctx, &t); err != nil {
+		return nil, err
+	}
+	t.CreatedBy = by
+	return s.db.Template.Create(ctx, t)
+}
+
+func (s *template) GetAll(ctx context.Context) ([]models.MissionTemplate, error) {
+	ctx, span := tracing.Start(ctx, "Template.GetAll")
+	defer span.End()
+
+	return s.db.Template.GetAll(ctx)
+}
+
+func (s *template) GetByID(ctx context.Context, id uuid.UUID) (*models.MissionTemplate, error) {
+	ctx, span := tracing.Start(ctx, "Template.GetByID")
+	defer span.End()
+
+	t, err := s.db.Template.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, errTemplateNotFound
+	}
+	return t, nil
+}
+
+// Update replaces a template. Missions already created from it keep what
+// they were created with.
+func (s *template) Update(ctx context.Context, t models.MissionTemplate) (*models.MissionTemplate, error) {
+	ctx, span := tracing.Start(ctx, "Template.Update")
+	defer span.End()
+
+	if err := s.check(ctx, &t); err != nil {
+		return nil, err
+	}
+	updated, err := s.db.Template.Update(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, errTemplateNotFound
+	}
+	return updated, nil
+}
+
+func (s *template) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "Template.Delete")
+	defer span.End()
+
+	found, err := s.db.Template.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errTemplateNotFound
+	}
+	return nil
+}
+
+// check normalises a template and applies the mission rules that do not
+// depend on the placeholders being filled in: 1-3 targets, known countries
+// and skills.
+func (s *template) check(ctx context.Context, t *models.MissionTemplate) error {
+	if err := validate.Template(t); err != nil {
+		return err
+	}
+
+	for i := range t.Targets {
+		placeholder := &t.Targets[i]
+		reqs := make([]models.SkillRequirement, len(placeholder.SuggestedSkills))
+		for j, skill := range placeholder.SuggestedSkills {
+			reqs[j] = models.SkillRequirement{
+				Skill:          models.Skill{Code: skill.Skill},
+				MinProficiency: skill.MinProficiency,
+			}
+		}
+		if err := resolveRequirements(ctx, s.db, reqs); err != nil {
+			return err
+		}
+		for j := range reqs {
+			placeholder.SuggestedSkills[j].Skill = reqs[j].Skill.Code
+		}
+	}
+	return nil
+}
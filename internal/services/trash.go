@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/db"
+	"github.com/mksmstpck/spy_cat_agency/internal/logging"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+	"github.com/mksmstpck/spy_cat_agency/internal/storage"
+	"github.com/mksmstpck/spy_cat_agency/internal/tenant"
+	"github.com/mksmstpck/spy_cat_agency/internal/tracing"
+)
+
+type trash struct {
+	db        db.DB
+	blobs     storage.Blob
+	retention time.Duration
+}
+
+func newTrash(db db.DB, blobs storage.Blob, retention time.Duration) *trash {
+	return &trash{
+		db:        db,
+		blobs:     blobs,
+		retention: retention,
+	}
+}
+
+func (s *trash) GetAll(ctx context.Context) ([]models.TrashItem, error) {
+	ctx, span := tracing.Start(ctx, "Trash.GetAll")
+	defer span.End()
+
+	return s.db.Trash.GetAll(ctx)
+}
+
+// Purge permanently removes everything the agency ctx acts for deleted
+// longer ago than the configured retention, including the files attached to
+// purged targets.
+func (s *trash) Purge(ctx context.Context) (*models.PurgeResult, error) {
+	ctx, span := tracing.Start(ctx, "Trash.Purge")
+	defer span.End()
+
+	agencyID := tenant.ID(ctx)
+	return s.purge(ctx, &agencyID)
+}
+
+// PurgeAll is Purge for every agency at once.
+func (s *trash) PurgeAll(ctx context.Context) (*models.PurgeResult, error) {
+	ctx, span := tracing.Start(ctx, "Trash.PurgeAll")
+	defer span.End()
+
+	return s.purge(ctx, nil)
+}
+
+func (s *trash) purge(ctx context.Context, agencyID *uuid.UUID) (*models.PurgeResult, error) {
+	result, keys, err := s.db.Trash.Purge(ctx, agencyID, time.Now().Add(-s.retention))
+	if err != nil {
+		return nil, err
+	}
+	// The rows are gone; a blob that fails to delete is only wasted space.
+	for _, key := range keys {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			logging.From(ctx).Errorf("purge attachment %s: %s", key, err)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/config"
+)
+
+// ErrNotFound is returned by Get for a key that holds no blob.
+var ErrNotFound = errors.New("blob not found")
+
+// Blob stores opaque files under slash-separated keys chosen by the caller.
+type Blob interface {
+	// Put stores everything read from r under key, replacing any blob there.
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob; a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the backend selected by StorageBackend.
+func New(cfg config.Config) (Blob, error) {
+	switch cfg.StorageBackend {
+	case "local":
+		return NewLocal(cfg.StorageDir)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
@@ -0,0 +1,31 @@
+// Package tenant carries the agency a request acts for in its context. The
+// db package scopes every agency-owned row by it.
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+type contextKey struct{}
+
+// WithAgency returns a copy of ctx that acts for agency.
+func WithAgency(ctx context.Context, agency models.Agency) context.Context {
+	return context.WithValue(ctx, contextKey{}, agency)
+}
+
+// Agency returns the agency ctx acts for, if any.
+func Agency(ctx context.Context) (models.Agency, bool) {
+	agency, ok := ctx.Value(contextKey{}).(models.Agency)
+	return agency, ok
+}
+
+// ID is the id of the agency ctx acts for. Without one it is uuid.Nil, which
+// no row belongs to, so a query run outside an agency finds nothing and an
+// insert fails.
+func ID(ctx context.Context) uuid.UUID {
+	agency, _ := Agency(ctx)
+	return agency.ID
+}
@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// rowsAffected is the number of rows a statement touched or returned, as
+// reported in the command tag.
+const rowsAffected = attribute.Key("db.rows_affected")
+
+// QueryTracer gives every pgx query and batch a client span carrying the
+// query text, the rows affected and, on failure, the SQLSTATE. Arguments
+// are never recorded: they hold notes and other classified values.
+type QueryTracer struct{}
+
+var (
+	_ pgx.QueryTracer = QueryTracer{}
+	_ pgx.BatchTracer = QueryTracer{}
+)
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	op := operation(data.SQL)
+	ctx, _ = startQuery(ctx, op,
+		semconv.DBSystemNamePostgreSQL,
+		semconv.DBOperationName(op),
+		semconv.DBQueryText(data.SQL),
+	)
+	return ctx
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+	if data.Err != nil {
+		failQuery(span, data.Err)
+		return
+	}
+	span.SetAttributes(rowsAffected.Int64(data.CommandTag.RowsAffected()))
+}
+
+func (QueryTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	ctx, _ = startQuery(ctx, "BATCH",
+		semconv.DBSystemNamePostgreSQL,
+		semconv.DBOperationName("BATCH"),
+		semconv.DBOperationBatchSize(data.Batch.Len()),
+	)
+	return ctx
+}
+
+// TraceBatchQuery is called once a queued query has its result, so each is
+// an event on the batch span rather than a span of its own.
+func (QueryTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	attrs := []attribute.KeyValue{semconv.DBQueryText(data.SQL)}
+	if data.Err != nil {
+		attrs = append(attrs, attribute.String("error.message", data.Err.Error()))
+		if code := sqlState(data.Err); code != "" {
+			attrs = append(attrs, semconv.DBResponseStatusCode(code))
+		}
+	} else {
+		attrs = append(attrs, rowsAffected.Int64(data.CommandTag.RowsAffected()))
+	}
+	trace.SpanFromContext(ctx).AddEvent("query", trace.WithAttributes(attrs...))
+}
+
+func (QueryTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+	if data.Err != nil {
+		failQuery(span, data.Err)
+	}
+}
+
+// startQuery starts a client span for a round trip to the database. Like
+// Start, it needs a parent.
+func startQuery(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return otel.Tracer(instrumentation).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+func failQuery(span trace.Span, err error) {
+	if code := sqlState(err); code != "" {
+		span.SetAttributes(semconv.DBResponseStatusCode(code))
+	}
+	Fail(span, err)
+}
+
+// sqlState returns the SQLSTATE of a server error, or "" for any other
+// failure.
+func sqlState(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// operation is the leading SQL keyword, e.g. SELECT, which names the span.
+func operation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
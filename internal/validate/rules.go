@@ -0,0 +1,180 @@
+package validate
+
+import (
+	"math"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+// maxTargets is the most targets a mission, or a template, may have.
+const maxTargets = 3
+
+// Mission checks a new mission and its targets. Text is trimmed and
+// countries are resolved to their codes in place.
+func Mission(mission *models.Mission, targets []models.Target) error {
+	var r Report
+	mission.Title = strings.TrimSpace(mission.Title)
+	if mission.Title == "" {
+		r.Add(Pointer("title"), "mission title cannot be empty")
+	}
+	if len(targets) < 1 || len(targets) > maxTargets {
+		r.Add(Pointer("targets"), "mission must have between 1 and %d targets", maxTargets)
+	}
+	for i := range targets {
+		r.target(Pointer("targets", i), &targets[i])
+	}
+	return r.Err()
+}
+
+// Target checks a target added to an existing mission.
+func Target(target *models.Target) error {
+	var r Report
+	r.target("", target)
+	return r.Err()
+}
+
+func (r *Report) target(at string, target *models.Target) {
+	target.Name = strings.TrimSpace(target.Name)
+	target.City = strings.TrimSpace(target.City)
+	target.Address = strings.TrimSpace(target.Address)
+	if target.Name == "" {
+		r.Add(at+Pointer("name"), "target name cannot be empty")
+	}
+	if code, ok := r.Country(at+Pointer("country"), target.Country); ok {
+		target.Country = code
+	} else if strings.TrimSpace(target.Country) == "" {
+		r.Add(at+Pointer("country"), "target country cannot be empty")
+	}
+	r.Coordinates(at, target.Latitude, target.Longitude)
+}
+
+// Country resolves free-form input to its ISO 3166-1 alpha-2 code. Empty
+// input is not resolved and not reported.
+func (r *Report) Country(at, value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", false
+	}
+	c, ok := models.ResolveCountry(value)
+	if !ok {
+		r.Add(at, "unknown country %q", value)
+		return "", false
+	}
+	return c.Code, true
+}
+
+// Coordinates checks an optional WGS 84 point held as latitude and
+// longitude fields of the object at the pointer.
+func (r *Report) Coordinates(at string, lat, lon *float64) {
+	coordinates(lat, lon, func(latitude bool, format string, args ...any) {
+		field := "longitude"
+		if latitude {
+			field = "latitude"
+		}
+		r.Add(at+Pointer(field), format, args...)
+	})
+}
+
+// CoordinateParams checks an optional WGS 84 point given as two query
+// parameters.
+func (r *Report) CoordinateParams(latName, lonName string, lat, lon *float64) {
+	coordinates(lat, lon, func(latitude bool, format string, args ...any) {
+		name := lonName
+		if latitude {
+			name = latName
+		}
+		r.AddParam(name, format, args...)
+	})
+}
+
+func coordinates(lat, lon *float64, fail func(latitude bool, format string, args ...any)) {
+	switch {
+	case lat == nil && lon == nil:
+		return
+	case lat == nil:
+		fail(true, "latitude and longitude must be given together")
+		return
+	case lon == nil:
+		fail(false, "latitude and longitude must be given together")
+		return
+	}
+	if math.IsNaN(*lat) || *lat < -90 || *lat > 90 {
+		fail(true, "latitude must be between -90 and 90, got %g", *lat)
+	}
+	if math.IsNaN(*lon) || *lon < -180 || *lon > 180 {
+		fail(false, "longitude must be between -180 and 180, got %g", *lon)
+	}
+}
+
+// Cat checks a new cat. The breed must already be looked up.
+func Cat(cat *models.SpyCat) error {
+	var r Report
+	cat.Name = strings.TrimSpace(cat.Name)
+	if cat.Name == "" {
+		r.Add(Pointer("name"), "cat name cannot be empty")
+	}
+	if cat.Breed.ID == uuid.Nil {
+		r.Add(Pointer("breed"), "breed cannot be empty")
+	}
+	r.experience(cat.ExpYears)
+	r.salary(cat.Salary)
+	return r.Err()
+}
+
+// Salary checks a cat's new salary.
+func Salary(salary float32) error {
+	var r Report
+	r.salary(salary)
+	return r.Err()
+}
+
+// Experience checks a cat's new years of experience.
+func Experience(years int) error {
+	var r Report
+	r.experience(years)
+	return r.Err()
+}
+
+func (r *Report) salary(salary float32) {
+	if salary < 0 {
+		r.Add(Pointer("salary"), "salary cannot be negative")
+	}
+}
+
+func (r *Report) experience(years int) {
+	if years < 0 {
+		r.Add(Pointer("years_experience"), "experience cannot be negative")
+	}
+}
+
+// Template checks a mission template. Placeholders may leave the country
+// for the mission to fill in.
+func Template(t *models.MissionTemplate) error {
+	var r Report
+	t.Name = strings.TrimSpace(t.Name)
+	t.Title = strings.TrimSpace(t.Title)
+	if t.Name == "" {
+		r.Add(Pointer("name"), "template name cannot be empty")
+	}
+	if t.Title == "" {
+		r.Add(Pointer("title"), "template title cannot be empty")
+	}
+	if len(t.Targets) < 1 || len(t.Targets) > maxTargets {
+		r.Add(Pointer("targets"), "mission must have between 1 and %d targets", maxTargets)
+	}
+	for i := range t.Targets {
+		placeholder := &t.Targets[i]
+		placeholder.Name = strings.TrimSpace(placeholder.Name)
+		if placeholder.Name == "" {
+			r.Add(Pointer("targets", i, "name"), "target name cannot be empty")
+		}
+		if code, ok := r.Country(Pointer("targets", i, "country"), placeholder.Country); ok {
+			placeholder.Country = code
+		} else {
+			placeholder.Country = strings.TrimSpace(placeholder.Country)
+		}
+	}
+	return r.Err()
+}
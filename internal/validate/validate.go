@@ -0,0 +1,121 @@
+// Package validate checks input before it is stored and reports every
+// problem at once instead of stopping at the first. The HTTP API, mission
+// and template imports and scactl all go through the same rules.
+//
+// A violation names the offending field with a JSON pointer (RFC 6901)
+// into the input, e.g. /targets/2/country, or a query parameter by name.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Violation is one problem with one field.
+type Violation struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Message   string `json:"message"`
+}
+
+func (v Violation) String() string {
+	switch {
+	case v.Pointer != "":
+		return v.Pointer + ": " + v.Message
+	case v.Parameter != "":
+		return v.Parameter + ": " + v.Message
+	default:
+		return v.Message
+	}
+}
+
+// Errors is every violation found in one input.
+type Errors []Violation
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, v := range e {
+		msgs[i] = v.String()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// As returns the violations in err, if it holds any.
+func As(err error) (Errors, bool) {
+	var invalid Errors
+	if errors.As(err, &invalid) {
+		return invalid, true
+	}
+	return nil, false
+}
+
+// Pointer builds a JSON pointer from object keys and array indexes.
+func Pointer(segments ...any) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteByte('/')
+		switch s := s.(type) {
+		case int:
+			b.WriteString(strconv.Itoa(s))
+		case string:
+			b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(s))
+		default:
+			b.WriteString(fmt.Sprint(s))
+		}
+	}
+	return b.String()
+}
+
+// Report collects violations. The zero value is ready to use.
+type Report struct {
+	violations Errors
+}
+
+// Add records a problem with the field at pointer.
+func (r *Report) Add(pointer, format string, args ...any) {
+	r.violations = append(r.violations, Violation{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+// AddParam records a problem with a query parameter.
+func (r *Report) AddParam(name, format string, args ...any) {
+	r.violations = append(r.violations, Violation{Parameter: name, Message: fmt.Sprintf(format, args...)})
+}
+
+// Merge adds the violations in err. Fields that already have a violation
+// keep only their first, so a missing field is not also reported as empty.
+func (r *Report) Merge(err error) {
+	if err == nil {
+		return
+	}
+	invalid, ok := As(err)
+	if !ok {
+		invalid = Errors{{Message: err.Error()}}
+	}
+	for _, v := range invalid {
+		if !r.has(v) {
+			r.violations = append(r.violations, v)
+		}
+	}
+}
+
+func (r *Report) has(v Violation) bool {
+	if v.Pointer == "" && v.Parameter == "" {
+		return false
+	}
+	for _, existing := range r.violations {
+		if existing.Pointer == v.Pointer && existing.Parameter == v.Parameter {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns the violations as Errors, or nil when there are none.
+func (r *Report) Err() error {
+	if len(r.violations) == 0 {
+		return nil
+	}
+	return r.violations
+}
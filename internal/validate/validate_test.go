@@ -0,0 +1,120 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mksmstpck/spy_cat_agency/internal/models"
+)
+
+func TestPointer(t *testing.T) {
+	tests := []struct {
+		segments []any
+		want     string
+	}{
+		{nil, ""},
+		{[]any{"title"}, "/title"},
+		{[]any{"targets", 2, "country"}, "/targets/2/country"},
+		{[]any{"a/b", "m~n"}, "/a~1b/m~0n"},
+	}
+	for _, tt := range tests {
+		if got := Pointer(tt.segments...); got != tt.want {
+			t.Errorf("Pointer(%v) = %q, want %q", tt.segments, got, tt.want)
+		}
+	}
+}
+
+func TestReportMerge(t *testing.T) {
+	tests := []struct {
+		name  string
+		first Errors
+		merge error
+		want  Errors
+	}{
+		{"nothing", nil, nil, nil},
+		{"new field", Errors{{Pointer: "/name", Message: "is required"}},
+			Errors{{Pointer: "/salary", Message: "salary cannot be negative"}},
+			Errors{{Pointer: "/name", Message: "is required"}, {Pointer: "/salary", Message: "salary cannot be negative"}}},
+		{"field already reported", Errors{{Pointer: "/name", Message: "is required"}},
+			Errors{{Pointer: "/name", Message: "cat name cannot be empty"}},
+			Errors{{Pointer: "/name", Message: "is required"}}},
+		{"same name, parameter and pointer", Errors{{Parameter: "limit", Message: "must be an integer"}},
+			Errors{{Pointer: "limit", Message: "too big"}},
+			Errors{{Parameter: "limit", Message: "must be an integer"}, {Pointer: "limit", Message: "too big"}}},
+		{"plain error", nil, errors.New("breed lookup failed"),
+			Errors{{Message: "breed lookup failed"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Report{violations: tt.first}
+			r.Merge(tt.merge)
+
+			err := r.Err()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				return
+			}
+			got, ok := As(err)
+			if !ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(f float64) *float64 { return &f }
+
+func TestMissionReportsEveryViolation(t *testing.T) {
+	tests := []struct {
+		name    string
+		mission models.Mission
+		targets []models.Target
+		want    []string
+	}{
+		{"valid", models.Mission{Title: " Yarn "}, []models.Target{{Name: "Mr. Whiskers", Country: "Austria"}}, nil},
+		{"no targets", models.Mission{Title: "Yarn"}, nil, []string{"/targets"}},
+		{"everything wrong", models.Mission{Title: " "}, []models.Target{
+			{Name: "Mr. Whiskers", Country: "Austria"},
+			{Name: "", Country: "Narnia"},
+			{Name: "Tom", Country: "", Latitude: ptr(91)},
+		}, []string{"/title", "/targets/1/name", "/targets/1/country", "/targets/2/country", "/targets/2/longitude"}},
+		{"out of range", models.Mission{Title: "Yarn"}, []models.Target{
+			{Name: "Tom", Country: "AT", Latitude: ptr(91), Longitude: ptr(-181)},
+		}, []string{"/targets/0/latitude", "/targets/0/longitude"}},
+		{"too many targets", models.Mission{Title: "Yarn"}, []models.Target{
+			{Name: "a", Country: "AT"}, {Name: "b", Country: "AT"}, {Name: "c", Country: "AT"}, {Name: "d", Country: "AT"},
+		}, []string{"/targets"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Mission(&tt.mission, tt.targets)
+
+			var got []string
+			if invalid, ok := As(err); ok {
+				for _, v := range invalid {
+					got = append(got, v.Pointer)
+				}
+			} else if err != nil {
+				t.Fatalf("got %v, want violations", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got violations at %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissionResolvesInPlace(t *testing.T) {
+	mission := models.Mission{Title: "  Yarn  "}
+	targets := []models.Target{{Name: " Mr. Whiskers ", Country: "austria"}}
+
+	if err := Mission(&mission, targets); err != nil {
+		t.Fatal(err)
+	}
+	if mission.Title != "Yarn" || targets[0].Name != "Mr. Whiskers" || targets[0].Country != "AT" {
+		t.Errorf("got %q, %q and %q, want them trimmed and the country resolved", mission.Title, targets[0].Name, targets[0].Country)
+	}
+}
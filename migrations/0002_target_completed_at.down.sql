@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS trg_set_target_completed_at ON targets;
+DROP FUNCTION IF EXISTS set_target_completed_at;
+
+DROP INDEX IF EXISTS idx_targets_completed_at;
+ALTER TABLE targets DROP COLUMN IF EXISTS completed_at;
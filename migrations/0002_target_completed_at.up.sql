@@ -0,0 +1,21 @@
+ALTER TABLE targets ADD COLUMN completed_at TIMESTAMPTZ;
+
+ALTER TABLE targets DISABLE TRIGGER targets_touch_updated_at;
+UPDATE targets SET completed_at = updated_at WHERE completed = TRUE;
+ALTER TABLE targets ENABLE TRIGGER targets_touch_updated_at;
+
+CREATE INDEX idx_targets_completed_at ON targets (completed_at) WHERE completed = TRUE;
+
+CREATE OR REPLACE FUNCTION set_target_completed_at() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+    IF NEW.completed AND NOT OLD.completed THEN
+        NEW.completed_at := now();
+    ELSIF NOT NEW.completed THEN
+        NEW.completed_at := NULL;
+    END IF;
+    RETURN NEW;
+END;
+$$;
+CREATE TRIGGER trg_set_target_completed_at
+    BEFORE UPDATE ON targets
+    FOR EACH ROW EXECUTE FUNCTION set_target_completed_at();
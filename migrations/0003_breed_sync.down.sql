@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS breeds_touch_updated_at ON breeds;
+
+ALTER TABLE breeds DROP COLUMN IF EXISTS removed_at;
+ALTER TABLE breeds DROP COLUMN IF EXISTS updated_at;
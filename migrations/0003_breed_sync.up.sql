@@ -0,0 +1,5 @@
+ALTER TABLE breeds ADD COLUMN updated_at TIMESTAMPTZ DEFAULT now();
+ALTER TABLE breeds ADD COLUMN removed_at TIMESTAMPTZ;
+
+CREATE TRIGGER breeds_touch_updated_at BEFORE UPDATE ON breeds
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_breeds_profile;
+
+ALTER TABLE breeds DROP COLUMN IF EXISTS profile;
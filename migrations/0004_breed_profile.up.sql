@@ -0,0 +1,3 @@
+ALTER TABLE breeds ADD COLUMN profile JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_breeds_profile ON breeds USING GIN (profile);
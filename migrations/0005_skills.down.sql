@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS target_required_skills;
+DROP TABLE IF EXISTS cat_skills;
+DROP TABLE IF EXISTS skills;
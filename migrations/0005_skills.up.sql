@@ -0,0 +1,45 @@
+CREATE TABLE skills (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    category TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT chk_skill_code CHECK (code ~ '^[a-z][a-z0-9_]*$')
+);
+
+CREATE TABLE cat_skills (
+    cat_id UUID NOT NULL REFERENCES cats(id) ON DELETE CASCADE,
+    skill_id UUID NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+    proficiency INT NOT NULL CHECK (proficiency BETWEEN 1 AND 5),
+    PRIMARY KEY (cat_id, skill_id)
+);
+
+CREATE TABLE target_required_skills (
+    target_id UUID NOT NULL REFERENCES targets(id) ON DELETE CASCADE,
+    skill_id UUID NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+    min_proficiency INT NOT NULL CHECK (min_proficiency BETWEEN 1 AND 5),
+    PRIMARY KEY (target_id, skill_id)
+);
+
+INSERT INTO skills (code, name, category) VALUES
+    ('lang_english', 'English', 'language'),
+    ('lang_french', 'French', 'language'),
+    ('lang_german', 'German', 'language'),
+    ('lang_spanish', 'Spanish', 'language'),
+    ('lang_russian', 'Russian', 'language'),
+    ('lang_arabic', 'Arabic', 'language'),
+    ('lang_mandarin', 'Mandarin', 'language'),
+    ('infiltration', 'Infiltration', 'field'),
+    ('surveillance', 'Surveillance', 'field'),
+    ('stealth', 'Stealth', 'field'),
+    ('climbing', 'Climbing', 'field'),
+    ('driving', 'Evasive driving', 'field'),
+    ('lockpicking', 'Lockpicking', 'technical'),
+    ('hacking', 'Hacking', 'technical'),
+    ('cryptography', 'Cryptography', 'technical'),
+    ('forgery', 'Forgery', 'technical'),
+    ('sabotage', 'Sabotage', 'technical'),
+    ('disguise', 'Disguise', 'social'),
+    ('interrogation', 'Interrogation', 'social'),
+    ('first_aid', 'First aid', 'support')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS cat_leaves;
+
+ALTER TABLE missions
+    DROP CONSTRAINT IF EXISTS chk_mission_schedule,
+    DROP COLUMN IF EXISTS scheduled_end,
+    DROP COLUMN IF EXISTS scheduled_start;
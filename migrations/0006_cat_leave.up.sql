@@ -0,0 +1,17 @@
+ALTER TABLE missions
+    ADD COLUMN scheduled_start DATE,
+    ADD COLUMN scheduled_end DATE,
+    ADD CONSTRAINT chk_mission_schedule
+        CHECK (scheduled_start IS NULL OR scheduled_end IS NULL OR scheduled_end >= scheduled_start);
+
+CREATE TABLE cat_leaves (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    cat_id UUID NOT NULL REFERENCES cats(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('vacation', 'medical', 'training')),
+    starts_on DATE NOT NULL,
+    ends_on DATE NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT chk_leave_dates CHECK (ends_on >= starts_on)
+);
+CREATE INDEX idx_cat_leaves_cat_dates ON cat_leaves (cat_id, starts_on, ends_on);
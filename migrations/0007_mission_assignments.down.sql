@@ -0,0 +1,24 @@
+ALTER TABLE missions ADD COLUMN assigned_cat_id UUID REFERENCES cats(id) ON DELETE SET NULL;
+
+ALTER TABLE missions DISABLE TRIGGER missions_touch_updated_at;
+UPDATE missions m SET assigned_cat_id = a.cat_id
+FROM mission_assignments a
+WHERE a.mission_id = m.id AND a.role = 'lead' AND a.unassigned_at IS NULL;
+ALTER TABLE missions ENABLE TRIGGER missions_touch_updated_at;
+
+CREATE UNIQUE INDEX uq_missions_assigned_cat_active
+    ON missions(assigned_cat_id)
+    WHERE assigned_cat_id IS NOT NULL AND completed = FALSE;
+
+CREATE OR REPLACE FUNCTION prevent_delete_assigned_mission() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+    IF OLD.assigned_cat_id IS NOT NULL THEN
+        RAISE EXCEPTION 'Cannot delete mission %: assigned to cat %', OLD.id, OLD.assigned_cat_id;
+    END IF;
+    RETURN OLD;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS trg_sync_assignment_mission_completed ON missions;
+DROP FUNCTION IF EXISTS sync_assignment_mission_completed();
+DROP TABLE IF EXISTS mission_assignments;
@@ -0,0 +1,60 @@
+CREATE TABLE mission_assignments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    mission_id UUID NOT NULL REFERENCES missions(id) ON DELETE CASCADE,
+    cat_id UUID NOT NULL REFERENCES cats(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('lead', 'support', 'backup')),
+    assigned_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    unassigned_at TIMESTAMPTZ,
+    unassign_reason TEXT,
+    -- Mirrors missions.completed so the indexes below can tell active
+    -- assignments apart.
+    mission_completed BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT chk_unassigned_after_assigned CHECK (unassigned_at IS NULL OR unassigned_at >= assigned_at)
+);
+CREATE INDEX idx_mission_assignments_mission ON mission_assignments (mission_id);
+
+-- One active mission per cat.
+CREATE UNIQUE INDEX uq_assignments_cat_active
+    ON mission_assignments (cat_id)
+    WHERE unassigned_at IS NULL AND mission_completed = FALSE;
+-- One lead per mission, and each cat at most once per mission.
+CREATE UNIQUE INDEX uq_assignments_mission_lead
+    ON mission_assignments (mission_id)
+    WHERE unassigned_at IS NULL AND role = 'lead';
+CREATE UNIQUE INDEX uq_assignments_mission_cat
+    ON mission_assignments (mission_id, cat_id)
+    WHERE unassigned_at IS NULL;
+
+INSERT INTO mission_assignments (mission_id, cat_id, role, assigned_at, mission_completed)
+SELECT id, assigned_cat_id, 'lead', COALESCE(created_at, now()), completed
+FROM missions
+WHERE assigned_cat_id IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION sync_assignment_mission_completed() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+    UPDATE mission_assignments SET mission_completed = NEW.completed WHERE mission_id = NEW.id;
+    RETURN NEW;
+END;
+$$;
+CREATE TRIGGER trg_sync_assignment_mission_completed
+    AFTER UPDATE ON missions
+    FOR EACH ROW
+    WHEN (OLD.completed IS DISTINCT FROM NEW.completed)
+    EXECUTE FUNCTION sync_assignment_mission_completed();
+
+CREATE OR REPLACE FUNCTION prevent_delete_assigned_mission() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    cat UUID;
+BEGIN
+    SELECT cat_id INTO cat FROM mission_assignments
+        WHERE mission_id = OLD.id AND unassigned_at IS NULL
+        LIMIT 1;
+    IF cat IS NOT NULL THEN
+        RAISE EXCEPTION 'Cannot delete mission %: assigned to cat %', OLD.id, cat;
+    END IF;
+    RETURN OLD;
+END;
+$$;
+
+DROP INDEX uq_missions_assigned_cat_active;
+ALTER TABLE missions DROP COLUMN assigned_cat_id;
@@ -0,0 +1,16 @@
+DROP TRIGGER IF EXISTS trg_set_mission_completed_at ON missions;
+DROP FUNCTION IF EXISTS set_mission_completed_at();
+ALTER TABLE missions DROP COLUMN IF EXISTS completed_at;
+
+DROP INDEX IF EXISTS idx_mission_assignments_cat;
+DELETE FROM mission_assignments WHERE cat_id IS NULL;
+ALTER TABLE mission_assignments
+    DROP CONSTRAINT mission_assignments_cat_id_fkey,
+    ADD CONSTRAINT mission_assignments_cat_id_fkey
+        FOREIGN KEY (cat_id) REFERENCES cats(id) ON DELETE CASCADE,
+    ALTER COLUMN cat_id SET NOT NULL;
+
+ALTER TABLE mission_assignments
+    DROP COLUMN IF EXISTS unassigned_by,
+    DROP COLUMN IF EXISTS assigned_by,
+    DROP COLUMN IF EXISTS cat_name;
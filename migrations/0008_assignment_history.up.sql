@@ -0,0 +1,34 @@
+ALTER TABLE mission_assignments
+    ADD COLUMN cat_name TEXT,
+    ADD COLUMN assigned_by TEXT NOT NULL DEFAULT 'system',
+    ADD COLUMN unassigned_by TEXT;
+
+UPDATE mission_assignments a SET cat_name = c.name FROM cats c WHERE c.id = a.cat_id;
+
+-- Keep the history when a cat is deleted; cat_name still says who it was.
+ALTER TABLE mission_assignments
+    ALTER COLUMN cat_id DROP NOT NULL,
+    DROP CONSTRAINT mission_assignments_cat_id_fkey,
+    ADD CONSTRAINT mission_assignments_cat_id_fkey
+        FOREIGN KEY (cat_id) REFERENCES cats(id) ON DELETE SET NULL;
+CREATE INDEX idx_mission_assignments_cat ON mission_assignments (cat_id, assigned_at);
+
+ALTER TABLE missions ADD COLUMN completed_at TIMESTAMPTZ;
+
+ALTER TABLE missions DISABLE TRIGGER missions_touch_updated_at;
+UPDATE missions SET completed_at = updated_at WHERE completed = TRUE;
+ALTER TABLE missions ENABLE TRIGGER missions_touch_updated_at;
+
+CREATE OR REPLACE FUNCTION set_mission_completed_at() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+    IF NEW.completed AND NOT OLD.completed THEN
+        NEW.completed_at := now();
+    ELSIF NOT NEW.completed THEN
+        NEW.completed_at := NULL;
+    END IF;
+    RETURN NEW;
+END;
+$$;
+CREATE TRIGGER trg_set_mission_completed_at
+    BEFORE UPDATE ON missions
+    FOR EACH ROW EXECUTE FUNCTION set_mission_completed_at();
@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS trg_prevent_retiring_assigned_cat ON cats;
+DROP FUNCTION IF EXISTS prevent_retiring_assigned_cat();
+DROP TRIGGER IF EXISTS trg_ensure_assignable_cat ON mission_assignments;
+DROP FUNCTION IF EXISTS ensure_assignable_cat();
+
+DROP INDEX IF EXISTS idx_cats_status;
+ALTER TABLE cats
+    DROP CONSTRAINT IF EXISTS chk_cat_retired_at,
+    DROP COLUMN IF EXISTS retirement_reason,
+    DROP COLUMN IF EXISTS retired_at,
+    DROP COLUMN IF EXISTS status;
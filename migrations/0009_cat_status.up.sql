@@ -0,0 +1,40 @@
+ALTER TABLE cats
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'suspended', 'retired')),
+    ADD COLUMN retired_at TIMESTAMPTZ,
+    ADD COLUMN retirement_reason TEXT,
+    ADD CONSTRAINT chk_cat_retired_at CHECK ((status = 'retired') = (retired_at IS NOT NULL));
+CREATE INDEX idx_cats_status ON cats (status);
+
+CREATE OR REPLACE FUNCTION ensure_assignable_cat() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    cat_status TEXT;
+BEGIN
+    SELECT status INTO cat_status FROM cats WHERE id = NEW.cat_id;
+    IF cat_status IS DISTINCT FROM 'active' THEN
+        RAISE EXCEPTION 'Cannot assign cat %: cat is %', NEW.cat_id, cat_status;
+    END IF;
+    RETURN NEW;
+END;
+$$;
+CREATE TRIGGER trg_ensure_assignable_cat
+    BEFORE INSERT ON mission_assignments
+    FOR EACH ROW EXECUTE FUNCTION ensure_assignable_cat();
+
+CREATE OR REPLACE FUNCTION prevent_retiring_assigned_cat() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    mission UUID;
+BEGIN
+    SELECT mission_id INTO mission FROM mission_assignments
+        WHERE cat_id = NEW.id AND unassigned_at IS NULL AND mission_completed = FALSE
+        LIMIT 1;
+    IF mission IS NOT NULL THEN
+        RAISE EXCEPTION 'Cannot retire cat %: still on active mission %', NEW.id, mission;
+    END IF;
+    RETURN NEW;
+END;
+$$;
+CREATE TRIGGER trg_prevent_retiring_assigned_cat
+    BEFORE UPDATE ON cats
+    FOR EACH ROW
+    WHEN (NEW.status = 'retired' AND OLD.status IS DISTINCT FROM 'retired')
+    EXECUTE FUNCTION prevent_retiring_assigned_cat();
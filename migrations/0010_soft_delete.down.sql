@@ -0,0 +1,73 @@
+DELETE FROM missions WHERE deleted_at IS NOT NULL;
+DELETE FROM targets WHERE deleted_at IS NOT NULL;
+
+DROP TRIGGER IF EXISTS trg_check_mission_soft_delete ON missions;
+DROP FUNCTION IF EXISTS check_mission_soft_delete();
+DROP TRIGGER IF EXISTS trg_check_target_soft_delete ON targets;
+DROP FUNCTION IF EXISTS check_target_soft_delete();
+DROP TRIGGER IF EXISTS trg_targets_count_after_soft_delete ON targets;
+
+CREATE OR REPLACE FUNCTION ensure_targets_count_bounds() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    t_count INT;
+    mission UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        mission := OLD.mission_id;
+    ELSE
+        mission := COALESCE(NEW.mission_id, OLD.mission_id);
+    END IF;
+
+    SELECT COUNT(*) INTO t_count FROM targets WHERE mission_id = mission;
+
+    IF t_count < 1 THEN
+        RAISE EXCEPTION 'Mission % must have at least 1 target (current: %)', mission, t_count;
+    ELSIF t_count > 3 THEN
+        RAISE EXCEPTION 'Mission % cannot have more than 3 targets (current: %)', mission, t_count;
+    END IF;
+
+    RETURN NULL;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION prevent_delete_completed_target() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+    IF OLD.completed THEN
+        RAISE EXCEPTION 'Cannot delete target %: it is completed', OLD.id;
+    END IF;
+    RETURN OLD;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION ensure_all_targets_completed_before_marking_mission() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    incomplete_count INT;
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.completed = TRUE AND OLD.completed = FALSE THEN
+        SELECT COUNT(*) INTO incomplete_count FROM targets WHERE mission_id = NEW.id AND completed = FALSE;
+        IF incomplete_count > 0 THEN
+            RAISE EXCEPTION 'Cannot complete mission %: % targets still incomplete', NEW.id, incomplete_count;
+        END IF;
+    END IF;
+    RETURN NEW;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION auto_complete_mission_when_all_targets_done() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    incomplete_count INT;
+BEGIN
+    IF OLD.completed = FALSE AND NEW.completed = TRUE THEN
+        SELECT COUNT(*) INTO incomplete_count FROM targets WHERE mission_id = NEW.mission_id AND completed = FALSE;
+        IF incomplete_count = 0 THEN
+            UPDATE missions SET completed = TRUE, updated_at = now() WHERE id = NEW.mission_id;
+        END IF;
+    END IF;
+    RETURN NEW;
+END;
+$$;
+
+DROP INDEX IF EXISTS idx_targets_deleted_at;
+DROP INDEX IF EXISTS idx_missions_deleted_at;
+ALTER TABLE targets DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE missions DROP COLUMN IF EXISTS deleted_at;
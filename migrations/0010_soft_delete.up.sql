@@ -0,0 +1,133 @@
+ALTER TABLE missions ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE targets ADD COLUMN deleted_at TIMESTAMPTZ;
+CREATE INDEX idx_missions_deleted_at ON missions (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_targets_deleted_at ON targets (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Only live targets of a live mission count towards the 1-3 rule. Skipping
+-- missions that are gone or deleted also lets a purge cascade to targets.
+CREATE OR REPLACE FUNCTION ensure_targets_count_bounds() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    t_count INT;
+    mission UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        mission := OLD.mission_id;
+    ELSE
+        mission := COALESCE(NEW.mission_id, OLD.mission_id);
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM missions WHERE id = mission AND deleted_at IS NULL) THEN
+        RETURN NULL;
+    END IF;
+
+    SELECT COUNT(*) INTO t_count FROM targets WHERE mission_id = mission AND deleted_at IS NULL;
+
+    IF t_count < 1 THEN
+        RAISE EXCEPTION 'Mission % must have at least 1 target (current: %)', mission, t_count;
+    ELSIF t_count > 3 THEN
+        RAISE EXCEPTION 'Mission % cannot have more than 3 targets (current: %)', mission, t_count;
+    END IF;
+
+    RETURN NULL;
+END;
+$$;
+CREATE TRIGGER trg_targets_count_after_soft_delete
+    AFTER UPDATE ON targets
+    FOR EACH ROW
+    WHEN (OLD.deleted_at IS DISTINCT FROM NEW.deleted_at)
+    EXECUTE FUNCTION ensure_targets_count_bounds();
+
+CREATE OR REPLACE FUNCTION prevent_delete_completed_target() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+    IF OLD.completed AND EXISTS (SELECT 1 FROM missions WHERE id = OLD.mission_id) THEN
+        RAISE EXCEPTION 'Cannot delete target %: it is completed', OLD.id;
+    END IF;
+    RETURN OLD;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION check_target_soft_delete() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    m_completed BOOLEAN;
+    m_deleted TIMESTAMPTZ;
+BEGIN
+    IF NEW.deleted_at IS NOT NULL AND OLD.deleted_at IS NULL THEN
+        IF OLD.completed THEN
+            RAISE EXCEPTION 'Cannot delete target %: it is completed', OLD.id;
+        END IF;
+    ELSIF NEW.deleted_at IS NULL AND OLD.deleted_at IS NOT NULL THEN
+        SELECT completed, deleted_at INTO m_completed, m_deleted FROM missions WHERE id = NEW.mission_id;
+        IF m_deleted IS NOT NULL THEN
+            RAISE EXCEPTION 'Cannot restore target %: mission % is deleted', NEW.id, NEW.mission_id;
+        END IF;
+        IF m_completed AND NOT NEW.completed THEN
+            RAISE EXCEPTION 'Cannot add target to mission %: mission completed', NEW.mission_id;
+        END IF;
+    END IF;
+    RETURN NEW;
+END;
+$$;
+CREATE TRIGGER trg_check_target_soft_delete
+    BEFORE UPDATE ON targets
+    FOR EACH ROW
+    WHEN (OLD.deleted_at IS DISTINCT FROM NEW.deleted_at)
+    EXECUTE FUNCTION check_target_soft_delete();
+
+CREATE OR REPLACE FUNCTION check_mission_soft_delete() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    cat UUID;
+    t_count INT;
+BEGIN
+    IF NEW.deleted_at IS NOT NULL AND OLD.deleted_at IS NULL THEN
+        SELECT cat_id INTO cat FROM mission_assignments
+            WHERE mission_id = OLD.id AND unassigned_at IS NULL
+            LIMIT 1;
+        IF cat IS NOT NULL THEN
+            RAISE EXCEPTION 'Cannot delete mission %: assigned to cat %', OLD.id, cat;
+        END IF;
+    ELSIF NEW.deleted_at IS NULL AND OLD.deleted_at IS NOT NULL THEN
+        SELECT COUNT(*) INTO t_count FROM targets WHERE mission_id = NEW.id AND deleted_at IS NULL;
+        IF t_count < 1 THEN
+            RAISE EXCEPTION 'Mission % must have at least 1 target (current: %)', NEW.id, t_count;
+        ELSIF t_count > 3 THEN
+            RAISE EXCEPTION 'Mission % cannot have more than 3 targets (current: %)', NEW.id, t_count;
+        END IF;
+    END IF;
+    RETURN NEW;
+END;
+$$;
+CREATE TRIGGER trg_check_mission_soft_delete
+    BEFORE UPDATE ON missions
+    FOR EACH ROW
+    WHEN (OLD.deleted_at IS DISTINCT FROM NEW.deleted_at)
+    EXECUTE FUNCTION check_mission_soft_delete();
+
+CREATE OR REPLACE FUNCTION ensure_all_targets_completed_before_marking_mission() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    incomplete_count INT;
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.completed = TRUE AND OLD.completed = FALSE THEN
+        SELECT COUNT(*) INTO incomplete_count FROM targets
+            WHERE mission_id = NEW.id AND completed = FALSE AND deleted_at IS NULL;
+        IF incomplete_count > 0 THEN
+            RAISE EXCEPTION 'Cannot complete mission %: % targets still incomplete', NEW.id, incomplete_count;
+        END IF;
+    END IF;
+    RETURN NEW;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION auto_complete_mission_when_all_targets_done() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    incomplete_count INT;
+BEGIN
+    IF OLD.completed = FALSE AND NEW.completed = TRUE THEN
+        SELECT COUNT(*) INTO incomplete_count FROM targets
+            WHERE mission_id = NEW.mission_id AND completed = FALSE AND deleted_at IS NULL;
+        IF incomplete_count = 0 THEN
+            UPDATE missions SET completed = TRUE, updated_at = now() WHERE id = NEW.mission_id;
+        END IF;
+    END IF;
+    RETURN NEW;
+END;
+$$;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_targets_search;
+ALTER TABLE targets DROP COLUMN IF EXISTS search_vector;
+
+DROP INDEX IF EXISTS idx_missions_search;
+ALTER TABLE missions DROP COLUMN IF EXISTS search_vector;
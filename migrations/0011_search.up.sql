@@ -0,0 +1,14 @@
+-- Generated columns keep the search vectors in step with every insert and
+-- update, so no trigger or application code has to maintain them.
+ALTER TABLE missions ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B')
+) STORED;
+CREATE INDEX idx_missions_search ON missions USING GIN (search_vector);
+
+ALTER TABLE targets ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(country, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(notes, '')), 'C')
+) STORED;
+CREATE INDEX idx_targets_search ON targets USING GIN (search_vector);
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_targets_search;
+DROP TRIGGER IF EXISTS trg_targets_search_vector ON targets;
+DROP FUNCTION IF EXISTS targets_search_vector();
+ALTER TABLE targets DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE targets ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(country, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(notes, '')), 'C')
+) STORED;
+CREATE INDEX idx_targets_search ON targets USING GIN (search_vector);
+
+-- Country codes stay in targets.country; the original free text is gone.
+ALTER TABLE targets DROP CONSTRAINT IF EXISTS fk_targets_country;
+DROP VIEW IF EXISTS unresolved_target_countries;
+DROP TABLE IF EXISTS countries;
@@ -0,0 +1,1304 @@
+-- ISO 3166-1 countries. internal/models/countries.json holds the same list
+-- with aliases; keep the two in step.
+CREATE TABLE countries (
+    code TEXT PRIMARY KEY CHECK (code ~ '^[A-Z]{2}$'),
+    alpha3 TEXT NOT NULL UNIQUE CHECK (alpha3 ~ '^[A-Z]{3}$'),
+    name TEXT NOT NULL
+);
+
+INSERT INTO countries (code, alpha3, name) VALUES
+    ('AD', 'AND', 'Andorra'),
+    ('AE', 'ARE', 'United Arab Emirates'),
+    ('AF', 'AFG', 'Afghanistan'),
+    ('AG', 'ATG', 'Antigua and Barbuda'),
+    ('AI', 'AIA', 'Anguilla'),
+    ('AL', 'ALB', 'Albania'),
+    ('AM', 'ARM', 'Armenia'),
+    ('AO', 'AGO', 'Angola'),
+    ('AQ', 'ATA', 'Antarctica'),
+    ('AR', 'ARG', 'Argentina'),
+    ('AS', 'ASM', 'American Samoa'),
+    ('AT', 'AUT', 'Austria'),
+    ('AU', 'AUS', 'Australia'),
+    ('AW', 'ABW', 'Aruba'),
+    ('AX', 'ALA', 'Åland Islands'),
+    ('AZ', 'AZE', 'Azerbaijan'),
+    ('BA', 'BIH', 'Bosnia and Herzegovina'),
+    ('BB', 'BRB', 'Barbados'),
+    ('BD', 'BGD', 'Bangladesh'),
+    ('BE', 'BEL', 'Belgium'),
+    ('BF', 'BFA', 'Burkina Faso'),
+    ('BG', 'BGR', 'Bulgaria'),
+    ('BH', 'BHR', 'Bahrain'),
+    ('BI', 'BDI', 'Burundi'),
+    ('BJ', 'BEN', 'Benin'),
+    ('BL', 'BLM', 'Saint Barthélemy'),
+    ('BM', 'BMU', 'Bermuda'),
+    ('BN', 'BRN', 'Brunei Darussalam'),
+    ('BO', 'BOL', 'Bolivia, Plurinational State of'),
+    ('BQ', 'BES', 'Bonaire, Sint Eustatius and Saba'),
+    ('BR', 'BRA', 'Brazil'),
+    ('BS', 'BHS', 'Bahamas'),
+    ('BT', 'BTN', 'Bhutan'),
+    ('BV', 'BVT', 'Bouvet Island'),
+    ('BW', 'BWA', 'Botswana'),
+    ('BY', 'BLR', 'Belarus'),
+    ('BZ', 'BLZ', 'Belize'),
+    ('CA', 'CAN', 'Canada'),
+    ('CC', 'CCK', 'Cocos (Keeling) Islands'),
+    ('CD', 'COD', 'Congo, The Democratic Republic of the'),
+    ('CF', 'CAF', 'Central African Republic'),
+    ('CG', 'COG', 'Congo'),
+    ('CH', 'CHE', 'Switzerland'),
+    ('CI', 'CIV', 'Côte d''Ivoire'),
+    ('CK', 'COK', 'Cook Islands'),
+    ('CL', 'CHL', 'Chile'),
+    ('CM', 'CMR', 'Cameroon'),
+    ('CN', 'CHN', 'China'),
+    ('CO', 'COL', 'Colombia'),
+    ('CR', 'CRI', 'Costa Rica'),
+    ('CU', 'CUB', 'Cuba'),
+    ('CV', 'CPV', 'Cabo Verde'),
+    ('CW', 'CUW', 'Curaçao'),
+    ('CX', 'CXR', 'Christmas Island'),
+    ('CY', 'CYP', 'Cyprus'),
+    ('CZ', 'CZE', 'Czechia'),
+    ('DE', 'DEU', 'Germany'),
+    ('DJ', 'DJI', 'Djibouti'),
+    ('DK', 'DNK', 'Denmark'),
+    ('DM', 'DMA', 'Dominica'),
+    ('DO', 'DOM', 'Dominican Republic'),
+    ('DZ', 'DZA', 'Algeria'),
+    ('EC', 'ECU', 'Ecuador'),
+    ('EE', 'EST', 'Estonia'),
+    ('EG', 'EGY', 'Egypt'),
+    ('EH', 'ESH', 'Western Sahara'),
+    ('ER', 'ERI', 'Eritrea'),
+    ('ES', 'ESP', 'Spain'),
+    ('ET', 'ETH', 'Ethiopia'),
+    ('FI', 'FIN', 'Finland'),
+    ('FJ', 'FJI', 'Fiji'),
+    ('FK', 'FLK', 'Falkland Islands (Malvinas)'),
+    ('FM', 'FSM', 'Micronesia, Federated States of'),
+    ('FO', 'FRO', 'Faroe Islands'),
+    ('FR', 'FRA', 'France'),
+    ('GA', 'GAB', 'Gabon'),
+    ('GB', 'GBR', 'United Kingdom'),
+    ('GD', 'GRD', 'Grenada'),
+    ('GE', 'GEO', 'Georgia'),
+    ('GF', 'GUF', 'French Guiana'),
+    ('GG', 'GGY', 'Guernsey'),
+    ('GH', 'GHA', 'Ghana'),
+    ('GI', 'GIB', 'Gibraltar'),
+    ('GL', 'GRL', 'Greenland'),
+    ('GM', 'GMB', 'Gambia'),
+    ('GN', 'GIN', 'Guinea'),
+    ('GP', 'GLP', 'Guadeloupe'),
+    ('GQ', 'GNQ', 'Equatorial Guinea'),
+    ('GR', 'GRC', 'Greece'),
+    ('GS', 'SGS', 'South Georgia and the South Sandwich Islands'),
+    ('GT', 'GTM', 'Guatemala'),
+    ('GU', 'GUM', 'Guam'),
+    ('GW', 'GNB', 'Guinea-Bissau'),
+    ('GY', 'GUY', 'Guyana'),
+    ('HK', 'HKG', 'Hong Kong'),
+    ('HM', 'HMD', 'Heard Island and McDonald Islands'),
+    ('HN', 'HND', 'Honduras'),
+    ('HR', 'HRV', 'Croatia'),
+    ('HT', 'HTI', 'Haiti'),
+    ('HU', 'HUN', 'Hungary'),
+    ('ID', 'IDN', 'Indonesia'),
+    ('IE', 'IRL', 'Ireland'),
+    ('IL', 'ISR', 'Israel'),
+    ('IM', 'IMN', 'Isle of Man'),
+    ('IN', 'IND', 'India'),
+    ('IO', 'IOT', 'British Indian Ocean Territory'),
+    ('IQ', 'IRQ', 'Iraq'),
+    ('IR', 'IRN', 'Iran, Islamic Republic of'),
+    ('IS', 'ISL', 'Iceland'),
+    ('IT', 'ITA', 'Italy'),
+    ('JE', 'JEY', 'Jersey'),
+    ('JM', 'JAM', 'Jamaica'),
+    ('JO', 'JOR', 'Jordan'),
+    ('JP', 'JPN', 'Japan'),
+    ('KE', 'KEN', 'Kenya'),
+    ('KG', 'KGZ', 'Kyrgyzstan'),
+    ('KH', 'KHM', 'Cambodia'),
+    ('KI', 'KIR', 'Kiribati'),
+    ('KM', 'COM', 'Comoros'),
+    ('KN', 'KNA', 'Saint Kitts and Nevis'),
+    ('KP', 'PRK', 'Korea, Democratic People''s Republic of'),
+    ('KR', 'KOR', 'Korea, Republic of'),
+    ('KW', 'KWT', 'Kuwait'),
+    ('KY', 'CYM', 'Cayman Islands'),
+    ('KZ', 'KAZ', 'Kazakhstan'),
+    ('LA', 'LAO', 'Lao People''s Democratic Republic'),
+    ('LB', 'LBN', 'Lebanon'),
+    ('LC', 'LCA', 'Saint Lucia'),
+    ('LI', 'LIE', 'Liechtenstein'),
+    ('LK', 'LKA', 'Sri Lanka'),
+    ('LR', 'LBR', 'Liberia'),
+    ('LS', 'LSO', 'Lesotho'),
+    ('LT', 'LTU', 'Lithuania'),
+    ('LU', 'LUX', 'Luxembourg'),
+    ('LV', 'LVA', 'Latvia'),
+    ('LY', 'LBY', 'Libya'),
+    ('MA', 'MAR', 'Morocco'),
+    ('MC', 'MCO', 'Monaco'),
+    ('MD', 'MDA', 'Moldova, Republic of'),
+    ('ME', 'MNE', 'Montenegro'),
+    ('MF', 'MAF', 'Saint Martin (French part)'),
+    ('MG', 'MDG', 'Madagascar'),
+    ('MH', 'MHL', 'Marshall Islands'),
+    ('MK', 'MKD', 'North Macedonia'),
+    ('ML', 'MLI', 'Mali'),
+    ('MM', 'MMR', 'Myanmar'),
+    ('MN', 'MNG', 'Mongolia'),
+    ('MO', 'MAC', 'Macao'),
+    ('MP', 'MNP', 'Northern Mariana Islands'),
+    ('MQ', 'MTQ', 'Martinique'),
+    ('MR', 'MRT', 'Mauritania'),
+    ('MS', 'MSR', 'Montserrat'),
+    ('MT', 'MLT', 'Malta'),
+    ('MU', 'MUS', 'Mauritius'),
+    ('MV', 'MDV', 'Maldives'),
+    ('MW', 'MWI', 'Malawi'),
+    ('MX', 'MEX', 'Mexico'),
+    ('MY', 'MYS', 'Malaysia'),
+    ('MZ', 'MOZ', 'Mozambique'),
+    ('NA', 'NAM', 'Namibia'),
+    ('NC', 'NCL', 'New Caledonia'),
+    ('NE', 'NER', 'Niger'),
+    ('NF', 'NFK', 'Norfolk Island'),
+    ('NG', 'NGA', 'Nigeria'),
+    ('NI', 'NIC', 'Nicaragua'),
+    ('NL', 'NLD', 'Netherlands'),
+    ('NO', 'NOR', 'Norway'),
+    ('NP', 'NPL', 'Nepal'),
+    ('NR', 'NRU', 'Nauru'),
+    ('NU', 'NIU', 'Niue'),
+    ('NZ', 'NZL', 'New Zealand'),
+    ('OM', 'OMN', 'Oman'),
+    ('PA', 'PAN', 'Panama'),
+    ('PE', 'PER', 'Peru'),
+    ('PF', 'PYF', 'French Polynesia'),
+    ('PG', 'PNG', 'Papua New Guinea'),
+    ('PH', 'PHL', 'Philippines'),
+    ('PK', 'PAK', 'Pakistan'),
+    ('PL', 'POL', 'Poland'),
+    ('PM', 'SPM', 'Saint Pierre and Miquelon'),
+    ('PN', 'PCN', 'Pitcairn'),
+    ('PR', 'PRI', 'Puerto Rico'),
+    ('PS', 'PSE', 'Palestine, State of'),
+    ('PT', 'PRT', 'Portugal'),
+    ('PW', 'PLW', 'Palau'),
+    ('PY', 'PRY', 'Paraguay'),
+    ('QA', 'QAT', 'Qatar'),
+    ('RE', 'REU', 'Réunion'),
+    ('RO', 'ROU', 'Romania'),
+    ('RS', 'SRB', 'Serbia'),
+    ('RU', 'RUS', 'Russian Federation'),
+    ('RW', 'RWA', 'Rwanda'),
+    ('SA', 'SAU', 'Saudi Arabia'),
+    ('SB', 'SLB', 'Solomon Islands'),
+    ('SC', 'SYC', 'Seychelles'),
+    ('SD', 'SDN', 'Sudan'),
+    ('SE', 'SWE', 'Sweden'),
+    ('SG', 'SGP', 'Singapore'),
+    ('SH', 'SHN', 'Saint Helena, Ascension and Tristan da Cunha'),
+    ('SI', 'SVN', 'Slovenia'),
+    ('SJ', 'SJM', 'Svalbard and Jan Mayen'),
+    ('SK', 'SVK', 'Slovakia'),
+    ('SL', 'SLE', 'Sierra Leone'),
+    ('SM', 'SMR', 'San Marino'),
+    ('SN', 'SEN', 'Senegal'),
+    ('SO', 'SOM', 'Somalia'),
+    ('SR', 'SUR', 'Suriname'),
+    ('SS', 'SSD', 'South Sudan'),
+    ('ST', 'STP', 'Sao Tome and Principe'),
+    ('SV', 'SLV', 'El Salvador'),
+    ('SX', 'SXM', 'Sint Maarten (Dutch part)'),
+    ('SY', 'SYR', 'Syrian Arab Republic'),
+    ('SZ', 'SWZ', 'Eswatini'),
+    ('TC', 'TCA', 'Turks and Caicos Islands'),
+    ('TD', 'TCD', 'Chad'),
+    ('TF', 'ATF', 'French Southern Territories'),
+    ('TG', 'TGO', 'Togo'),
+    ('TH', 'THA', 'Thailand'),
+    ('TJ', 'TJK', 'Tajikistan'),
+    ('TK', 'TKL', 'Tokelau'),
+    ('TL', 'TLS', 'Timor-Leste'),
+    ('TM', 'TKM', 'Turkmenistan'),
+    ('TN', 'TUN', 'Tunisia'),
+    ('TO', 'TON', 'Tonga'),
+    ('TR', 'TUR', 'Türkiye'),
+    ('TT', 'TTO', 'Trinidad and Tobago'),
+    ('TV', 'TUV', 'Tuvalu'),
+    ('TW', 'TWN', 'Taiwan, Province of China'),
+    ('TZ', 'TZA', 'Tanzania, United Republic of'),
+    ('UA', 'UKR', 'Ukraine'),
+    ('UG', 'UGA', 'Uganda'),
+    ('UM', 'UMI', 'United States Minor Outlying Islands'),
+    ('US', 'USA', 'United States'),
+    ('UY', 'URY', 'Uruguay'),
+    ('UZ', 'UZB', 'Uzbekistan'),
+    ('VA', 'VAT', 'Holy See (Vatican City State)'),
+    ('VC', 'VCT', 'Saint Vincent and the Grenadines'),
+    ('VE', 'VEN', 'Venezuela, Bolivarian Republic of'),
+    ('VG', 'VGB', 'Virgin Islands, British'),
+    ('VI', 'VIR', 'Virgin Islands, U.S.'),
+    ('VN', 'VNM', 'Viet Nam'),
+    ('VU', 'VUT', 'Vanuatu'),
+    ('WF', 'WLF', 'Wallis and Futuna'),
+    ('WS', 'WSM', 'Samoa'),
+    ('YE', 'YEM', 'Yemen'),
+    ('YT', 'MYT', 'Mayotte'),
+    ('ZA', 'ZAF', 'South Africa'),
+    ('ZM', 'ZMB', 'Zambia'),
+    ('ZW', 'ZWE', 'Zimbabwe');
+
+-- Resolve existing free-text countries with the same folding as
+-- models.ResolveCountry: case, accents and punctuation are ignored and every
+-- name, code and alias is accepted.
+CREATE FUNCTION pg_temp.country_key(value TEXT) RETURNS TEXT LANGUAGE sql IMMUTABLE AS $$
+    SELECT regexp_replace(
+        btrim(regexp_replace(
+            replace(
+                lower(translate(value,
+                    'ÀÁÂÃÄÅÇÈÉÊËÌÍÎÏÑÒÓÔÕÖØÙÚÛÜÝàáâãäåçèéêëìíîïñòóôõöøùúûüýÿ',
+                    'aaaaaaceeeeiiiinoooooouuuuyaaaaaaceeeeiiiinoooooouuuuyy')),
+                '&', ' and '),
+            '[^a-z0-9]+', ' ', 'g')),
+        '^the ', '')
+$$;
+
+CREATE TEMP TABLE country_keys (key TEXT PRIMARY KEY, code TEXT NOT NULL);
+INSERT INTO country_keys (key, code) VALUES
+    ('abw', 'AW'),
+    ('ad', 'AD'),
+    ('ae', 'AE'),
+    ('af', 'AF'),
+    ('afg', 'AF'),
+    ('afghanistan', 'AF'),
+    ('ag', 'AG'),
+    ('ago', 'AO'),
+    ('ai', 'AI'),
+    ('aia', 'AI'),
+    ('al', 'AL'),
+    ('ala', 'AX'),
+    ('aland islands', 'AX'),
+    ('alb', 'AL'),
+    ('albania', 'AL'),
+    ('algeria', 'DZ'),
+    ('am', 'AM'),
+    ('america', 'US'),
+    ('american samoa', 'AS'),
+    ('and', 'AD'),
+    ('andorra', 'AD'),
+    ('angola', 'AO'),
+    ('anguilla', 'AI'),
+    ('antarctica', 'AQ'),
+    ('antigua and barbuda', 'AG'),
+    ('ao', 'AO'),
+    ('aq', 'AQ'),
+    ('ar', 'AR'),
+    ('arab republic of egypt', 'EG'),
+    ('are', 'AE'),
+    ('arg', 'AR'),
+    ('argentina', 'AR'),
+    ('argentine republic', 'AR'),
+    ('arm', 'AM'),
+    ('armenia', 'AM'),
+    ('aruba', 'AW'),
+    ('as', 'AS'),
+    ('asm', 'AS'),
+    ('at', 'AT'),
+    ('ata', 'AQ'),
+    ('atf', 'TF'),
+    ('atg', 'AG'),
+    ('au', 'AU'),
+    ('aus', 'AU'),
+    ('australia', 'AU'),
+    ('austria', 'AT'),
+    ('aut', 'AT'),
+    ('aw', 'AW'),
+    ('ax', 'AX'),
+    ('az', 'AZ'),
+    ('aze', 'AZ'),
+    ('azerbaijan', 'AZ'),
+    ('ba', 'BA'),
+    ('bahamas', 'BS'),
+    ('bahrain', 'BH'),
+    ('bangladesh', 'BD'),
+    ('barbados', 'BB'),
+    ('bb', 'BB'),
+    ('bd', 'BD'),
+    ('bdi', 'BI'),
+    ('be', 'BE'),
+    ('bel', 'BE'),
+    ('belarus', 'BY'),
+    ('belgium', 'BE'),
+    ('belize', 'BZ'),
+    ('ben', 'BJ'),
+    ('benin', 'BJ'),
+    ('bermuda', 'BM'),
+    ('bes', 'BQ'),
+    ('bf', 'BF'),
+    ('bfa', 'BF'),
+    ('bg', 'BG'),
+    ('bgd', 'BD'),
+    ('bgr', 'BG'),
+    ('bh', 'BH'),
+    ('bhr', 'BH'),
+    ('bhs', 'BS'),
+    ('bhutan', 'BT'),
+    ('bi', 'BI'),
+    ('bih', 'BA'),
+    ('bj', 'BJ'),
+    ('bl', 'BL'),
+    ('blm', 'BL'),
+    ('blr', 'BY'),
+    ('blz', 'BZ'),
+    ('bm', 'BM'),
+    ('bmu', 'BM'),
+    ('bn', 'BN'),
+    ('bo', 'BO'),
+    ('bol', 'BO'),
+    ('bolivarian republic of venezuela', 'VE'),
+    ('bolivia', 'BO'),
+    ('bolivia plurinational state of', 'BO'),
+    ('bonaire sint eustatius and saba', 'BQ'),
+    ('bosnia', 'BA'),
+    ('bosnia and herzegovina', 'BA'),
+    ('botswana', 'BW'),
+    ('bouvet island', 'BV'),
+    ('bq', 'BQ'),
+    ('br', 'BR'),
+    ('bra', 'BR'),
+    ('brazil', 'BR'),
+    ('brb', 'BB'),
+    ('britain', 'GB'),
+    ('british indian ocean territory', 'IO'),
+    ('british virgin islands', 'VG'),
+    ('brn', 'BN'),
+    ('brunei', 'BN'),
+    ('brunei darussalam', 'BN'),
+    ('bs', 'BS'),
+    ('bt', 'BT'),
+    ('btn', 'BT'),
+    ('bulgaria', 'BG'),
+    ('burkina faso', 'BF'),
+    ('burma', 'MM'),
+    ('burundi', 'BI'),
+    ('bv', 'BV'),
+    ('bvt', 'BV'),
+    ('bw', 'BW'),
+    ('bwa', 'BW'),
+    ('by', 'BY'),
+    ('bz', 'BZ'),
+    ('ca', 'CA'),
+    ('cabo verde', 'CV'),
+    ('caf', 'CF'),
+    ('cambodia', 'KH'),
+    ('cameroon', 'CM'),
+    ('can', 'CA'),
+    ('canada', 'CA'),
+    ('cape verde', 'CV'),
+    ('cayman islands', 'KY'),
+    ('cc', 'CC'),
+    ('cck', 'CC'),
+    ('cd', 'CD'),
+    ('central african republic', 'CF'),
+    ('cf', 'CF'),
+    ('cg', 'CG'),
+    ('ch', 'CH'),
+    ('chad', 'TD'),
+    ('che', 'CH'),
+    ('chile', 'CL'),
+    ('china', 'CN'),
+    ('chl', 'CL'),
+    ('chn', 'CN'),
+    ('christmas island', 'CX'),
+    ('ci', 'CI'),
+    ('civ', 'CI'),
+    ('ck', 'CK'),
+    ('cl', 'CL'),
+    ('cm', 'CM'),
+    ('cmr', 'CM'),
+    ('cn', 'CN'),
+    ('co', 'CO'),
+    ('cocos keeling islands', 'CC'),
+    ('cod', 'CD'),
+    ('cog', 'CG'),
+    ('cok', 'CK'),
+    ('col', 'CO'),
+    ('colombia', 'CO'),
+    ('com', 'KM'),
+    ('commonwealth of dominica', 'DM'),
+    ('commonwealth of the bahamas', 'BS'),
+    ('commonwealth of the northern mariana islands', 'MP'),
+    ('comoros', 'KM'),
+    ('congo', 'CG'),
+    ('congo brazzaville', 'CG'),
+    ('congo kinshasa', 'CD'),
+    ('congo the democratic republic of the', 'CD'),
+    ('cook islands', 'CK'),
+    ('costa rica', 'CR'),
+    ('cote d ivoire', 'CI'),
+    ('cpv', 'CV'),
+    ('cr', 'CR'),
+    ('cri', 'CR'),
+    ('croatia', 'HR'),
+    ('cu', 'CU'),
+    ('cub', 'CU'),
+    ('cuba', 'CU'),
+    ('curacao', 'CW'),
+    ('cuw', 'CW'),
+    ('cv', 'CV'),
+    ('cw', 'CW'),
+    ('cx', 'CX'),
+    ('cxr', 'CX'),
+    ('cy', 'CY'),
+    ('cym', 'KY'),
+    ('cyp', 'CY'),
+    ('cyprus', 'CY'),
+    ('cz', 'CZ'),
+    ('cze', 'CZ'),
+    ('czech republic', 'CZ'),
+    ('czechia', 'CZ'),
+    ('de', 'DE'),
+    ('democratic people s republic of korea', 'KP'),
+    ('democratic republic of sao tome and principe', 'ST'),
+    ('democratic republic of the congo', 'CD'),
+    ('democratic republic of timor leste', 'TL'),
+    ('democratic socialist republic of sri lanka', 'LK'),
+    ('denmark', 'DK'),
+    ('deu', 'DE'),
+    ('dj', 'DJ'),
+    ('dji', 'DJ'),
+    ('djibouti', 'DJ'),
+    ('dk', 'DK'),
+    ('dm', 'DM'),
+    ('dma', 'DM'),
+    ('dnk', 'DK'),
+    ('do', 'DO'),
+    ('dom', 'DO'),
+    ('dominica', 'DM'),
+    ('dominican republic', 'DO'),
+    ('dprk', 'KP'),
+    ('dr congo', 'CD'),
+    ('drc', 'CD'),
+    ('dz', 'DZ'),
+    ('dza', 'DZ'),
+    ('east timor', 'TL'),
+    ('eastern republic of uruguay', 'UY'),
+    ('ec', 'EC'),
+    ('ecu', 'EC'),
+    ('ecuador', 'EC'),
+    ('ee', 'EE'),
+    ('eg', 'EG'),
+    ('egy', 'EG'),
+    ('egypt', 'EG'),
+    ('eh', 'EH'),
+    ('el salvador', 'SV'),
+    ('emirates', 'AE'),
+    ('england', 'GB'),
+    ('equatorial guinea', 'GQ'),
+    ('er', 'ER'),
+    ('eri', 'ER'),
+    ('eritrea', 'ER'),
+    ('es', 'ES'),
+    ('esh', 'EH'),
+    ('esp', 'ES'),
+    ('est', 'EE'),
+    ('estonia', 'EE'),
+    ('eswatini', 'SZ'),
+    ('et', 'ET'),
+    ('eth', 'ET'),
+    ('ethiopia', 'ET'),
+    ('falkland islands', 'FK'),
+    ('falkland islands malvinas', 'FK'),
+    ('faroe islands', 'FO'),
+    ('federal democratic republic of ethiopia', 'ET'),
+    ('federal democratic republic of nepal', 'NP'),
+    ('federal republic of germany', 'DE'),
+    ('federal republic of nigeria', 'NG'),
+    ('federal republic of somalia', 'SO'),
+    ('federated states of micronesia', 'FM'),
+    ('federative republic of brazil', 'BR'),
+    ('fi', 'FI'),
+    ('fiji', 'FJ'),
+    ('fin', 'FI'),
+    ('finland', 'FI'),
+    ('fj', 'FJ'),
+    ('fji', 'FJ'),
+    ('fk', 'FK'),
+    ('flk', 'FK'),
+    ('fm', 'FM'),
+    ('fo', 'FO'),
+    ('fr', 'FR'),
+    ('fra', 'FR'),
+    ('france', 'FR'),
+    ('french guiana', 'GF'),
+    ('french polynesia', 'PF'),
+    ('french republic', 'FR'),
+    ('french southern territories', 'TF'),
+    ('fro', 'FO'),
+    ('fsm', 'FM'),
+    ('ga', 'GA'),
+    ('gab', 'GA'),
+    ('gabon', 'GA'),
+    ('gabonese republic', 'GA'),
+    ('gambia', 'GM'),
+    ('gb', 'GB'),
+    ('gbr', 'GB'),
+    ('gd', 'GD'),
+    ('ge', 'GE'),
+    ('geo', 'GE'),
+    ('georgia', 'GE'),
+    ('germany', 'DE'),
+    ('gf', 'GF'),
+    ('gg', 'GG'),
+    ('ggy', 'GG'),
+    ('gh', 'GH'),
+    ('gha', 'GH'),
+    ('ghana', 'GH'),
+    ('gi', 'GI'),
+    ('gib', 'GI'),
+    ('gibraltar', 'GI'),
+    ('gin', 'GN'),
+    ('gl', 'GL'),
+    ('glp', 'GP'),
+    ('gm', 'GM'),
+    ('gmb', 'GM'),
+    ('gn', 'GN'),
+    ('gnb', 'GW'),
+    ('gnq', 'GQ'),
+    ('gp', 'GP'),
+    ('gq', 'GQ'),
+    ('gr', 'GR'),
+    ('grand duchy of luxembourg', 'LU'),
+    ('grc', 'GR'),
+    ('grd', 'GD'),
+    ('great britain', 'GB'),
+    ('greece', 'GR'),
+    ('greenland', 'GL'),
+    ('grenada', 'GD'),
+    ('grl', 'GL'),
+    ('gs', 'GS'),
+    ('gt', 'GT'),
+    ('gtm', 'GT'),
+    ('gu', 'GU'),
+    ('guadeloupe', 'GP'),
+    ('guam', 'GU'),
+    ('guatemala', 'GT'),
+    ('guernsey', 'GG'),
+    ('guf', 'GF'),
+    ('guinea', 'GN'),
+    ('guinea bissau', 'GW'),
+    ('gum', 'GU'),
+    ('guy', 'GY'),
+    ('guyana', 'GY'),
+    ('gw', 'GW'),
+    ('gy', 'GY'),
+    ('haiti', 'HT'),
+    ('hashemite kingdom of jordan', 'JO'),
+    ('heard island and mcdonald islands', 'HM'),
+    ('hellenic republic', 'GR'),
+    ('hk', 'HK'),
+    ('hkg', 'HK'),
+    ('hm', 'HM'),
+    ('hmd', 'HM'),
+    ('hn', 'HN'),
+    ('hnd', 'HN'),
+    ('holland', 'NL'),
+    ('holy see', 'VA'),
+    ('holy see vatican city state', 'VA'),
+    ('honduras', 'HN'),
+    ('hong kong', 'HK'),
+    ('hong kong special administrative region of china', 'HK'),
+    ('hr', 'HR'),
+    ('hrv', 'HR'),
+    ('ht', 'HT'),
+    ('hti', 'HT'),
+    ('hu', 'HU'),
+    ('hun', 'HU'),
+    ('hungary', 'HU'),
+    ('iceland', 'IS'),
+    ('id', 'ID'),
+    ('idn', 'ID'),
+    ('ie', 'IE'),
+    ('il', 'IL'),
+    ('im', 'IM'),
+    ('imn', 'IM'),
+    ('in', 'IN'),
+    ('ind', 'IN'),
+    ('independent state of papua new guinea', 'PG'),
+    ('independent state of samoa', 'WS'),
+    ('india', 'IN'),
+    ('indonesia', 'ID'),
+    ('io', 'IO'),
+    ('iot', 'IO'),
+    ('iq', 'IQ'),
+    ('ir', 'IR'),
+    ('iran', 'IR'),
+    ('iran islamic republic of', 'IR'),
+    ('iraq', 'IQ'),
+    ('ireland', 'IE'),
+    ('irl', 'IE'),
+    ('irn', 'IR'),
+    ('irq', 'IQ'),
+    ('is', 'IS'),
+    ('isl', 'IS'),
+    ('islamic republic of afghanistan', 'AF'),
+    ('islamic republic of iran', 'IR'),
+    ('islamic republic of mauritania', 'MR'),
+    ('islamic republic of pakistan', 'PK'),
+    ('isle of man', 'IM'),
+    ('isr', 'IL'),
+    ('israel', 'IL'),
+    ('it', 'IT'),
+    ('ita', 'IT'),
+    ('italian republic', 'IT'),
+    ('italy', 'IT'),
+    ('ivory coast', 'CI'),
+    ('jam', 'JM'),
+    ('jamaica', 'JM'),
+    ('japan', 'JP'),
+    ('je', 'JE'),
+    ('jersey', 'JE'),
+    ('jey', 'JE'),
+    ('jm', 'JM'),
+    ('jo', 'JO'),
+    ('jor', 'JO'),
+    ('jordan', 'JO'),
+    ('jp', 'JP'),
+    ('jpn', 'JP'),
+    ('kaz', 'KZ'),
+    ('kazakhstan', 'KZ'),
+    ('ke', 'KE'),
+    ('ken', 'KE'),
+    ('kenya', 'KE'),
+    ('kg', 'KG'),
+    ('kgz', 'KG'),
+    ('kh', 'KH'),
+    ('khm', 'KH'),
+    ('ki', 'KI'),
+    ('kingdom of bahrain', 'BH'),
+    ('kingdom of belgium', 'BE'),
+    ('kingdom of bhutan', 'BT'),
+    ('kingdom of cambodia', 'KH'),
+    ('kingdom of denmark', 'DK'),
+    ('kingdom of eswatini', 'SZ'),
+    ('kingdom of lesotho', 'LS'),
+    ('kingdom of morocco', 'MA'),
+    ('kingdom of norway', 'NO'),
+    ('kingdom of saudi arabia', 'SA'),
+    ('kingdom of spain', 'ES'),
+    ('kingdom of sweden', 'SE'),
+    ('kingdom of thailand', 'TH'),
+    ('kingdom of the netherlands', 'NL'),
+    ('kingdom of tonga', 'TO'),
+    ('kir', 'KI'),
+    ('kiribati', 'KI'),
+    ('km', 'KM'),
+    ('kn', 'KN'),
+    ('kna', 'KN'),
+    ('kor', 'KR'),
+    ('korea', 'KR'),
+    ('korea democratic people s republic of', 'KP'),
+    ('korea republic of', 'KR'),
+    ('kp', 'KP'),
+    ('kr', 'KR'),
+    ('kuwait', 'KW'),
+    ('kw', 'KW'),
+    ('kwt', 'KW'),
+    ('ky', 'KY'),
+    ('kyrgyz republic', 'KG'),
+    ('kyrgyzstan', 'KG'),
+    ('kz', 'KZ'),
+    ('la', 'LA'),
+    ('lao', 'LA'),
+    ('lao people s democratic republic', 'LA'),
+    ('laos', 'LA'),
+    ('latvia', 'LV'),
+    ('lb', 'LB'),
+    ('lbn', 'LB'),
+    ('lbr', 'LR'),
+    ('lby', 'LY'),
+    ('lc', 'LC'),
+    ('lca', 'LC'),
+    ('lebanese republic', 'LB'),
+    ('lebanon', 'LB'),
+    ('lesotho', 'LS'),
+    ('li', 'LI'),
+    ('liberia', 'LR'),
+    ('libya', 'LY'),
+    ('lie', 'LI'),
+    ('liechtenstein', 'LI'),
+    ('lithuania', 'LT'),
+    ('lk', 'LK'),
+    ('lka', 'LK'),
+    ('lr', 'LR'),
+    ('ls', 'LS'),
+    ('lso', 'LS'),
+    ('lt', 'LT'),
+    ('ltu', 'LT'),
+    ('lu', 'LU'),
+    ('lux', 'LU'),
+    ('luxembourg', 'LU'),
+    ('lv', 'LV'),
+    ('lva', 'LV'),
+    ('ly', 'LY'),
+    ('ma', 'MA'),
+    ('mac', 'MO'),
+    ('macao', 'MO'),
+    ('macao special administrative region of china', 'MO'),
+    ('macau', 'MO'),
+    ('macedonia', 'MK'),
+    ('madagascar', 'MG'),
+    ('maf', 'MF'),
+    ('malawi', 'MW'),
+    ('malaysia', 'MY'),
+    ('maldives', 'MV'),
+    ('mali', 'ML'),
+    ('malta', 'MT'),
+    ('malvinas', 'FK'),
+    ('mar', 'MA'),
+    ('marshall islands', 'MH'),
+    ('martinique', 'MQ'),
+    ('mauritania', 'MR'),
+    ('mauritius', 'MU'),
+    ('mayotte', 'YT'),
+    ('mc', 'MC'),
+    ('mco', 'MC'),
+    ('md', 'MD'),
+    ('mda', 'MD'),
+    ('mdg', 'MG'),
+    ('mdv', 'MV'),
+    ('me', 'ME'),
+    ('mex', 'MX'),
+    ('mexico', 'MX'),
+    ('mf', 'MF'),
+    ('mg', 'MG'),
+    ('mh', 'MH'),
+    ('mhl', 'MH'),
+    ('micronesia', 'FM'),
+    ('micronesia federated states of', 'FM'),
+    ('mk', 'MK'),
+    ('mkd', 'MK'),
+    ('ml', 'ML'),
+    ('mli', 'ML'),
+    ('mlt', 'MT'),
+    ('mm', 'MM'),
+    ('mmr', 'MM'),
+    ('mn', 'MN'),
+    ('mne', 'ME'),
+    ('mng', 'MN'),
+    ('mnp', 'MP'),
+    ('mo', 'MO'),
+    ('moldova', 'MD'),
+    ('moldova republic of', 'MD'),
+    ('monaco', 'MC'),
+    ('mongolia', 'MN'),
+    ('montenegro', 'ME'),
+    ('montserrat', 'MS'),
+    ('morocco', 'MA'),
+    ('moz', 'MZ'),
+    ('mozambique', 'MZ'),
+    ('mp', 'MP'),
+    ('mq', 'MQ'),
+    ('mr', 'MR'),
+    ('mrt', 'MR'),
+    ('ms', 'MS'),
+    ('msr', 'MS'),
+    ('mt', 'MT'),
+    ('mtq', 'MQ'),
+    ('mu', 'MU'),
+    ('mus', 'MU'),
+    ('mv', 'MV'),
+    ('mw', 'MW'),
+    ('mwi', 'MW'),
+    ('mx', 'MX'),
+    ('my', 'MY'),
+    ('myanmar', 'MM'),
+    ('mys', 'MY'),
+    ('myt', 'YT'),
+    ('mz', 'MZ'),
+    ('na', 'NA'),
+    ('nam', 'NA'),
+    ('namibia', 'NA'),
+    ('nauru', 'NR'),
+    ('nc', 'NC'),
+    ('ncl', 'NC'),
+    ('ne', 'NE'),
+    ('nepal', 'NP'),
+    ('ner', 'NE'),
+    ('netherlands', 'NL'),
+    ('new caledonia', 'NC'),
+    ('new zealand', 'NZ'),
+    ('nf', 'NF'),
+    ('nfk', 'NF'),
+    ('ng', 'NG'),
+    ('nga', 'NG'),
+    ('ni', 'NI'),
+    ('nic', 'NI'),
+    ('nicaragua', 'NI'),
+    ('niger', 'NE'),
+    ('nigeria', 'NG'),
+    ('niu', 'NU'),
+    ('niue', 'NU'),
+    ('nl', 'NL'),
+    ('nld', 'NL'),
+    ('no', 'NO'),
+    ('nor', 'NO'),
+    ('norfolk island', 'NF'),
+    ('north korea', 'KP'),
+    ('north macedonia', 'MK'),
+    ('northern ireland', 'GB'),
+    ('northern mariana islands', 'MP'),
+    ('norway', 'NO'),
+    ('np', 'NP'),
+    ('npl', 'NP'),
+    ('nr', 'NR'),
+    ('nru', 'NR'),
+    ('nu', 'NU'),
+    ('nz', 'NZ'),
+    ('nzl', 'NZ'),
+    ('om', 'OM'),
+    ('oman', 'OM'),
+    ('omn', 'OM'),
+    ('pa', 'PA'),
+    ('pak', 'PK'),
+    ('pakistan', 'PK'),
+    ('palau', 'PW'),
+    ('palestine', 'PS'),
+    ('palestine state of', 'PS'),
+    ('pan', 'PA'),
+    ('panama', 'PA'),
+    ('papua new guinea', 'PG'),
+    ('paraguay', 'PY'),
+    ('pcn', 'PN'),
+    ('pe', 'PE'),
+    ('people s democratic republic of algeria', 'DZ'),
+    ('people s republic of bangladesh', 'BD'),
+    ('people s republic of china', 'CN'),
+    ('per', 'PE'),
+    ('peru', 'PE'),
+    ('pf', 'PF'),
+    ('pg', 'PG'),
+    ('ph', 'PH'),
+    ('philippines', 'PH'),
+    ('phl', 'PH'),
+    ('pitcairn', 'PN'),
+    ('pk', 'PK'),
+    ('pl', 'PL'),
+    ('plurinational state of bolivia', 'BO'),
+    ('plw', 'PW'),
+    ('pm', 'PM'),
+    ('pn', 'PN'),
+    ('png', 'PG'),
+    ('pol', 'PL'),
+    ('poland', 'PL'),
+    ('portugal', 'PT'),
+    ('portuguese republic', 'PT'),
+    ('pr', 'PR'),
+    ('prc', 'CN'),
+    ('pri', 'PR'),
+    ('principality of andorra', 'AD'),
+    ('principality of liechtenstein', 'LI'),
+    ('principality of monaco', 'MC'),
+    ('prk', 'KP'),
+    ('prt', 'PT'),
+    ('pry', 'PY'),
+    ('ps', 'PS'),
+    ('pse', 'PS'),
+    ('pt', 'PT'),
+    ('puerto rico', 'PR'),
+    ('pw', 'PW'),
+    ('py', 'PY'),
+    ('pyf', 'PF'),
+    ('qa', 'QA'),
+    ('qat', 'QA'),
+    ('qatar', 'QA'),
+    ('re', 'RE'),
+    ('republic of albania', 'AL'),
+    ('republic of angola', 'AO'),
+    ('republic of armenia', 'AM'),
+    ('republic of austria', 'AT'),
+    ('republic of azerbaijan', 'AZ'),
+    ('republic of belarus', 'BY'),
+    ('republic of benin', 'BJ'),
+    ('republic of bosnia and herzegovina', 'BA'),
+    ('republic of botswana', 'BW'),
+    ('republic of bulgaria', 'BG'),
+    ('republic of burundi', 'BI'),
+    ('republic of cabo verde', 'CV'),
+    ('republic of cameroon', 'CM'),
+    ('republic of chad', 'TD'),
+    ('republic of chile', 'CL'),
+    ('republic of colombia', 'CO'),
+    ('republic of costa rica', 'CR'),
+    ('republic of cote d ivoire', 'CI'),
+    ('republic of croatia', 'HR'),
+    ('republic of cuba', 'CU'),
+    ('republic of cyprus', 'CY'),
+    ('republic of djibouti', 'DJ'),
+    ('republic of ecuador', 'EC'),
+    ('republic of el salvador', 'SV'),
+    ('republic of equatorial guinea', 'GQ'),
+    ('republic of estonia', 'EE'),
+    ('republic of fiji', 'FJ'),
+    ('republic of finland', 'FI'),
+    ('republic of ghana', 'GH'),
+    ('republic of guatemala', 'GT'),
+    ('republic of guinea', 'GN'),
+    ('republic of guinea bissau', 'GW'),
+    ('republic of guyana', 'GY'),
+    ('republic of haiti', 'HT'),
+    ('republic of honduras', 'HN'),
+    ('republic of iceland', 'IS'),
+    ('republic of india', 'IN'),
+    ('republic of indonesia', 'ID'),
+    ('republic of iraq', 'IQ'),
+    ('republic of kazakhstan', 'KZ'),
+    ('republic of kenya', 'KE'),
+    ('republic of kiribati', 'KI'),
+    ('republic of korea', 'KR'),
+    ('republic of latvia', 'LV'),
+    ('republic of liberia', 'LR'),
+    ('republic of lithuania', 'LT'),
+    ('republic of madagascar', 'MG'),
+    ('republic of malawi', 'MW'),
+    ('republic of maldives', 'MV'),
+    ('republic of mali', 'ML'),
+    ('republic of malta', 'MT'),
+    ('republic of mauritius', 'MU'),
+    ('republic of moldova', 'MD'),
+    ('republic of mozambique', 'MZ'),
+    ('republic of myanmar', 'MM'),
+    ('republic of namibia', 'NA'),
+    ('republic of nauru', 'NR'),
+    ('republic of nicaragua', 'NI'),
+    ('republic of north macedonia', 'MK'),
+    ('republic of palau', 'PW'),
+    ('republic of panama', 'PA'),
+    ('republic of paraguay', 'PY'),
+    ('republic of peru', 'PE'),
+    ('republic of poland', 'PL'),
+    ('republic of san marino', 'SM'),
+    ('republic of senegal', 'SN'),
+    ('republic of serbia', 'RS'),
+    ('republic of seychelles', 'SC'),
+    ('republic of sierra leone', 'SL'),
+    ('republic of singapore', 'SG'),
+    ('republic of slovenia', 'SI'),
+    ('republic of south africa', 'ZA'),
+    ('republic of south sudan', 'SS'),
+    ('republic of suriname', 'SR'),
+    ('republic of tajikistan', 'TJ'),
+    ('republic of the congo', 'CG'),
+    ('republic of the gambia', 'GM'),
+    ('republic of the marshall islands', 'MH'),
+    ('republic of the niger', 'NE'),
+    ('republic of the philippines', 'PH'),
+    ('republic of the sudan', 'SD'),
+    ('republic of trinidad and tobago', 'TT'),
+    ('republic of tunisia', 'TN'),
+    ('republic of turkiye', 'TR'),
+    ('republic of uganda', 'UG'),
+    ('republic of uzbekistan', 'UZ'),
+    ('republic of vanuatu', 'VU'),
+    ('republic of yemen', 'YE'),
+    ('republic of zambia', 'ZM'),
+    ('republic of zimbabwe', 'ZW'),
+    ('reu', 'RE'),
+    ('reunion', 'RE'),
+    ('ro', 'RO'),
+    ('romania', 'RO'),
+    ('rou', 'RO'),
+    ('rs', 'RS'),
+    ('ru', 'RU'),
+    ('rus', 'RU'),
+    ('russia', 'RU'),
+    ('russian federation', 'RU'),
+    ('rw', 'RW'),
+    ('rwa', 'RW'),
+    ('rwanda', 'RW'),
+    ('rwandese republic', 'RW'),
+    ('sa', 'SA'),
+    ('saint barthelemy', 'BL'),
+    ('saint helena ascension and tristan da cunha', 'SH'),
+    ('saint kitts and nevis', 'KN'),
+    ('saint lucia', 'LC'),
+    ('saint martin french part', 'MF'),
+    ('saint pierre and miquelon', 'PM'),
+    ('saint vincent and the grenadines', 'VC'),
+    ('samoa', 'WS'),
+    ('san marino', 'SM'),
+    ('sao tome and principe', 'ST'),
+    ('sau', 'SA'),
+    ('saudi arabia', 'SA'),
+    ('sb', 'SB'),
+    ('sc', 'SC'),
+    ('scotland', 'GB'),
+    ('sd', 'SD'),
+    ('sdn', 'SD'),
+    ('se', 'SE'),
+    ('sen', 'SN'),
+    ('senegal', 'SN'),
+    ('serbia', 'RS'),
+    ('seychelles', 'SC'),
+    ('sg', 'SG'),
+    ('sgp', 'SG'),
+    ('sgs', 'GS'),
+    ('sh', 'SH'),
+    ('shn', 'SH'),
+    ('si', 'SI'),
+    ('sierra leone', 'SL'),
+    ('singapore', 'SG'),
+    ('sint maarten dutch part', 'SX'),
+    ('sj', 'SJ'),
+    ('sjm', 'SJ'),
+    ('sk', 'SK'),
+    ('sl', 'SL'),
+    ('slb', 'SB'),
+    ('sle', 'SL'),
+    ('slovak republic', 'SK'),
+    ('slovakia', 'SK'),
+    ('slovenia', 'SI'),
+    ('slv', 'SV'),
+    ('sm', 'SM'),
+    ('smr', 'SM'),
+    ('sn', 'SN'),
+    ('so', 'SO'),
+    ('socialist republic of viet nam', 'VN'),
+    ('solomon islands', 'SB'),
+    ('som', 'SO'),
+    ('somalia', 'SO'),
+    ('south africa', 'ZA'),
+    ('south georgia and the south sandwich islands', 'GS'),
+    ('south korea', 'KR'),
+    ('south sudan', 'SS'),
+    ('spain', 'ES'),
+    ('spm', 'PM'),
+    ('sr', 'SR'),
+    ('srb', 'RS'),
+    ('sri lanka', 'LK'),
+    ('ss', 'SS'),
+    ('ssd', 'SS'),
+    ('st', 'ST'),
+    ('state of eritrea', 'ER'),
+    ('state of israel', 'IL'),
+    ('state of kuwait', 'KW'),
+    ('state of palestine', 'PS'),
+    ('state of qatar', 'QA'),
+    ('stp', 'ST'),
+    ('sudan', 'SD'),
+    ('sultanate of oman', 'OM'),
+    ('sur', 'SR'),
+    ('suriname', 'SR'),
+    ('sv', 'SV'),
+    ('svalbard and jan mayen', 'SJ'),
+    ('svk', 'SK'),
+    ('svn', 'SI'),
+    ('swaziland', 'SZ'),
+    ('swe', 'SE'),
+    ('sweden', 'SE'),
+    ('swiss confederation', 'CH'),
+    ('switzerland', 'CH'),
+    ('swz', 'SZ'),
+    ('sx', 'SX'),
+    ('sxm', 'SX'),
+    ('sy', 'SY'),
+    ('syc', 'SC'),
+    ('syr', 'SY'),
+    ('syria', 'SY'),
+    ('syrian arab republic', 'SY'),
+    ('sz', 'SZ'),
+    ('taiwan', 'TW'),
+    ('taiwan province of china', 'TW'),
+    ('tajikistan', 'TJ'),
+    ('tanzania', 'TZ'),
+    ('tanzania united republic of', 'TZ'),
+    ('tc', 'TC'),
+    ('tca', 'TC'),
+    ('tcd', 'TD'),
+    ('td', 'TD'),
+    ('tf', 'TF'),
+    ('tg', 'TG'),
+    ('tgo', 'TG'),
+    ('th', 'TH'),
+    ('tha', 'TH'),
+    ('thailand', 'TH'),
+    ('timor leste', 'TL'),
+    ('tj', 'TJ'),
+    ('tjk', 'TJ'),
+    ('tk', 'TK'),
+    ('tkl', 'TK'),
+    ('tkm', 'TM'),
+    ('tl', 'TL'),
+    ('tls', 'TL'),
+    ('tm', 'TM'),
+    ('tn', 'TN'),
+    ('to', 'TO'),
+    ('togo', 'TG'),
+    ('togolese republic', 'TG'),
+    ('tokelau', 'TK'),
+    ('ton', 'TO'),
+    ('tonga', 'TO'),
+    ('tr', 'TR'),
+    ('trinidad and tobago', 'TT'),
+    ('tt', 'TT'),
+    ('tto', 'TT'),
+    ('tun', 'TN'),
+    ('tunisia', 'TN'),
+    ('tur', 'TR'),
+    ('turkey', 'TR'),
+    ('turkiye', 'TR'),
+    ('turkmenistan', 'TM'),
+    ('turks and caicos islands', 'TC'),
+    ('tuv', 'TV'),
+    ('tuvalu', 'TV'),
+    ('tv', 'TV'),
+    ('tw', 'TW'),
+    ('twn', 'TW'),
+    ('tz', 'TZ'),
+    ('tza', 'TZ'),
+    ('u k', 'GB'),
+    ('u s', 'US'),
+    ('u s a', 'US'),
+    ('ua', 'UA'),
+    ('uae', 'AE'),
+    ('ug', 'UG'),
+    ('uga', 'UG'),
+    ('uganda', 'UG'),
+    ('uk', 'GB'),
+    ('ukr', 'UA'),
+    ('ukraine', 'UA'),
+    ('um', 'UM'),
+    ('umi', 'UM'),
+    ('union of the comoros', 'KM'),
+    ('united arab emirates', 'AE'),
+    ('united kingdom', 'GB'),
+    ('united kingdom of great britain and northern ireland', 'GB'),
+    ('united mexican states', 'MX'),
+    ('united republic of tanzania', 'TZ'),
+    ('united states', 'US'),
+    ('united states minor outlying islands', 'UM'),
+    ('united states of america', 'US'),
+    ('uruguay', 'UY'),
+    ('ury', 'UY'),
+    ('us', 'US'),
+    ('us virgin islands', 'VI'),
+    ('usa', 'US'),
+    ('uy', 'UY'),
+    ('uz', 'UZ'),
+    ('uzb', 'UZ'),
+    ('uzbekistan', 'UZ'),
+    ('va', 'VA'),
+    ('vanuatu', 'VU'),
+    ('vat', 'VA'),
+    ('vatican', 'VA'),
+    ('vatican city', 'VA'),
+    ('vc', 'VC'),
+    ('vct', 'VC'),
+    ('ve', 'VE'),
+    ('ven', 'VE'),
+    ('venezuela', 'VE'),
+    ('venezuela bolivarian republic of', 'VE'),
+    ('vg', 'VG'),
+    ('vgb', 'VG'),
+    ('vi', 'VI'),
+    ('viet nam', 'VN'),
+    ('vietnam', 'VN'),
+    ('vir', 'VI'),
+    ('virgin islands british', 'VG'),
+    ('virgin islands of the united states', 'VI'),
+    ('virgin islands u s', 'VI'),
+    ('vn', 'VN'),
+    ('vnm', 'VN'),
+    ('vu', 'VU'),
+    ('vut', 'VU'),
+    ('wales', 'GB'),
+    ('wallis and futuna', 'WF'),
+    ('western sahara', 'EH'),
+    ('wf', 'WF'),
+    ('wlf', 'WF'),
+    ('ws', 'WS'),
+    ('wsm', 'WS'),
+    ('ye', 'YE'),
+    ('yem', 'YE'),
+    ('yemen', 'YE'),
+    ('yt', 'YT'),
+    ('za', 'ZA'),
+    ('zaf', 'ZA'),
+    ('zambia', 'ZM'),
+    ('zimbabwe', 'ZW'),
+    ('zm', 'ZM'),
+    ('zmb', 'ZM'),
+    ('zw', 'ZW'),
+    ('zwe', 'ZW');
+
+-- A data fix, not an edit: leave updated_at alone.
+ALTER TABLE targets DISABLE TRIGGER targets_touch_updated_at;
+UPDATE targets t
+SET country = k.code
+FROM country_keys k
+WHERE k.key = pg_temp.country_key(t.country) AND t.country <> k.code;
+ALTER TABLE targets ENABLE TRIGGER targets_touch_updated_at;
+
+DROP TABLE country_keys;
+DROP FUNCTION pg_temp.country_key(TEXT);
+
+-- Targets whose country could not be resolved keep their text and show up
+-- here until someone fixes them with PUT /target/:id/country.
+CREATE VIEW unresolved_target_countries AS
+SELECT t.id AS target_id, t.mission_id, t.name, t.country
+FROM targets t
+WHERE NOT EXISTS (SELECT 1 FROM countries c WHERE c.code = t.country);
+
+DO $$
+DECLARE
+    unresolved INT;
+    vals TEXT;
+BEGIN
+    SELECT COUNT(*), string_agg(DISTINCT quote_literal(country), ', ')
+        INTO unresolved, vals
+        FROM unresolved_target_countries;
+    IF unresolved > 0 THEN
+        RAISE NOTICE '% target(s) have an unresolved country: %', unresolved, vals;
+    END IF;
+END;
+$$;
+
+-- NOT VALID enforces the reference for new and changed countries only, so
+-- unresolved rows do not block the migration.
+ALTER TABLE targets
+    ADD CONSTRAINT fk_targets_country FOREIGN KEY (country) REFERENCES countries (code) NOT VALID;
+
+-- The search vector now indexes the country's name rather than its code,
+-- which a generated column cannot look up.
+ALTER TABLE targets DROP COLUMN search_vector;
+ALTER TABLE targets ADD COLUMN search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION targets_search_vector() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    country_name TEXT;
+BEGIN
+    SELECT name INTO country_name FROM countries WHERE code = NEW.country;
+    NEW.search_vector :=
+        setweight(to_tsvector('english', coalesce(NEW.name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(country_name, NEW.country, '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(NEW.notes, '')), 'C');
+    RETURN NEW;
+END;
+$$;
+CREATE TRIGGER trg_targets_search_vector
+    BEFORE INSERT OR UPDATE OF name, country, notes ON targets
+    FOR EACH ROW EXECUTE FUNCTION targets_search_vector();
+
+ALTER TABLE targets DISABLE TRIGGER targets_touch_updated_at;
+UPDATE targets SET name = name;
+ALTER TABLE targets ENABLE TRIGGER targets_touch_updated_at;
+CREATE INDEX idx_targets_search ON targets USING GIN (search_vector);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_targets_location;
+ALTER TABLE targets
+    DROP CONSTRAINT IF EXISTS chk_targets_coordinates_pair,
+    DROP COLUMN IF EXISTS address,
+    DROP COLUMN IF EXISTS city,
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,11 @@
+ALTER TABLE targets
+    ADD COLUMN latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90),
+    ADD COLUMN longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180),
+    ADD COLUMN city TEXT,
+    ADD COLUMN address TEXT,
+    ADD CONSTRAINT chk_targets_coordinates_pair CHECK ((latitude IS NULL) = (longitude IS NULL));
+
+-- Proximity queries narrow to a bounding box on this index before computing
+-- great-circle distances.
+CREATE INDEX idx_targets_location ON targets (latitude, longitude)
+    WHERE latitude IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_prevent_delete_completed_evidence ON target_attachments;
+DROP FUNCTION IF EXISTS prevent_delete_completed_evidence();
+DROP TABLE IF EXISTS target_attachments;
@@ -0,0 +1,25 @@
+CREATE TABLE target_attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    target_id UUID NOT NULL REFERENCES targets(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL CHECK (char_length(filename) > 0),
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes >= 0),
+    sha256 TEXT NOT NULL CHECK (sha256 ~ '^[0-9a-f]{64}$'),
+    storage_key TEXT NOT NULL UNIQUE,
+    uploaded_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_target_attachments_target_id ON target_attachments (target_id, created_at);
+
+-- Evidence of a completed target is part of the record.
+CREATE OR REPLACE FUNCTION prevent_delete_completed_evidence() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM targets WHERE id = OLD.target_id AND completed) THEN
+        RAISE EXCEPTION 'Cannot delete attachment %: target % is completed', OLD.id, OLD.target_id;
+    END IF;
+    RETURN OLD;
+END;
+$$;
+CREATE TRIGGER trg_prevent_delete_completed_evidence
+    BEFORE DELETE ON target_attachments
+    FOR EACH ROW EXECUTE FUNCTION prevent_delete_completed_evidence();
@@ -0,0 +1,15 @@
+CREATE TABLE mission_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE CHECK (char_length(name) > 0),
+    title TEXT NOT NULL CHECK (char_length(title) > 0),
+    description TEXT,
+    -- Placeholder targets, see models.TemplateTarget.
+    targets JSONB NOT NULL CHECK (jsonb_typeof(targets) = 'array'
+        AND jsonb_array_length(targets) BETWEEN 1 AND 3),
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE TRIGGER mission_templates_touch_updated_at BEFORE UPDATE ON mission_templates
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
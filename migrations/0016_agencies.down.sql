@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS agency_breeds;
+
+ALTER TABLE mission_templates DROP CONSTRAINT IF EXISTS uq_mission_templates_agency_name;
+ALTER TABLE mission_templates ADD CONSTRAINT mission_templates_name_key UNIQUE (name);
+
+ALTER TABLE mission_templates DROP COLUMN IF EXISTS agency_id;
+ALTER TABLE target_attachments DROP COLUMN IF EXISTS agency_id;
+ALTER TABLE cat_leaves DROP COLUMN IF EXISTS agency_id;
+ALTER TABLE mission_assignments DROP COLUMN IF EXISTS agency_id;
+ALTER TABLE targets DROP COLUMN IF EXISTS agency_id;
+ALTER TABLE missions DROP COLUMN IF EXISTS agency_id;
+ALTER TABLE cats DROP COLUMN IF EXISTS agency_id;
+
+DROP TABLE IF EXISTS agencies;
@@ -0,0 +1,74 @@
+CREATE TABLE agencies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug TEXT NOT NULL UNIQUE CHECK (slug ~ '^[a-z][a-z0-9-]{0,62}$'),
+    name TEXT NOT NULL CHECK (char_length(name) > 0),
+    -- Overrides of the service configuration, see models.AgencySettings.
+    settings JSONB NOT NULL DEFAULT '{}' CHECK (jsonb_typeof(settings) = 'object'),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE TRIGGER agencies_touch_updated_at BEFORE UPDATE ON agencies
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+
+-- Everything that exists so far belongs to the default agency.
+INSERT INTO agencies (id, slug, name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'default', 'Spy Cat Agency');
+
+-- Every agency-owned table gets agency_id. The default only backfills the
+-- existing rows; new rows must name their agency.
+ALTER TABLE cats ADD COLUMN agency_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES agencies(id);
+ALTER TABLE missions ADD COLUMN agency_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES agencies(id);
+ALTER TABLE targets ADD COLUMN agency_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES agencies(id);
+ALTER TABLE mission_assignments ADD COLUMN agency_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES agencies(id);
+ALTER TABLE cat_leaves ADD COLUMN agency_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES agencies(id);
+ALTER TABLE target_attachments ADD COLUMN agency_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES agencies(id);
+ALTER TABLE mission_templates ADD COLUMN agency_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES agencies(id);
+
+ALTER TABLE cats ALTER COLUMN agency_id DROP DEFAULT;
+ALTER TABLE missions ALTER COLUMN agency_id DROP DEFAULT;
+ALTER TABLE targets ALTER COLUMN agency_id DROP DEFAULT;
+ALTER TABLE mission_assignments ALTER COLUMN agency_id DROP DEFAULT;
+ALTER TABLE cat_leaves ALTER COLUMN agency_id DROP DEFAULT;
+ALTER TABLE target_attachments ALTER COLUMN agency_id DROP DEFAULT;
+ALTER TABLE mission_templates ALTER COLUMN agency_id DROP DEFAULT;
+
+-- Rows may only point at rows of their own agency.
+ALTER TABLE cats ADD CONSTRAINT uq_cats_agency UNIQUE (id, agency_id);
+ALTER TABLE missions ADD CONSTRAINT uq_missions_agency UNIQUE (id, agency_id);
+ALTER TABLE targets ADD CONSTRAINT uq_targets_agency UNIQUE (id, agency_id);
+
+ALTER TABLE targets ADD CONSTRAINT fk_targets_mission_agency
+    FOREIGN KEY (mission_id, agency_id) REFERENCES missions (id, agency_id) ON DELETE CASCADE;
+ALTER TABLE mission_assignments ADD CONSTRAINT fk_assignments_mission_agency
+    FOREIGN KEY (mission_id, agency_id) REFERENCES missions (id, agency_id) ON DELETE CASCADE;
+ALTER TABLE mission_assignments ADD CONSTRAINT fk_assignments_cat_agency
+    FOREIGN KEY (cat_id, agency_id) REFERENCES cats (id, agency_id) ON DELETE SET NULL (cat_id);
+ALTER TABLE cat_leaves ADD CONSTRAINT fk_leaves_cat_agency
+    FOREIGN KEY (cat_id, agency_id) REFERENCES cats (id, agency_id) ON DELETE CASCADE;
+ALTER TABLE target_attachments ADD CONSTRAINT fk_attachments_target_agency
+    FOREIGN KEY (target_id, agency_id) REFERENCES targets (id, agency_id) ON DELETE CASCADE;
+
+CREATE INDEX idx_cats_agency ON cats (agency_id, name);
+CREATE INDEX idx_missions_agency ON missions (agency_id, created_at);
+CREATE INDEX idx_targets_agency ON targets (agency_id);
+CREATE INDEX idx_mission_assignments_agency ON mission_assignments (agency_id, cat_id);
+
+ALTER TABLE mission_templates DROP CONSTRAINT mission_templates_name_key;
+ALTER TABLE mission_templates ADD CONSTRAINT uq_mission_templates_agency_name UNIQUE (agency_id, name);
+
+-- How an agency sees a breed: under another name, or not at all for new cats.
+CREATE TABLE agency_breeds (
+    agency_id UUID NOT NULL REFERENCES agencies(id) ON DELETE CASCADE,
+    breed_id UUID NOT NULL REFERENCES breeds(id) ON DELETE CASCADE,
+    name TEXT CHECK (char_length(name) > 0),
+    hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (agency_id, breed_id)
+);
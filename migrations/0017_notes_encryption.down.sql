@@ -0,0 +1,30 @@
+-- Notes stay encrypted; only the service can turn them back into text.
+DROP TRIGGER targets_touch_updated_at ON targets;
+CREATE TRIGGER targets_touch_updated_at BEFORE UPDATE ON targets
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+
+DROP TRIGGER trg_prevent_notes_update_if_completed ON targets;
+CREATE TRIGGER trg_prevent_notes_update_if_completed
+    BEFORE UPDATE ON targets
+    FOR EACH ROW EXECUTE FUNCTION prevent_notes_update_if_completed();
+
+CREATE OR REPLACE FUNCTION targets_search_vector() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    country_name TEXT;
+BEGIN
+    SELECT name INTO country_name FROM countries WHERE code = NEW.country;
+    NEW.search_vector :=
+        setweight(to_tsvector('english', coalesce(NEW.name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(country_name, NEW.country, '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(NEW.notes, '')), 'C');
+    RETURN NEW;
+END;
+$$;
+DROP TRIGGER trg_targets_search_vector ON targets;
+CREATE TRIGGER trg_targets_search_vector
+    BEFORE INSERT OR UPDATE OF name, country, notes ON targets
+    FOR EACH ROW EXECUTE FUNCTION targets_search_vector();
+
+ALTER TABLE targets DISABLE TRIGGER targets_touch_updated_at;
+UPDATE targets SET name = name;
+ALTER TABLE targets ENABLE TRIGGER targets_touch_updated_at;
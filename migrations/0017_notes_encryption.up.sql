@@ -0,0 +1,37 @@
+-- Target notes are encrypted by the service from now on, so the search
+-- vector indexes a target's name and country only.
+CREATE OR REPLACE FUNCTION targets_search_vector() RETURNS trigger LANGUAGE plpgsql AS $$
+DECLARE
+    country_name TEXT;
+BEGIN
+    SELECT name INTO country_name FROM countries WHERE code = NEW.country;
+    NEW.search_vector :=
+        setweight(to_tsvector('english', coalesce(NEW.name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(country_name, NEW.country, '')), 'B');
+    RETURN NEW;
+END;
+$$;
+DROP TRIGGER trg_targets_search_vector ON targets;
+CREATE TRIGGER trg_targets_search_vector
+    BEFORE INSERT OR UPDATE OF name, country ON targets
+    FOR EACH ROW EXECUTE FUNCTION targets_search_vector();
+
+ALTER TABLE targets DISABLE TRIGGER targets_touch_updated_at;
+UPDATE targets SET name = name;
+ALTER TABLE targets ENABLE TRIGGER targets_touch_updated_at;
+
+-- Key rotation rewrites the notes of completed targets too, and is not an
+-- edit: with sca.maintenance on for its transaction, neither the notes
+-- guard nor updated_at fire.
+DROP TRIGGER trg_prevent_notes_update_if_completed ON targets;
+CREATE TRIGGER trg_prevent_notes_update_if_completed
+    BEFORE UPDATE ON targets
+    FOR EACH ROW
+    WHEN (current_setting('sca.maintenance', true) IS DISTINCT FROM 'on')
+    EXECUTE FUNCTION prevent_notes_update_if_completed();
+
+DROP TRIGGER targets_touch_updated_at ON targets;
+CREATE TRIGGER targets_touch_updated_at BEFORE UPDATE ON targets
+    FOR EACH ROW
+    WHEN (current_setting('sca.maintenance', true) IS DISTINCT FROM 'on')
+    EXECUTE FUNCTION touch_updated_at();
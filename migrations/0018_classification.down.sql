@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS classified_reads;
+DROP TABLE IF EXISTS principals;
+ALTER TABLE cats DROP COLUMN IF EXISTS clearance;
+ALTER TABLE targets DROP COLUMN IF EXISTS classification;
+ALTER TABLE missions DROP COLUMN IF EXISTS classification;
+DROP DOMAIN IF EXISTS classification;
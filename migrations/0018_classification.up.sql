@@ -0,0 +1,37 @@
+CREATE DOMAIN classification AS TEXT
+    CHECK (VALUE IN ('unclassified', 'confidential', 'secret', 'top_secret'));
+
+ALTER TABLE missions ADD COLUMN classification classification NOT NULL DEFAULT 'unclassified';
+ALTER TABLE targets ADD COLUMN classification classification NOT NULL DEFAULT 'unclassified';
+ALTER TABLE cats ADD COLUMN clearance classification NOT NULL DEFAULT 'unclassified';
+
+-- API callers, named by the X-Actor header, with the clearance they hold in
+-- one agency. Callers without a row are unclassified.
+CREATE TABLE principals (
+    agency_id UUID NOT NULL REFERENCES agencies(id),
+    name TEXT NOT NULL CHECK (char_length(name) > 0),
+    clearance classification NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (agency_id, name)
+);
+
+CREATE TRIGGER principals_touch_updated_at BEFORE UPDATE ON principals
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+
+-- Who was shown, or refused, classified data. object_id has no foreign key
+-- so the trail outlives purged missions and targets.
+CREATE TABLE classified_reads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agency_id UUID NOT NULL REFERENCES agencies(id),
+    principal TEXT NOT NULL,
+    clearance classification NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('mission', 'target')),
+    object_id UUID NOT NULL,
+    classification classification NOT NULL,
+    outcome TEXT NOT NULL CHECK (outcome IN ('read', 'withheld')),
+    path TEXT NOT NULL,
+    read_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_classified_reads_agency ON classified_reads (agency_id, read_at DESC);
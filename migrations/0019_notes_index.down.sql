@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_targets_notes_index;
+ALTER TABLE targets DROP COLUMN IF EXISTS notes_index;
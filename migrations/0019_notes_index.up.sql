@@ -0,0 +1,4 @@
+-- Blind index of target notes: the keyed hashes of the words in each note,
+-- written by the service. NULL until the note is written or reindexed.
+ALTER TABLE targets ADD COLUMN notes_index TEXT[];
+CREATE INDEX idx_targets_notes_index ON targets USING GIN (notes_index);
@@ -0,0 +1,3 @@
+DROP TRIGGER mission_templates_touch_updated_at ON mission_templates;
+CREATE TRIGGER mission_templates_touch_updated_at BEFORE UPDATE ON mission_templates
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
@@ -0,0 +1,7 @@
+-- Placeholder notes in templates are sealed like target notes, and key
+-- rotation rewrites them without it counting as an edit.
+DROP TRIGGER mission_templates_touch_updated_at ON mission_templates;
+CREATE TRIGGER mission_templates_touch_updated_at BEFORE UPDATE ON mission_templates
+    FOR EACH ROW
+    WHEN (current_setting('sca.maintenance', true) IS DISTINCT FROM 'on')
+    EXECUTE FUNCTION touch_updated_at();
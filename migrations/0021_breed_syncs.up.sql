@@ -0,0 +1,13 @@
+-- The last breed sync that succeeded. Readiness looks here rather than at
+-- the breeds table, which the seed rows of 0001_init never leave empty.
+CREATE TABLE breed_syncs (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    source TEXT NOT NULL CHECK (source IN ('api', 'not_modified', 'snapshot')),
+    synced_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Only an API sync fills in profiles, so a database that has them was
+-- synced before this table existed.
+INSERT INTO breed_syncs (source, synced_at)
+SELECT 'api', max(updated_at) FROM breeds WHERE profile <> '{}'
+HAVING count(*) > 0;